@@ -0,0 +1,164 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+const flagFrom = "from"
+const flagDuration = "duration"
+const flagMonthEnd = "month-end"
+const flagTimezone = "tz"
+
+func NewAddCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newAddCmd(iostreams, systemClock{})
+}
+
+func newAddCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var addCmd = &cobra.Command{
+		Use:   "add",
+		Short: "Adds a duration to a date",
+		Long: heredoc.Doc(`
+			Adds a duration to a date.
+
+			The duration can be an ISO 8601 duration (e.g. P1Y2M10DT2H30M, P2W)
+			or a Go duration (e.g. 1h30m, 90s).
+
+			Years, months, weeks and days are calendar units: adding P1D
+			keeps the wall clock time even across a daylight saving change.
+			Hours, minutes and seconds are exact.
+
+			When adding months lands on a day that doesn't exist (e.g.
+			January 31 + 1 month) the --month-end rule decides the result:
+				clamp    = the last day of the month (February 28)
+				overflow = the extra days overflow to the next month (March 3)
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime add -f 2021-12-08T12:00:00Z -d P1M2DT3H
+			canivete datetime add -f 2022-01-31 -d P1M --month-end overflow
+			canivete datetime add -f "2022-03-26 10:00" -d P1D --tz Europe/Lisbon
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, err := runAdd(cmd, false, clock.Now())
+			if err != nil {
+				return err
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	addDateArithmeticFlags(addCmd)
+
+	return addCmd
+}
+
+func NewSubCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newSubCmd(iostreams, systemClock{})
+}
+
+func newSubCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var subCmd = &cobra.Command{
+		Use:   "sub",
+		Short: "Subtracts a duration from a date",
+		Long: heredoc.Doc(`
+			Subtracts a duration from a date.
+
+			Accepts the same durations and month-end rules as the add command,
+			e.g. March 31 - 1 month is February 28 with the clamp rule.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime sub -f 2022-03-31 -d P1M
+			canivete datetime sub -f now -d 36h
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, err := runAdd(cmd, true, clock.Now())
+			if err != nil {
+				return err
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	addDateArithmeticFlags(subCmd)
+
+	return subCmd
+}
+
+func addDateArithmeticFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(
+		flagFrom,
+		"f",
+		"now",
+		"the base date (e.g. 2021-12-08, 2021-12-08T12:00:00Z, 1638964800)")
+
+	cmd.Flags().StringP(
+		flagDuration,
+		"d",
+		"",
+		"the duration (e.g. P1M2DT3H, P2W, 1h30m)")
+	cmd.MarkFlagRequired(flagDuration)
+
+	cmd.Flags().String(
+		flagMonthEnd,
+		monthEndClamp,
+		"what to do when the day doesn't exist in the resulting month: clamp or overflow")
+
+	cmd.Flags().String(
+		flagTimezone,
+		"UTC",
+		"the IANA timezone used for dates without offset and for calendar units (e.g. Europe/Lisbon)")
+}
+
+func runAdd(cmd *cobra.Command, subtract bool, now time.Time) (fromUnixOutput, error) {
+	from, _ := cmd.Flags().GetString(flagFrom)
+	durationValue, _ := cmd.Flags().GetString(flagDuration)
+	monthEnd, _ := cmd.Flags().GetString(flagMonthEnd)
+	tz, _ := cmd.Flags().GetString(flagTimezone)
+
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return fromUnixOutput{}, err
+	}
+
+	base, err := parseInstant(from, loc, now)
+	if err != nil {
+		return fromUnixOutput{}, err
+	}
+
+	d, err := parseDuration(durationValue)
+	if err != nil {
+		return fromUnixOutput{}, err
+	}
+
+	if subtract {
+		d = d.negate()
+	}
+
+	result, err := d.addTo(base, monthEnd)
+	if err != nil {
+		return fromUnixOutput{}, err
+	}
+
+	return newFromUnixOutput(result), nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"testing"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewAddCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"--from=2021-12-08T12:00:00Z", "--duration=P1M2DT3H"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), "Mon Jan 10 15:00:00 UTC 2022")
+}
+
+func TestAddCmdMonthEnd(t *testing.T) {
+	testCases := []struct {
+		monthEnd string
+		expected string
+	}{
+		{monthEnd: "clamp", expected: "Mon Feb 28 00:00:00 UTC 2022"},
+		{monthEnd: "overflow", expected: "Thu Mar  3 00:00:00 UTC 2022"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewAddCmd(*iostreams)
+
+		// act
+		cmd.SetArgs([]string{"-f=2022-01-31", "-d=P1M", "--month-end=" + tc.monthEnd})
+		_, err := cmd.ExecuteC()
+
+		// assert
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, out.String(), tc.expected)
+	}
+}
+
+func TestAddCmdKeepsWallClockAcrossDst(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewAddCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"-f=2022-03-26 10:00", "-d=P1D", "--tz=Europe/Lisbon"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), "Sun Mar 27 10:00:00 WEST 2022")
+	assert.Contains(t, out.String(), "Sun Mar 27 09:00:00 UTC 2022")
+}
+
+func TestSubCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewSubCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"-f=2022-03-31T08:00:00Z", "-d=P1MT90M"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), "Mon Feb 28 06:30:00 UTC 2022")
+}
+
+func TestAddCmdInvalidValues(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"-f=yesterday-ish", "-d=P1D"}, expected: "invalid date or time"},
+		{args: []string{"-f=2022-01-01", "-d=P1X"}, expected: "invalid duration"},
+		{args: []string{"-f=2022-01-01", "-d=P1D", "--tz=Mars/Olympus"}, expected: "invalid timezone"},
+		{args: []string{"-f=2022-01-01", "-d=P1D", "--month-end=round"}, expected: "invalid month-end rule"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewAddCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(tc.args)
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), tc.expected)
+	}
+}
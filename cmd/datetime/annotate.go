@@ -0,0 +1,315 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+const flagMode = "mode"
+const flagFormat = "format"
+const flagInputTimezone = "input-tz"
+const flagUnixRegex = "unix-regex"
+const flagIsoRegex = "iso-regex"
+const flagMinYear = "min-year"
+const flagMaxYear = "max-year"
+const flagComment = "comment"
+
+const annotateModeReplace = "replace"
+const annotateModeAppend = "append"
+
+const defaultUnixRegex = `\b\d{10}(?:\d{3}|\d{6}|\d{9})?(?:\.\d{1,9})?\b`
+const defaultIsoRegex = `\b\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(?::\d{2}(?:[.,]\d{1,9})?)?(?:Z|[+-]\d{2}(?::?\d{2})?)?`
+
+// annotateFormats are the named output formats, any other value is used as
+// a Go time layout.
+var annotateFormats = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"rfc1123":     time.RFC1123,
+	"unixdate":    time.UnixDate,
+	"datetime":    "2006-01-02 15:04:05",
+	"datetimems":  "2006-01-02 15:04:05.000",
+	"time":        "15:04:05",
+	"unix":        "",
+	"unixms":      "",
+}
+
+// layouts of the ISO timestamps, with the separator normalized to T
+var annotateZonedLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999Z07",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04Z0700",
+}
+
+var annotateLocalLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+}
+
+// annotator converts the timestamps found in lines of text.
+type annotator struct {
+	unixRegexp *regexp.Regexp
+	isoRegexp  *regexp.Regexp
+	loc        *time.Location
+	inputLoc   *time.Location
+	format     string
+	mode       string
+	comment    string
+	minYear    int
+	maxYear    int
+}
+
+func NewAnnotateCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	var annotateCmd = &cobra.Command{
+		Use:   "annotate",
+		Short: "Rewrites the timestamps of text read from the standard input",
+		Long: heredoc.Doc(`
+			Rewrites the Unix and ISO 8601 timestamps of text read from the
+			standard input, line by line, so it can follow logs.
+
+			Unix timestamps in seconds, milliseconds, microseconds or
+			nanoseconds are told apart by their number of digits. Only the
+			timestamps between --min-year and --max-year are converted, to
+			leave other numbers alone. ISO timestamps without offset are
+			interpreted in --input-tz.
+
+			Timestamps are replaced in place or, with --mode append, listed in
+			a comment at the end of the line.
+
+			Formats: rfc3339, rfc3339nano, rfc1123, unixdate, datetime,
+			datetimems, time, unix, unixms or a Go layout (e.g. "Jan 2 15:04").
+
+			Set a regex to "" to disable the detection of that kind of
+			timestamps.
+		`),
+		Example: heredoc.Doc(`
+			tail -f app.log | canivete datetime annotate --tz Europe/Lisbon
+			kubectl logs my-pod | canivete datetime annotate --mode append --format datetime
+			cat events.csv | canivete datetime annotate --iso-regex "" --format unix
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tz, _ := cmd.Flags().GetString(flagTimezone)
+			inputTz, _ := cmd.Flags().GetString(flagInputTimezone)
+			unixRegex, _ := cmd.Flags().GetString(flagUnixRegex)
+			isoRegex, _ := cmd.Flags().GetString(flagIsoRegex)
+
+			a := &annotator{}
+			a.format, _ = cmd.Flags().GetString(flagFormat)
+			a.mode, _ = cmd.Flags().GetString(flagMode)
+			a.comment, _ = cmd.Flags().GetString(flagComment)
+			a.minYear, _ = cmd.Flags().GetInt(flagMinYear)
+			a.maxYear, _ = cmd.Flags().GetInt(flagMaxYear)
+
+			if a.mode != annotateModeReplace && a.mode != annotateModeAppend {
+				return fmt.Errorf("invalid mode %q, must be %s or %s", a.mode, annotateModeReplace, annotateModeAppend)
+			}
+			if layout, ok := annotateFormats[a.format]; ok && layout != "" {
+				a.format = layout
+			}
+
+			var err error
+			if a.loc, err = loadLocation(tz); err != nil {
+				return err
+			}
+			if a.inputLoc, err = loadLocation(inputTz); err != nil {
+				return err
+			}
+			if a.unixRegexp, err = compileOptionalRegexp(unixRegex); err != nil {
+				return err
+			}
+			if a.isoRegexp, err = compileOptionalRegexp(isoRegex); err != nil {
+				return err
+			}
+
+			return a.run(iostreams.In, iostreams.Out)
+		},
+	}
+
+	annotateCmd.Flags().String(flagTimezone, "UTC", "the IANA timezone of the rewritten timestamps")
+	annotateCmd.Flags().String(flagInputTimezone, "UTC", "the IANA timezone of ISO timestamps without offset")
+	annotateCmd.Flags().StringP(flagFormat, "f", "rfc3339", "the format of the rewritten timestamps")
+	annotateCmd.Flags().StringP(flagMode, "m", annotateModeReplace, "replace the timestamps or append them in a comment")
+	annotateCmd.Flags().String(flagComment, "#", "the comment marker of the append mode")
+	annotateCmd.Flags().String(flagUnixRegex, defaultUnixRegex, "the regex of Unix timestamps")
+	annotateCmd.Flags().String(flagIsoRegex, defaultIsoRegex, "the regex of ISO 8601 timestamps")
+	annotateCmd.Flags().Int(flagMinYear, 2000, "ignore timestamps before this year")
+	annotateCmd.Flags().Int(flagMaxYear, 2100, "ignore timestamps after this year")
+
+	return annotateCmd
+}
+
+func compileOptionalRegexp(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	r, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %w", expr, err)
+	}
+	return r, nil
+}
+
+// run annotates the lines of in as they are read, writing them to out.
+func (a *annotator) run(in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if _, writeErr := io.WriteString(out, a.annotateLine(line)); writeErr != nil {
+				return writeErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// annotateLine converts the timestamps of a line, keeping its line ending.
+func (a *annotator) annotateLine(line string) string {
+	text := strings.TrimRight(line, "\r\n")
+	ending := line[len(text):]
+
+	type match struct {
+		start, end int
+		converted  string
+	}
+	matches := []match{}
+
+	// ISO timestamps take precedence over the Unix ones inside them
+	for _, r := range []*regexp.Regexp{a.isoRegexp, a.unixRegexp} {
+		if r == nil {
+			continue
+		}
+		for _, loc := range r.FindAllStringIndex(text, -1) {
+			overlaps := false
+			for _, m := range matches {
+				overlaps = overlaps || (loc[0] < m.end && loc[1] > m.start)
+			}
+			if overlaps {
+				continue
+			}
+
+			var t time.Time
+			var ok bool
+			if r == a.isoRegexp {
+				t, ok = a.parseIso(text[loc[0]:loc[1]])
+			} else {
+				t, ok = a.parseUnix(text[loc[0]:loc[1]])
+			}
+			if ok && t.Year() >= a.minYear && t.Year() <= a.maxYear {
+				matches = append(matches, match{start: loc[0], end: loc[1], converted: a.formatTime(t)})
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return line
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	if a.mode == annotateModeAppend {
+		converted := []string{}
+		for _, m := range matches {
+			converted = append(converted, m.converted)
+		}
+		return text + "  " + a.comment + " " + strings.Join(converted, ", ") + ending
+	}
+
+	var b strings.Builder
+	previous := 0
+	for _, m := range matches {
+		b.WriteString(text[previous:m.start])
+		b.WriteString(m.converted)
+		previous = m.end
+	}
+	b.WriteString(text[previous:])
+	b.WriteString(ending)
+
+	return b.String()
+}
+
+// parseUnix converts a Unix timestamp, in seconds (optionally with a
+// fraction), milliseconds, microseconds or nanoseconds depending on its
+// number of digits.
+func (a *annotator) parseUnix(value string) (time.Time, bool) {
+	parts := strings.SplitN(value, ".", 2)
+	n, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	switch digits := len(parts[0]); {
+	case len(parts) == 2:
+		fraction, _ := strconv.Atoi((parts[1] + "00000000")[:9])
+		return time.Unix(n, int64(fraction)), true
+	case digits <= 11:
+		return time.Unix(n, 0), true
+	case digits <= 14:
+		return time.Unix(0, n*int64(time.Millisecond)), true
+	case digits <= 17:
+		return time.Unix(0, n*int64(time.Microsecond)), true
+	default:
+		return time.Unix(0, n), true
+	}
+}
+
+func (a *annotator) parseIso(value string) (time.Time, bool) {
+	value = strings.Replace(strings.Replace(value, " ", "T", 1), ",", ".", 1)
+
+	for _, layout := range annotateZonedLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	for _, layout := range annotateLocalLayouts {
+		if t, err := time.ParseInLocation(layout, value, a.inputLoc); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (a *annotator) formatTime(t time.Time) string {
+	switch a.format {
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unixms":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	}
+	return t.In(a.loc).Format(a.format)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotateCmdReplace(t *testing.T) {
+	// arrange
+	iostreams, in, out, _ := iostreams.Test()
+	cmd := NewAnnotateCmd(*iostreams)
+	in.WriteString(strings.Join([]string{
+		"1638964800 INFO started pid=12345",
+		"ts=1638964800123 took 1638964800123456 us",
+		"at 1638964800123456789 and 1638964800.5",
+		"2021-12-08T12:00:00Z request id=9999999999999999",
+		"2021-12-08 13:00:00,250+01:00 done",
+		"no timestamps here",
+		"last line without newline 1638964800",
+	}, "\n"))
+
+	// act
+	cmd.SetArgs([]string{"--tz=Europe/Lisbon", "--format=rfc3339nano"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"2021-12-08T12:00:00Z INFO started pid=12345",
+		"ts=2021-12-08T12:00:00.123Z took 2021-12-08T12:00:00.123456Z us",
+		"at 2021-12-08T12:00:00.123456789Z and 2021-12-08T12:00:00.5Z",
+		"2021-12-08T12:00:00Z request id=9999999999999999",
+		"2021-12-08T12:00:00.25Z done",
+		"no timestamps here",
+		"last line without newline 2021-12-08T12:00:00Z",
+	}, "\n")
+	assert.Equal(t, expected, out.String())
+}
+
+func TestAnnotateCmdAppend(t *testing.T) {
+	// arrange
+	iostreams, in, out, _ := iostreams.Test()
+	cmd := NewAnnotateCmd(*iostreams)
+	in.WriteString("from 1638964800 to 2021-12-08T14:00:00\r\nnothing\n")
+
+	// act
+	cmd.SetArgs([]string{"-m=append", "-f=datetime", "--tz=America/New_York", "--input-tz=Europe/Lisbon", "--comment=//"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "from 1638964800 to 2021-12-08T14:00:00  // 2021-12-08 07:00:00, 2021-12-08 09:00:00\r\nnothing\n"
+	assert.Equal(t, expected, out.String())
+}
+
+func TestAnnotateCmdUnixFormatWithoutIso(t *testing.T) {
+	// arrange
+	iostreams, in, out, _ := iostreams.Test()
+	cmd := NewAnnotateCmd(*iostreams)
+	in.WriteString("2021-12-08T12:00:00Z 1638964800\n")
+
+	// act
+	cmd.SetArgs([]string{"--iso-regex=", "--format=Jan 2 15:04"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "2021-12-08T12:00:00Z Dec 8 12:00\n", out.String())
+}
+
+func TestAnnotateCmdYearRange(t *testing.T) {
+	// arrange
+	iostreams, in, out, _ := iostreams.Test()
+	cmd := NewAnnotateCmd(*iostreams)
+	in.WriteString("1638964800 1000000000\n")
+
+	// act
+	cmd.SetArgs([]string{"--min-year=2010", "--format=unixms"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "1638964800000 1000000000\n", out.String())
+}
+
+func TestAnnotateCmdInvalidMode(t *testing.T) {
+	// arrange
+	iostreams, _, _, _ := iostreams.Test()
+	cmd := NewAnnotateCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"--mode=prepend"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	assert.EqualError(t, err, `invalid mode "prepend", must be replace or append`)
+}
+
+func TestAnnotateCmdInvalidRegex(t *testing.T) {
+	// arrange
+	iostreams, _, _, _ := iostreams.Test()
+	cmd := NewAnnotateCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"--unix-regex=("})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid regex "("`)
+}
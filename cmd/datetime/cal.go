@@ -0,0 +1,325 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/calendar"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type calDayOutput struct {
+	Date      string
+	Weekday   string
+	ISOYear   int
+	ISOWeek   int
+	DayOfYear int
+	Quarter   int
+	Weekend   bool
+	Holiday   string `json:",omitempty"`
+}
+
+type calOutput struct {
+	Year     int
+	Month    int    `json:",omitempty"`
+	Calendar string `json:",omitempty"`
+	Days     []calDayOutput
+}
+
+const flagFirstWeekday = "first-weekday"
+
+// calMonthWidth is the width of a month: the week number and 7 days, each
+// with 3 characters (holidays are marked in the third).
+const calMonthWidth = 24
+const calMonthSeparator = "  "
+
+// calWeekRows is the maximum number of weeks a month spans.
+const calWeekRows = 6
+
+var calYearMonthRegexp = regexp.MustCompile(`^(\d{4})(?:-(\d{2}))?$`)
+
+func NewCalCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newCalCmd(iostreams, systemClock{})
+}
+
+func newCalCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var calCmd = &cobra.Command{
+		Use:   "cal [[month] year | year-month]",
+		Short: "Displays a calendar with ISO 8601 week numbers",
+		Long: heredoc.Doc(`
+			Displays a month or year calendar, like cal, with the ISO 8601
+			week numbers on the left.
+
+			Without arguments the current month is displayed. A year alone
+			(e.g. 2026) displays the whole year.
+
+			With a holiday calendar (see the workdays command), holidays are
+			marked with * and listed below the calendar.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime cal
+			canivete datetime cal 11 2026 -c pt
+			canivete datetime cal 2026-11 --first-weekday sun
+			canivete datetime cal 2026 --json
+		`),
+		Args: cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			firstWeekdayValue, _ := cmd.Flags().GetString(flagFirstWeekday)
+			calendarName, _ := cmd.Flags().GetString(flagCalendar)
+			wholeYear, _ := cmd.Flags().GetBool(flagYear)
+			asJson, _ := cmd.Flags().GetBool(flagJson)
+
+			year, month, err := parseCalArgs(args, clock.Now())
+			if err != nil {
+				return err
+			}
+			if wholeYear {
+				month = 0
+			}
+
+			firstWeekday, err := calendar.ParseWeekday(firstWeekdayValue)
+			if err != nil {
+				return err
+			}
+
+			var cal *calendar.Calendar
+			if calendarName != "" {
+				if cal, err = loadCalendar(calendarName); err != nil {
+					return err
+				}
+			}
+
+			if asJson {
+				return iostreams.PrintOutput(runCal(year, month, cal))
+			}
+
+			for _, line := range renderCal(year, month, firstWeekday, cal) {
+				fmt.Fprintln(iostreams.Out, line)
+			}
+			return nil
+		},
+	}
+
+	calCmd.Flags().String(flagFirstWeekday, "mon", "the first day of the week")
+	calCmd.Flags().StringP(flagCalendar, "c", "", "the holiday calendar (embedded or defined in the config file)")
+	calCmd.Flags().BoolP(flagYear, "y", false, "display the whole year")
+	calCmd.Flags().Bool(flagJson, false, "output each day as JSON")
+
+	return calCmd
+}
+
+// parseCalArgs returns the year and month (zero for the whole year) of the
+// arguments, which are like the ones of cal, or year-month.
+func parseCalArgs(args []string, now time.Time) (int, time.Month, error) {
+	switch len(args) {
+	case 0:
+		return now.Year(), now.Month(), nil
+	case 2:
+		month, err := parseCronValue(strings.ToUpper(args[0]), 1, 12, monthNames)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid month %q", args[0])
+		}
+		year, err := strconv.Atoi(args[1])
+		if err != nil || year < 1 || year > 9999 {
+			return 0, 0, fmt.Errorf("invalid year %q", args[1])
+		}
+		return year, time.Month(month), nil
+	}
+
+	matches := calYearMonthRegexp.FindStringSubmatch(args[0])
+	if matches == nil {
+		return 0, 0, fmt.Errorf("invalid year or month %q", args[0])
+	}
+
+	year, _ := strconv.Atoi(matches[1])
+	month, _ := strconv.Atoi(matches[2])
+	if year < 1 || month > 12 || (matches[2] != "" && month < 1) {
+		return 0, 0, fmt.Errorf("invalid year or month %q", args[0])
+	}
+
+	return year, time.Month(month), nil
+}
+
+func runCal(year int, month time.Month, cal *calendar.Calendar) calOutput {
+	output := calOutput{Year: year, Month: int(month), Days: []calDayOutput{}}
+	if cal != nil {
+		output.Calendar = cal.Name
+	}
+
+	from, to := calPeriod(year, month)
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		isoYear, isoWeek := d.ISOWeek()
+		day := calDayOutput{
+			Date:      d.Format(dateLayout),
+			Weekday:   d.Weekday().String(),
+			ISOYear:   isoYear,
+			ISOWeek:   isoWeek,
+			DayOfYear: d.YearDay(),
+			Quarter:   (int(d.Month())-1)/3 + 1,
+			Weekend:   d.Weekday() == time.Saturday || d.Weekday() == time.Sunday,
+		}
+		if cal != nil {
+			day.Weekend = cal.IsWeekend(d)
+			if holiday, ok := cal.Holiday(d); ok {
+				day.Holiday = holiday.Name
+			}
+		}
+		output.Days = append(output.Days, day)
+	}
+
+	return output
+}
+
+// calPeriod returns the first day of the month, or year when month is
+// zero, and the first day after it.
+func calPeriod(year int, month time.Month) (time.Time, time.Time) {
+	if month == 0 {
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), time.Date(year, month+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// renderCal returns the lines of a month calendar, or of a year calendar
+// with three months side by side, followed by the holidays.
+func renderCal(year int, month time.Month, firstWeekday time.Weekday, cal *calendar.Calendar) []string {
+	lines := []string{}
+
+	if month != 0 {
+		for _, line := range renderCalMonth(year, month, firstWeekday, cal, true) {
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, strings.TrimRight(line, " "))
+			}
+		}
+	} else {
+		width := 3*calMonthWidth + 2*len(calMonthSeparator)
+		lines = append(lines, strings.TrimRight(centerText(strconv.Itoa(year), width), " "), "")
+		for first := time.January; first <= time.December; first += 3 {
+			blocks := [][]string{}
+			for m := first; m < first+3; m++ {
+				blocks = append(blocks, renderCalMonth(year, m, firstWeekday, cal, false))
+			}
+			for i := range blocks[0] {
+				line := strings.Join([]string{blocks[0][i], blocks[1][i], blocks[2][i]}, calMonthSeparator)
+				lines = append(lines, strings.TrimRight(line, " "))
+			}
+
+			// a single empty line between each row of months
+			for lines[len(lines)-1] == "" {
+				lines = lines[:len(lines)-1]
+			}
+			if first < time.October {
+				lines = append(lines, "")
+			}
+		}
+	}
+
+	if cal == nil {
+		return lines
+	}
+
+	from, to := calPeriod(year, month)
+	holidays := []string{}
+	for y := from.Year(); y <= to.AddDate(0, 0, -1).Year(); y++ {
+		for _, holiday := range cal.Holidays(y) {
+			if !holiday.Date.Before(from) && holiday.Date.Before(to) {
+				holidays = append(holidays, fmt.Sprintf("* %s %s", holiday.Date.Format(dateLayout), holiday.Name))
+			}
+		}
+	}
+	if len(holidays) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, holidays...)
+	}
+
+	return lines
+}
+
+// renderCalMonth returns the title, the weekdays header and the six weeks
+// of a month, each line with calMonthWidth characters.
+func renderCalMonth(year int, month time.Month, firstWeekday time.Weekday, cal *calendar.Calendar, withYear bool) []string {
+	title := month.String()
+	if withYear {
+		title += " " + strconv.Itoa(year)
+	}
+
+	header := "Wk "
+	for i := 0; i < 7; i++ {
+		header += time.Weekday((int(firstWeekday) + i) % 7).String()[:2] + " "
+	}
+
+	lines := []string{centerText(title, calMonthWidth), padText(header, calMonthWidth)}
+
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	rowStart := first.AddDate(0, 0, -((int(first.Weekday()) - int(firstWeekday) + 7) % 7))
+
+	for row := 0; row < calWeekRows; row++ {
+		if rowStart.After(first) && rowStart.Month() != month {
+			lines = append(lines, padText("", calMonthWidth))
+			rowStart = rowStart.AddDate(0, 0, 7)
+			continue
+		}
+
+		// the ISO week of the row is the one of its Monday
+		monday := rowStart.AddDate(0, 0, (int(time.Monday)-int(rowStart.Weekday())+7)%7)
+		_, week := monday.ISOWeek()
+
+		line := fmt.Sprintf("%2d ", week)
+		for i := 0; i < 7; i++ {
+			d := rowStart.AddDate(0, 0, i)
+			switch {
+			case d.Month() != month:
+				line += "   "
+			case cal != nil && isHoliday(cal, d):
+				line += fmt.Sprintf("%2d*", d.Day())
+			default:
+				line += fmt.Sprintf("%2d ", d.Day())
+			}
+		}
+
+		lines = append(lines, padText(line, calMonthWidth))
+		rowStart = rowStart.AddDate(0, 0, 7)
+	}
+
+	return lines
+}
+
+func isHoliday(cal *calendar.Calendar, date time.Time) bool {
+	_, ok := cal.Holiday(date)
+	return ok
+}
+
+func centerText(text string, width int) string {
+	left := (width - len(text)) / 2
+	if left < 0 {
+		left = 0
+	}
+	return padText(strings.Repeat(" ", left)+text, width)
+}
+
+func padText(text string, width int) string {
+	if len(text) >= width {
+		return text
+	}
+	return text + strings.Repeat(" ", width-len(text))
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renato0307/canivete/pkg/calendar"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalCmdMonth(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewCalCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"12", "2022", "-c=pt"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"     December 2022",
+		"Wk Mo Tu We Th Fr Sa Su",
+		"48           1* 2  3  4",
+		"49  5  6  7  8* 9 10 11",
+		"50 12 13 14 15 16 17 18",
+		"51 19 20 21 22 23 24 25*",
+		"52 26 27 28 29 30 31",
+		"",
+		"* 2022-12-01 Restoration of Independence",
+		"* 2022-12-08 Immaculate Conception",
+		"* 2022-12-25 Christmas Day",
+		"",
+	}, "\n")
+	assert.Equal(t, expected, out.String())
+}
+
+func TestRenderCalFirstWeekday(t *testing.T) {
+	// act
+	lines := renderCal(2026, time.November, time.Sunday, nil)
+
+	// assert
+	assert.Equal(t, "Wk Su Mo Tu We Th Fr Sa", lines[1])
+	assert.Equal(t, "45  1  2  3  4  5  6  7", lines[2])
+	assert.Equal(t, "49 29 30", lines[6])
+	assert.Len(t, lines, 7)
+}
+
+func TestRenderCalYear(t *testing.T) {
+	// act
+	lines := renderCal(2027, 0, time.Monday, nil)
+
+	// assert
+	assert.Equal(t, "2027", strings.TrimSpace(lines[0]))
+	assert.Contains(t, lines[2], "January")
+	assert.Contains(t, lines[2], "March")
+	assert.True(t, strings.HasPrefix(lines[4], "53              1  2  3"))
+	assert.Equal(t, 4, strings.Count(strings.Join(lines, "\n"), "Wk Mo Tu We Th Fr Sa Su   Wk"))
+}
+
+func TestRunCal(t *testing.T) {
+	// arrange
+	cal, _ := calendar.Get("pt", nil)
+
+	// act
+	output := runCal(2026, 0, cal)
+
+	// assert
+	assert.Len(t, output.Days, 365)
+	assert.Equal(t, "pt", output.Calendar)
+	assert.Equal(t, calDayOutput{
+		Date:      "2026-01-01",
+		Weekday:   "Thursday",
+		ISOYear:   2026,
+		ISOWeek:   1,
+		DayOfYear: 1,
+		Quarter:   1,
+		Holiday:   "New Year's Day",
+	}, output.Days[0])
+	assert.Equal(t, 53, output.Days[364].ISOWeek)
+	assert.Equal(t, 4, output.Days[364].Quarter)
+	assert.True(t, output.Days[2].Weekend)
+}
+
+func TestParseCalArgs(t *testing.T) {
+	// arrange
+	now := time.Date(2022, time.June, 10, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		args  []string
+		year  int
+		month time.Month
+		err   string
+	}{
+		{args: []string{}, year: 2022, month: time.June},
+		{args: []string{"2026"}, year: 2026},
+		{args: []string{"2026-11"}, year: 2026, month: time.November},
+		{args: []string{"nov", "2026"}, year: 2026, month: time.November},
+		{args: []string{"13", "2026"}, err: "invalid month"},
+		{args: []string{"2026-13"}, err: "invalid year or month"},
+		{args: []string{"26"}, err: "invalid year or month"},
+	}
+
+	for _, tc := range testCases {
+		// act
+		year, month, err := parseCalArgs(tc.args, now)
+
+		// assert
+		if tc.err != "" {
+			assert.NotNil(t, err, tc.args)
+			assert.Contains(t, err.Error(), tc.err)
+			continue
+		}
+		assert.Nil(t, err, tc.args)
+		assert.Equal(t, tc.year, year)
+		assert.Equal(t, tc.month, month)
+	}
+}
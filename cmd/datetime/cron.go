@@ -0,0 +1,390 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type cronFireOutput struct {
+	Time          string
+	UnixTimestamp int64
+	Note          string `json:",omitempty"`
+}
+
+type cronOutput struct {
+	Expression  string
+	Description string
+	Timezone    string
+	Next        []cronFireOutput
+	Previous    []cronFireOutput `json:",omitempty"`
+}
+
+const flagCount = "count"
+const flagPrevious = "previous"
+const flagQuartz = "quartz"
+
+func NewCronCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newCronCmd(iostreams, systemClock{})
+}
+
+func newCronCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var cronCmd = &cobra.Command{
+		Use:   "cron <expression>",
+		Short: "Explains a cron expression and calculates its fire times",
+		Long: heredoc.Doc(`
+			Explains a cron expression and calculates its next and previous fire times.
+
+			Supported formats:
+				5 fields = minute hour day-of-month month day-of-week
+				6 fields = second minute hour day-of-month month day-of-week
+				7 fields = quartz, second ... day-of-week year
+				macros   = @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly
+
+			Six field expressions using ?, L, W or # (or with --quartz) follow
+			the quartz rules, where days of the week go from 1 (Sunday) to 7.
+
+			Daylight saving changes are handled like cronie does:
+			jobs with a fixed hour scheduled in a skipped hour run when the
+			gap ends and run only once in a repeated hour, while jobs with a
+			wildcard hour (e.g. */15 * * * *) follow the clock.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime cron "*/15 9-17 * * 1-5"
+			canivete datetime cron "0 30 2 * * ?" --tz Europe/Lisbon --from 2022-03-26 -n 3
+			canivete datetime cron "0 0 12 ? * 6L" --previous 2
+			canivete datetime cron @daily
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, _ := cmd.Flags().GetString(flagFrom)
+			tz, _ := cmd.Flags().GetString(flagTimezone)
+			count, _ := cmd.Flags().GetInt(flagCount)
+			previous, _ := cmd.Flags().GetInt(flagPrevious)
+			quartz, _ := cmd.Flags().GetBool(flagQuartz)
+
+			loc, err := loadLocation(tz)
+			if err != nil {
+				return err
+			}
+
+			fromTime, err := parseInstant(from, loc, clock.Now())
+			if err != nil {
+				return err
+			}
+
+			output, err := runCron(args[0], quartz, fromTime, count, previous)
+			if err != nil {
+				return err
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	cronCmd.Flags().StringP(
+		flagFrom,
+		"f",
+		"now",
+		"the instant to calculate fire times from")
+
+	cronCmd.Flags().String(
+		flagTimezone,
+		"UTC",
+		"the IANA timezone the schedule runs in (e.g. Europe/Lisbon)")
+
+	cronCmd.Flags().IntP(
+		flagCount,
+		"n",
+		5,
+		"number of next fire times")
+
+	cronCmd.Flags().IntP(
+		flagPrevious,
+		"p",
+		0,
+		"number of previous fire times")
+
+	cronCmd.Flags().Bool(
+		flagQuartz,
+		false,
+		"read six field expressions as quartz expressions (days of the week from 1 to 7)")
+
+	return cronCmd
+}
+
+func runCron(expression string, quartz bool, from time.Time, count, previous int) (cronOutput, error) {
+	schedule, err := parseCron(expression, quartz)
+	if err != nil {
+		return cronOutput{}, err
+	}
+
+	output := cronOutput{
+		Expression:  expression,
+		Description: schedule.describe(),
+		Timezone:    from.Location().String(),
+		Next:        toCronFireOutputs(schedule.next(from, count)),
+		Previous:    toCronFireOutputs(schedule.previous(from, previous)),
+	}
+
+	if count > 0 && len(output.Next) == 0 && previous == 0 {
+		return cronOutput{}, fmt.Errorf("the expression %q never fires in the next %d days", expression, cronSearchDays)
+	}
+
+	return output, nil
+}
+
+func toCronFireOutputs(fires []cronFire) []cronFireOutput {
+	outputs := []cronFireOutput{}
+	for _, fire := range fires {
+		outputs = append(outputs, cronFireOutput{
+			Time:          fire.Time.Format(time.RFC3339),
+			UnixTimestamp: fire.Time.Unix(),
+			Note:          fire.Note,
+		})
+	}
+
+	return outputs
+}
+
+var ordinals = []string{"", "first", "second", "third", "fourth", "fifth"}
+
+// describe explains the schedule in plain English.
+func (s *cronSchedule) describe() string {
+	parts := []string{s.describeTime()}
+
+	if dom := s.describeDayOfMonth(); dom != "" {
+		parts = append(parts, dom)
+	}
+	if dow := s.describeDayOfWeek(); dow != "" {
+		if s.daysEitherMatch() {
+			parts[len(parts)-1] += " or " + strings.TrimPrefix(dow, "only ")
+		} else {
+			parts = append(parts, dow)
+		}
+	}
+	if s.months.raw != "*" {
+		parts = append(parts, describeCronField(s.months.raw, "month", monthName, "in"))
+	}
+	if s.years != nil && s.years.raw != "*" {
+		parts = append(parts, describeCronField(s.years.raw, "year", strconv.Itoa, "in"))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (s *cronSchedule) describeTime() string {
+	secondsFixed := isSingleValue(s.seconds.raw)
+	if secondsFixed && isSingleValue(s.minutes.raw) && isPlainList(s.hours.raw) {
+		second, _ := strconv.Atoi(s.seconds.raw)
+		minute, _ := strconv.Atoi(s.minutes.raw)
+		times := []string{}
+		for hour := 0; hour <= 23; hour++ {
+			if s.hours.allowed[hour] {
+				times = append(times, formatClock(hour, minute, second))
+			}
+		}
+		return "At " + joinWithAnd(times)
+	}
+
+	parts := []string{}
+	if !(secondsFixed && s.seconds.raw == "0") {
+		parts = append(parts, describeCronField(s.seconds.raw, "second", strconv.Itoa, "at"))
+	}
+	if !(s.minutes.raw == "*" && len(parts) > 0) {
+		parts = append(parts, describeCronField(s.minutes.raw, "minute", strconv.Itoa, "at"))
+	}
+
+	switch {
+	case s.hours.raw == "*":
+	case isRange(s.hours.raw):
+		bounds := strings.SplitN(s.hours.raw, "-", 2)
+		from, _ := strconv.Atoi(bounds[0])
+		to, _ := strconv.Atoi(bounds[1])
+		parts = append(parts, fmt.Sprintf("between %02d:00 and %02d:59", from, to))
+	default:
+		parts = append(parts, describeCronField(s.hours.raw, "hour", strconv.Itoa, "past"))
+	}
+
+	text := strings.Join(parts, ", ")
+	return strings.ToUpper(text[:1]) + text[1:]
+}
+
+func (s *cronSchedule) describeDayOfMonth() string {
+	switch {
+	case !s.domRestricted:
+		return ""
+	case s.domLast && s.domLastOffset > 0:
+		return fmt.Sprintf("%d days before the last day of the month", s.domLastOffset)
+	case s.domLast:
+		return "on the last day of the month"
+	case s.domLastWeekday:
+		return "on the last weekday of the month"
+	case s.domNearestWeekday > 0:
+		return fmt.Sprintf("on the weekday nearest day %d of the month", s.domNearestWeekday)
+	}
+
+	return describeCronField(s.dom.raw, "day", strconv.Itoa, "on") + " of the month"
+}
+
+func (s *cronSchedule) describeDayOfWeek() string {
+	if !s.dowRestricted {
+		return ""
+	}
+
+	if s.dowNth != nil {
+		items := []string{}
+		for _, nth := range s.dowNth {
+			switch nth.nth {
+			case 0:
+				items = append(items, nth.weekday.String())
+			case -1:
+				items = append(items, "the last "+nth.weekday.String()+" of the month")
+			default:
+				items = append(items, "the "+ordinals[nth.nth]+" "+nth.weekday.String()+" of the month")
+			}
+		}
+		return "on " + joinWithAnd(items)
+	}
+
+	weekdayName := func(v int) string {
+		return time.Weekday(v % 7).String()
+	}
+	raw := strings.ToUpper(s.dow.raw)
+	if s.quartz {
+		// describe quartz days with the standard numbering
+		weekdayName = func(v int) string {
+			return time.Weekday((v + 6) % 7).String()
+		}
+	}
+
+	if isRange(raw) {
+		bounds := strings.SplitN(raw, "-", 2)
+		return fmt.Sprintf("%s through %s", cronValueName(bounds[0], weekdayName), cronValueName(bounds[1], weekdayName))
+	}
+
+	return "only " + describeCronField(raw, "weekday", weekdayName, "on")
+}
+
+// describeCronField explains a field with lists, ranges and steps using
+// the unit name and a function to name values (e.g. months).
+func describeCronField(raw, unit string, name func(int) string, preposition string) string {
+	if raw == "*" || raw == "?" {
+		return "every " + unit
+	}
+
+	named := func(text string) string {
+		return cronValueName(text, name)
+	}
+	// months and weekdays have names, no need to repeat the unit
+	hasNames := unit == "month" || unit == "weekday"
+
+	items := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		rangeText, step := item, ""
+		if i := strings.Index(item, "/"); i >= 0 {
+			rangeText, step = item[:i], item[i+1:]
+		}
+
+		switch {
+		case step != "" && (rangeText == "*" || rangeText == "?"):
+			items = append(items, fmt.Sprintf("every %s %ss", step, unit))
+		case step != "" && isRange(rangeText):
+			bounds := strings.SplitN(rangeText, "-", 2)
+			items = append(items, fmt.Sprintf("every %s %ss, %s %s through %s",
+				step, unit, unit, named(bounds[0]), named(bounds[1])))
+		case step != "":
+			items = append(items, fmt.Sprintf("every %s %ss, starting at %s %s", step, unit, unit, named(rangeText)))
+		case isRange(rangeText) && hasNames:
+			bounds := strings.SplitN(rangeText, "-", 2)
+			items = append(items, fmt.Sprintf("%s through %s", named(bounds[0]), named(bounds[1])))
+		case isRange(rangeText):
+			bounds := strings.SplitN(rangeText, "-", 2)
+			items = append(items, fmt.Sprintf("%ss %s through %s", unit, named(bounds[0]), named(bounds[1])))
+		default:
+			items = append(items, named(rangeText))
+		}
+	}
+
+	if isPlainList(raw) {
+		if hasNames || unit == "year" {
+			return fmt.Sprintf("%s %s", preposition, joinWithAnd(items))
+		}
+		if len(items) > 1 {
+			unit += "s"
+		}
+		return fmt.Sprintf("%s %s %s", preposition, unit, joinWithAnd(items))
+	}
+
+	return joinWithAnd(items)
+}
+
+func cronValueName(text string, name func(int) string) string {
+	if value, err := strconv.Atoi(text); err == nil {
+		return name(value)
+	}
+	for i, weekday := range weekdayNames {
+		if strings.EqualFold(text, weekday) {
+			return time.Weekday(i).String()
+		}
+	}
+	for i, month := range monthNames {
+		if strings.EqualFold(text, month) {
+			return time.Month(i + 1).String()
+		}
+	}
+
+	return text
+}
+
+func monthName(month int) string {
+	return time.Month(month).String()
+}
+
+func isSingleValue(raw string) bool {
+	_, err := strconv.Atoi(raw)
+	return err == nil
+}
+
+func isRange(raw string) bool {
+	return strings.Count(raw, "-") == 1 && !strings.ContainsAny(raw, ",/")
+}
+
+func isPlainList(raw string) bool {
+	return !strings.ContainsAny(raw, "*?/-")
+}
+
+func formatClock(hour, minute, second int) string {
+	if second != 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", hour, minute, second)
+	}
+	return fmt.Sprintf("%02d:%02d", hour, minute)
+}
+
+func joinWithAnd(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
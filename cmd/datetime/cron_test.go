@@ -0,0 +1,122 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"testing"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewCronCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"*/15 9-17 * * 1-5", "--from=2021-12-10T17:50:00Z", "-n=2", "-p=1"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), "Every 15 minutes, between 09:00 and 17:59, Monday through Friday")
+	assert.Contains(t, out.String(), "2021-12-13T09:00:00Z")
+	assert.Contains(t, out.String(), "2021-12-13T09:15:00Z")
+	assert.Contains(t, out.String(), "2021-12-10T17:45:00Z")
+}
+
+func TestCronCmdDescriptions(t *testing.T) {
+	testCases := []struct {
+		expression string
+		expected   string
+	}{
+		{expression: "@daily", expected: "At 00:00"},
+		{expression: "0 9,17 * * *", expected: "At 09:00 and 17:00"},
+		{expression: "5 * * * *", expected: "At minute 5"},
+		{expression: "*/10 * * * * *", expected: "Every 10 seconds"},
+		{expression: "0 0 1 1-3 *", expected: "At 00:00, on day 1 of the month, January through March"},
+		{expression: "0 0 12 ? * 6L", expected: "At 12:00, on the last Friday of the month"},
+		{expression: "0 15 10 ? * 2#1 2027", expected: "At 10:15, on the first Monday of the month, in 2027"},
+		{expression: "0 0 9 LW * ?", expected: "At 09:00, on the last weekday of the month"},
+		{expression: "0 12 1 * MON", expected: "At 12:00, on day 1 of the month or on Monday"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewCronCmd(*iostreams)
+
+		// act
+		cmd.SetArgs([]string{tc.expression, "--from=2022-01-01T00:00:00Z"})
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.Nil(t, err, tc.expression)
+		assert.Contains(t, out.String(), tc.expected, tc.expression)
+	}
+}
+
+func TestCronCmdDstGap(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewCronCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"30 1 * * *", "--tz=Europe/Lisbon", "--from=2022-03-27", "-n=1"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), "2022-03-27T02:00:00+01:00")
+	assert.Contains(t, out.String(), "skipped by daylight saving")
+}
+
+func TestCronCmdInvalidValues(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"* * *"}, expected: "expected 5, 6 or 7 fields"},
+		{args: []string{"61 * * * *"}, expected: "invalid minutes field"},
+		{args: []string{"@reboot"}, expected: "unsupported cron macro"},
+		{args: []string{"0 0 L * *"}, expected: "only valid in quartz expressions"},
+		{args: []string{"0 12 * * 8"}, expected: "invalid day-of-week field"},
+		{args: []string{"0 0 12 1 * 2 *"}, expected: "must use ? in day-of-month or day-of-week"},
+		{args: []string{"0 0 30 2 *"}, expected: "never fires"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewCronCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(tc.args)
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.NotNil(t, err, tc.args[0])
+		if err != nil {
+			assert.Contains(t, err.Error(), tc.expected)
+		}
+	}
+}
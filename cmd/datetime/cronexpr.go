@@ -0,0 +1,570 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+var weekdayNames = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+// cronField holds the raw text of a field and the values it allows.
+type cronField struct {
+	raw     string
+	allowed []bool
+	min     int
+	max     int
+}
+
+// cronNthWeekday is a Quartz "n#k" (k-th weekday n), "nL" (last weekday
+// n, k = -1) or plain (k = 0) day-of-week item.
+type cronNthWeekday struct {
+	weekday time.Weekday
+	nth     int
+}
+
+// cronSchedule is a parsed cron expression. Weekdays are always stored
+// with Sunday = 0, whatever the input flavour.
+type cronSchedule struct {
+	expression string
+	quartz     bool
+	hasSeconds bool
+
+	seconds cronField
+	minutes cronField
+	hours   cronField
+	dom     cronField
+	months  cronField
+	dow     cronField
+	years   *cronField
+
+	domRestricted bool
+	dowRestricted bool
+
+	domLast           bool
+	domLastOffset     int
+	domLastWeekday    bool
+	domNearestWeekday int
+	dowNth            []cronNthWeekday
+}
+
+// parseCron parses standard 5-field, 6-field (with seconds) and Quartz
+// expressions as well as the @daily-style macros. Six field expressions
+// are read as Quartz when forceQuartz is set or when they use Quartz only
+// syntax (?, L, W or #); seven field expressions are always Quartz.
+func parseCron(expression string, forceQuartz bool) (*cronSchedule, error) {
+	expression = strings.TrimSpace(expression)
+	text := expression
+
+	if strings.HasPrefix(text, "@") {
+		macro, ok := cronMacros[strings.ToLower(text)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported cron macro %q", text)
+		}
+		text = macro
+	}
+
+	fields := strings.Fields(text)
+	s := &cronSchedule{expression: expression}
+
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+		s.hasSeconds = true
+		s.quartz = forceQuartz || usesQuartzSyntax(fields[3], fields[5])
+	case 7:
+		s.hasSeconds = true
+		s.quartz = true
+	default:
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5, 6 or 7 fields", expression)
+	}
+
+	var err error
+	if s.seconds, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid seconds field: %w", err)
+	}
+	if s.minutes, err = parseCronField(fields[1], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid minutes field: %w", err)
+	}
+	if s.hours, err = parseCronField(fields[2], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid hours field: %w", err)
+	}
+	if err = s.parseDayOfMonth(fields[3]); err != nil {
+		return nil, fmt.Errorf("invalid day-of-month field: %w", err)
+	}
+	if s.months, err = parseCronField(fields[4], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("invalid month field: %w", err)
+	}
+	if err = s.parseDayOfWeek(fields[5]); err != nil {
+		return nil, fmt.Errorf("invalid day-of-week field: %w", err)
+	}
+	if len(fields) == 7 {
+		years, err := parseCronField(fields[6], 1970, 2199, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid year field: %w", err)
+		}
+		s.years = &years
+	}
+
+	if s.quartz && s.domRestricted && s.dowRestricted {
+		return nil, fmt.Errorf("invalid cron expression %q: quartz expressions must use ? in day-of-month or day-of-week", expression)
+	}
+
+	return s, nil
+}
+
+// usesQuartzSyntax tells if the day fields use characters only known by
+// quartz: ? and L, W or # (weekday names never end with L).
+func usesQuartzSyntax(dom, dow string) bool {
+	dom, dow = strings.ToUpper(dom), strings.ToUpper(dow)
+	return strings.ContainsAny(dom, "?LW") || strings.ContainsAny(dow, "?#") || strings.HasSuffix(dow, "L")
+}
+
+func (s *cronSchedule) parseDayOfMonth(raw string) error {
+	s.domRestricted = raw != "*" && raw != "?"
+	upper := strings.ToUpper(raw)
+
+	switch {
+	case upper == "L":
+		s.domLast = true
+	case upper == "LW":
+		s.domLastWeekday = true
+	case strings.HasPrefix(upper, "L-"):
+		offset, err := strconv.Atoi(upper[2:])
+		if err != nil || offset < 0 || offset > 30 {
+			return fmt.Errorf("invalid value %q", raw)
+		}
+		s.domLast = true
+		s.domLastOffset = offset
+	case strings.HasSuffix(upper, "W"):
+		day, err := strconv.Atoi(upper[:len(upper)-1])
+		if err != nil || day < 1 || day > 31 {
+			return fmt.Errorf("invalid value %q", raw)
+		}
+		s.domNearestWeekday = day
+	default:
+		field, err := parseCronField(raw, 1, 31, nil)
+		if err != nil {
+			return err
+		}
+		s.dom = field
+		return nil
+	}
+
+	if !s.quartz {
+		return fmt.Errorf("%q is only valid in quartz expressions", raw)
+	}
+	s.dom = cronField{raw: raw, min: 1, max: 31}
+
+	return nil
+}
+
+func (s *cronSchedule) parseDayOfWeek(raw string) error {
+	s.dowRestricted = raw != "*" && raw != "?"
+	upper := strings.ToUpper(raw)
+
+	if s.quartz && upper == "L" {
+		// a single L in the day-of-week field is just Saturday
+		upper = "7"
+	}
+
+	if strings.ContainsAny(upper, "#L") {
+		if !s.quartz {
+			return fmt.Errorf("%q is only valid in quartz expressions", raw)
+		}
+		s.dow = cronField{raw: raw, min: 0, max: 6}
+		for _, item := range strings.Split(upper, ",") {
+			nth, err := parseCronNthWeekday(item)
+			if err != nil {
+				return err
+			}
+			s.dowNth = append(s.dowNth, nth)
+		}
+		return nil
+	}
+
+	if s.quartz {
+		field, err := parseCronField(upper, 1, 7, weekdayNames)
+		if err != nil {
+			return err
+		}
+		// quartz weekdays go from 1 (Sunday) to 7 (Saturday)
+		s.dow = cronField{raw: upper, allowed: field.allowed[1:], min: 0, max: 6}
+
+		return nil
+	}
+
+	field, err := parseCronField(raw, 0, 7, weekdayNames)
+	if err != nil {
+		return err
+	}
+	// in standard cron both 0 and 7 are Sunday
+	if field.allowed[7] {
+		field.allowed[0] = true
+	}
+	field.allowed = field.allowed[:7]
+	field.max = 6
+	s.dow = field
+
+	return nil
+}
+
+func parseCronNthWeekday(item string) (cronNthWeekday, error) {
+	weekdayText, nthText, nth := item, "", 0
+	if i := strings.Index(item, "#"); i >= 0 {
+		weekdayText, nthText = item[:i], item[i+1:]
+	} else if strings.HasSuffix(item, "L") {
+		weekdayText, nth = item[:len(item)-1], -1
+	}
+
+	weekday, err := parseCronValue(weekdayText, 1, 7, weekdayNames)
+	if err != nil {
+		return cronNthWeekday{}, err
+	}
+
+	if nthText != "" {
+		nth, err = strconv.Atoi(nthText)
+		if err != nil || nth < 1 || nth > 5 {
+			return cronNthWeekday{}, fmt.Errorf("invalid value %q", item)
+		}
+	}
+
+	return cronNthWeekday{weekday: time.Weekday(weekday - 1), nth: nth}, nil
+}
+
+// parseCronField parses lists of values, ranges and steps (e.g. 1,5-10,*/15).
+func parseCronField(raw string, min, max int, names []string) (cronField, error) {
+	field := cronField{raw: raw, allowed: make([]bool, max+1), min: min, max: max}
+
+	for _, item := range strings.Split(raw, ",") {
+		rangeText, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			rangeText = item[:i]
+			step, err = strconv.Atoi(item[i+1:])
+			if err != nil || step < 1 {
+				return cronField{}, fmt.Errorf("invalid step in %q", item)
+			}
+		}
+
+		from, to := min, max
+		switch {
+		case rangeText == "*" || rangeText == "?":
+		case strings.Contains(rangeText, "-"):
+			parts := strings.SplitN(rangeText, "-", 2)
+			var err error
+			if from, err = parseCronValue(parts[0], min, max, names); err != nil {
+				return cronField{}, err
+			}
+			if to, err = parseCronValue(parts[1], min, max, names); err != nil {
+				return cronField{}, err
+			}
+			if from > to {
+				return cronField{}, fmt.Errorf("invalid range %q", rangeText)
+			}
+		default:
+			var err error
+			if from, err = parseCronValue(rangeText, min, max, names); err != nil {
+				return cronField{}, err
+			}
+			if step == 1 {
+				to = from
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			field.allowed[v] = true
+		}
+	}
+
+	return field, nil
+}
+
+func parseCronValue(text string, min, max int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(text, name) {
+			return i + min, nil
+		}
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil || value < min || value > max {
+		return 0, fmt.Errorf("invalid value %q, must be between %d and %d", text, min, max)
+	}
+
+	return value, nil
+}
+
+// matchesDay tells if the schedule fires on a given date.
+func (s *cronSchedule) matchesDay(year int, month time.Month, day int) bool {
+	if s.years != nil && (year > s.years.max || year < s.years.min || !s.years.allowed[year]) {
+		return false
+	}
+	if !s.months.allowed[month] {
+		return false
+	}
+
+	domMatch := s.matchesDayOfMonth(year, month, day)
+	dowMatch := s.matchesDayOfWeek(year, month, day)
+
+	if s.daysEitherMatch() {
+		return domMatch || dowMatch
+	}
+
+	return domMatch && dowMatch
+}
+
+// daysEitherMatch tells if a day matches when either day field matches,
+// which classic cron does when both are restricted. Like cronie, a field
+// starting with * (e.g. */2) isn't considered restricted for this.
+func (s *cronSchedule) daysEitherMatch() bool {
+	return s.domRestricted && s.dowRestricted &&
+		!strings.HasPrefix(s.dom.raw, "*") && !strings.HasPrefix(s.dow.raw, "*")
+}
+
+func (s *cronSchedule) matchesDayOfMonth(year int, month time.Month, day int) bool {
+	if !s.domRestricted {
+		return true
+	}
+
+	last := daysIn(year, month)
+	switch {
+	case s.domLast:
+		return day == last-s.domLastOffset
+	case s.domLastWeekday:
+		return day == nearestWeekday(year, month, last)
+	case s.domNearestWeekday > 0:
+		target := s.domNearestWeekday
+		if target > last {
+			target = last
+		}
+		return day == nearestWeekday(year, month, target)
+	}
+
+	return s.dom.allowed[day]
+}
+
+func (s *cronSchedule) matchesDayOfWeek(year int, month time.Month, day int) bool {
+	if !s.dowRestricted {
+		return true
+	}
+
+	weekday := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()
+	if s.dowNth == nil {
+		return s.dow.allowed[weekday]
+	}
+
+	for _, nth := range s.dowNth {
+		if weekday != nth.weekday {
+			continue
+		}
+		if nth.nth == 0 {
+			return true
+		}
+		if nth.nth == -1 && day+7 > daysIn(year, month) {
+			return true
+		}
+		if nth.nth == (day-1)/7+1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nearestWeekday returns the weekday (Monday to Friday) nearest to the day,
+// without leaving the month, as the quartz W character does.
+func nearestWeekday(year int, month time.Month, day int) int {
+	weekday := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()
+	switch weekday {
+	case time.Saturday:
+		if day == 1 {
+			return 3
+		}
+		return day - 1
+	case time.Sunday:
+		if day == daysIn(year, month) {
+			return day - 2
+		}
+		return day + 1
+	}
+
+	return day
+}
+
+// cronFire is a fire time. When the wall clock time doesn't exist, or
+// exists twice, because of a daylight saving change the note explains what
+// happened.
+type cronFire struct {
+	Time time.Time
+	Note string
+}
+
+// fireTimesOn returns the fire times of a local date, in chronological order.
+//
+// Daylight saving changes follow the cronie rules: jobs with a fixed hour
+// scheduled in a skipped interval run when the gap ends and jobs run only
+// once in a repeated interval; jobs with a wildcard hour simply follow the
+// clock, so they are skipped in gaps and run twice in overlaps.
+func (s *cronSchedule) fireTimesOn(year int, month time.Month, day int, loc *time.Location) []cronFire {
+	if !s.matchesDay(year, month, day) {
+		return nil
+	}
+
+	wildcardHour := strings.HasPrefix(s.hours.raw, "*")
+	fires := []cronFire{}
+	seen := map[int64]bool{}
+
+	add := func(t time.Time, note string) {
+		if !seen[t.UnixNano()] {
+			seen[t.UnixNano()] = true
+			fires = append(fires, cronFire{Time: t, Note: note})
+		}
+	}
+
+	for hour := 0; hour <= 23; hour++ {
+		if !s.hours.allowed[hour] {
+			continue
+		}
+		for minute := 0; minute <= 59; minute++ {
+			if !s.minutes.allowed[minute] {
+				continue
+			}
+			for second := 0; second <= 59; second++ {
+				if !s.seconds.allowed[second] {
+					continue
+				}
+
+				instants, before := resolveWallClock(year, month, day, hour, minute, second, loc)
+				switch {
+				case len(instants) == 1:
+					add(instants[0], "")
+				case len(instants) == 2 && wildcardHour:
+					add(instants[0], "repeated local time (first occurrence)")
+					add(instants[1], "repeated local time (second occurrence)")
+				case len(instants) == 2:
+					add(instants[0], "repeated local time, runs only once")
+				case !wildcardHour:
+					add(gapEnd(before), fmt.Sprintf("local time %02d:%02d:%02d skipped by daylight saving, runs after the gap",
+						hour, minute, second))
+				}
+			}
+		}
+	}
+
+	sort.Slice(fires, func(i, j int) bool { return fires[i].Time.Before(fires[j].Time) })
+
+	return fires
+}
+
+// resolveWallClock returns the instants with the given wall clock time in
+// loc: one normally, two in a daylight saving overlap and none in a gap. In
+// the latter case it also returns the instant obtained with the offset in
+// use before the gap.
+func resolveWallClock(year int, month time.Month, day, hour, minute, second int, loc *time.Location) ([]time.Time, time.Time) {
+	wall := time.Date(year, month, day, hour, minute, second, 0, time.UTC)
+
+	_, offsetBefore := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, offsetAfter := wall.Add(24 * time.Hour).In(loc).Zone()
+
+	instants := []time.Time{}
+	for _, offset := range []int{offsetBefore, offsetAfter} {
+		t := wall.Add(-time.Duration(offset) * time.Second).In(loc)
+		if t.Hour() != hour || t.Minute() != minute || t.Second() != second || t.Day() != day {
+			continue
+		}
+		if len(instants) == 0 || !instants[0].Equal(t) {
+			instants = append(instants, t)
+		}
+	}
+
+	sort.Slice(instants, func(i, j int) bool { return instants[i].Before(instants[j]) })
+
+	return instants, wall.Add(-time.Duration(offsetBefore) * time.Second).In(loc)
+}
+
+// gapEnd returns the first instant after a daylight saving gap, given an
+// instant after it within a day, e.g. the skipped wall clock time with the
+// offset in use before the gap.
+func gapEnd(after time.Time) time.Time {
+	_, offset := after.Zone()
+	lo, hi := after.Add(-24*time.Hour), after
+	for hi.Sub(lo) > time.Second {
+		mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+		if _, midOffset := mid.Zone(); midOffset == offset {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi
+}
+
+// cronSearchDays limits how far fire times are searched for.
+const cronSearchDays = 366 * 10
+
+// next returns up to count fire times strictly after from.
+func (s *cronSchedule) next(from time.Time, count int) []cronFire {
+	fires := []cronFire{}
+	year, month, day := from.Date()
+
+	for i := 0; i < cronSearchDays && len(fires) < count; i++ {
+		date := time.Date(year, month, day+i, 0, 0, 0, 0, time.UTC)
+		for _, fire := range s.fireTimesOn(date.Year(), date.Month(), date.Day(), from.Location()) {
+			if fire.Time.After(from) && len(fires) < count {
+				fires = append(fires, fire)
+			}
+		}
+	}
+
+	return fires
+}
+
+// previous returns up to count fire times strictly before from, the most
+// recent first.
+func (s *cronSchedule) previous(from time.Time, count int) []cronFire {
+	fires := []cronFire{}
+	year, month, day := from.Date()
+
+	for i := 0; i < cronSearchDays && len(fires) < count; i++ {
+		date := time.Date(year, month, day-i, 0, 0, 0, 0, time.UTC)
+		dayFires := s.fireTimesOn(date.Year(), date.Month(), date.Day(), from.Location())
+		for j := len(dayFires) - 1; j >= 0; j-- {
+			if dayFires[j].Time.Before(from) && len(fires) < count {
+				fires = append(fires, dayFires[j])
+			}
+		}
+	}
+
+	return fires
+}
@@ -0,0 +1,137 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronScheduleDstOverlap(t *testing.T) {
+	// arrange
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	from := time.Date(2022, time.October, 30, 0, 50, 0, 0, lisbon)
+	wildcard, _ := parseCron("*/30 * * * *", false)
+	fixed, _ := parseCron("30 1 * * *", false)
+
+	// act
+	wildcardFires := wildcard.next(from, 4)
+	fixedFires := fixed.next(from, 2)
+
+	// assert
+	assert.Equal(t, int64(1667088000), wildcardFires[0].Time.Unix())
+	assert.Equal(t, int64(1667089800), wildcardFires[1].Time.Unix())
+	assert.Equal(t, int64(1667091600), wildcardFires[2].Time.Unix())
+	assert.Equal(t, int64(1667093400), wildcardFires[3].Time.Unix())
+	assert.Equal(t, int64(1667089800), fixedFires[0].Time.Unix())
+	assert.Equal(t, time.Date(2022, time.October, 31, 1, 30, 0, 0, lisbon), fixedFires[1].Time)
+}
+
+func TestCronScheduleDstGapWithWildcardHour(t *testing.T) {
+	// arrange
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	from := time.Date(2022, time.March, 27, 0, 50, 0, 0, lisbon)
+	schedule, _ := parseCron("*/30 * * * *", false)
+
+	// act
+	fires := schedule.next(from, 2)
+
+	// assert
+	assert.Equal(t, time.Date(2022, time.March, 27, 2, 0, 0, 0, lisbon), fires[0].Time)
+	assert.Equal(t, time.Date(2022, time.March, 27, 2, 30, 0, 0, lisbon), fires[1].Time)
+}
+
+func TestCronScheduleDstGapWithFixedHour(t *testing.T) {
+	// arrange
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	from := time.Date(2022, time.March, 27, 0, 0, 0, 0, lisbon)
+	schedule, _ := parseCron("30 1 * * *", false)
+
+	// act
+	fires := schedule.next(from, 2)
+
+	// assert
+	assert.Equal(t, time.Date(2022, time.March, 27, 2, 0, 0, 0, lisbon), fires[0].Time)
+	assert.Equal(t, int64(1648342800), fires[0].Time.Unix())
+	assert.Contains(t, fires[0].Note, "skipped by daylight saving")
+	assert.Equal(t, time.Date(2022, time.March, 28, 1, 30, 0, 0, lisbon), fires[1].Time)
+}
+
+func TestCronScheduleStepDayOfMonthAndDayOfWeek(t *testing.T) {
+	// arrange
+	from := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	stepped, _ := parseCron("0 0 */2 * 1", false)
+	listed, _ := parseCron("0 0 1,15 * 1", false)
+
+	// act
+	steppedFires := stepped.next(from, 3)
+	listedFires := listed.next(from, 3)
+
+	// assert
+	// like cronie, both day fields must match when one starts with *
+	assert.Equal(t, 3, steppedFires[0].Time.Day())
+	assert.Equal(t, 17, steppedFires[1].Time.Day())
+	assert.Equal(t, 31, steppedFires[2].Time.Day())
+	// and either one when both are restricted
+	assert.Equal(t, 3, listedFires[0].Time.Day())
+	assert.Equal(t, 10, listedFires[1].Time.Day())
+	assert.Equal(t, 15, listedFires[2].Time.Day())
+}
+
+func TestCronScheduleQuartzWeekdays(t *testing.T) {
+	// arrange
+	from := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	quartz, _ := parseCron("0 0 8 ? * 2-6", false)
+	seconds, _ := parseCron("0 0 8 * * 1-5", false)
+
+	// act
+	quartzFires := quartz.next(from, 1)
+	secondsFires := seconds.next(from, 1)
+
+	// assert
+	assert.True(t, quartz.quartz)
+	assert.False(t, seconds.quartz)
+	assert.Equal(t, time.Monday, quartzFires[0].Time.Weekday())
+	assert.Equal(t, time.Monday, secondsFires[0].Time.Weekday())
+}
+
+func TestCronScheduleNearestWeekday(t *testing.T) {
+	// arrange
+	from := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	schedule, _ := parseCron("0 0 0 1W * ?", false)
+
+	// act
+	fires := schedule.next(from, 1)
+
+	// assert: January 1st 2022 is a Saturday, the nearest weekday in the month is Monday 3rd
+	assert.Equal(t, time.Date(2022, time.January, 3, 0, 0, 0, 0, time.UTC), fires[0].Time)
+}
+
+func TestCronSchedulePrevious(t *testing.T) {
+	// arrange
+	from := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	schedule, _ := parseCron("@monthly", false)
+
+	// act
+	fires := schedule.previous(from, 2)
+
+	// assert
+	assert.Equal(t, time.Date(2021, time.December, 1, 0, 0, 0, 0, time.UTC), fires[0].Time)
+	assert.Equal(t, time.Date(2021, time.November, 1, 0, 0, 0, 0, time.UTC), fires[1].Time)
+}
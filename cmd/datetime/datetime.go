@@ -19,6 +19,7 @@ package datetime
 import (
 	"fmt"
 
+	"github.com/MakeNowJust/heredoc"
 	"github.com/renato0307/canivete/pkg/iostreams"
 	"github.com/spf13/cobra"
 )
@@ -28,13 +29,37 @@ func NewDatetimeCmd(iostreams iostreams.IOStreams) *cobra.Command {
 	var datetimeCmd = &cobra.Command{
 		Use:   "datetime",
 		Short: "Date & time related tools",
-		Long:  ``,
+		Long: heredoc.Doc(`
+			Date & time related tools.
+
+			Dates and times can be given as Unix timestamps, RFC 3339
+			timestamps, ISO 8601 dates and date-times (e.g. 2022-03-21 09:30)
+			or natural language phrases in English or Portuguese, like "now",
+			"tomorrow 9am", "next friday", "in 3 weeks", "2 hours ago",
+			"last day of next month" or "amanhã às 9h30".
+		`),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("must specify a subcommand")
 		},
 	}
 
 	datetimeCmd.AddCommand(NewFromUnixCmd(iostreams))
+	datetimeCmd.AddCommand(NewAddCmd(iostreams))
+	datetimeCmd.AddCommand(NewSubCmd(iostreams))
+	datetimeCmd.AddCommand(NewCronCmd(iostreams))
+	datetimeCmd.AddCommand(NewWorkdaysCmd(iostreams))
+	datetimeCmd.AddCommand(NewSlaCmd(iostreams))
+	datetimeCmd.AddCommand(NewRecurCmd(iostreams))
+	datetimeCmd.AddCommand(NewIcsCmd(iostreams))
+	datetimeCmd.AddCommand(NewMeetCmd(iostreams))
+	datetimeCmd.AddCommand(NewCalCmd(iostreams))
+	datetimeCmd.AddCommand(NewAnnotateCmd(iostreams))
+	datetimeCmd.AddCommand(NewFromIdCmd(iostreams))
+	datetimeCmd.AddCommand(NewZoneCmd(iostreams))
+	datetimeCmd.AddCommand(NewSunCmd(iostreams))
+	datetimeCmd.AddCommand(NewFiscalCmd(iostreams))
+	datetimeCmd.AddCommand(NewTimerCmd(iostreams))
+	datetimeCmd.AddCommand(NewStopwatchCmd(iostreams))
 
 	return datetimeCmd
 }
@@ -0,0 +1,161 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const monthEndClamp = "clamp"
+const monthEndOverflow = "overflow"
+
+// calendarDuration is a duration with calendar units (years, months, weeks
+// and days) which depend on the date they are applied to, plus an exact
+// clock part.
+type calendarDuration struct {
+	Years  int
+	Months int
+	Weeks  int
+	Days   int
+	Clock  time.Duration
+}
+
+var isoDurationRegexp = regexp.MustCompile(
+	`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?` +
+		`(?:T(?:(\d+(?:[.,]\d+)?)H)?(?:(\d+(?:[.,]\d+)?)M)?(?:(\d+(?:[.,]\d+)?)S)?)?$`)
+
+// parseDuration parses ISO 8601 durations (e.g. P1M2DT3H) and Go durations
+// (e.g. 1h30m). Both forms accept a leading minus sign.
+func parseDuration(value string) (calendarDuration, error) {
+	value = strings.TrimSpace(value)
+
+	negative := false
+	if strings.HasPrefix(value, "-") {
+		negative = true
+		value = value[1:]
+	} else if strings.HasPrefix(value, "+") {
+		value = value[1:]
+	}
+
+	var d calendarDuration
+	var err error
+	if strings.HasPrefix(strings.ToUpper(value), "P") {
+		d, err = parseIsoDuration(strings.ToUpper(value))
+	} else {
+		var clock time.Duration
+		clock, err = time.ParseDuration(value)
+		d = calendarDuration{Clock: clock}
+	}
+	if err != nil {
+		return calendarDuration{}, fmt.Errorf("invalid duration %q", value)
+	}
+
+	if negative {
+		d = d.negate()
+	}
+
+	return d, nil
+}
+
+func parseIsoDuration(value string) (calendarDuration, error) {
+	matches := isoDurationRegexp.FindStringSubmatch(value)
+	if matches == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return calendarDuration{}, fmt.Errorf("invalid ISO 8601 duration")
+	}
+
+	d := calendarDuration{}
+	d.Years, _ = atoiOrZero(matches[1])
+	d.Months, _ = atoiOrZero(matches[2])
+	d.Weeks, _ = atoiOrZero(matches[3])
+	d.Days, _ = atoiOrZero(matches[4])
+
+	units := []time.Duration{time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		if matches[5+i] == "" {
+			continue
+		}
+		amount, err := strconv.ParseFloat(strings.Replace(matches[5+i], ",", ".", 1), 64)
+		if err != nil {
+			return calendarDuration{}, err
+		}
+		d.Clock += time.Duration(amount * float64(unit))
+	}
+
+	return d, nil
+}
+
+func atoiOrZero(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func (d calendarDuration) negate() calendarDuration {
+	return calendarDuration{
+		Years:  -d.Years,
+		Months: -d.Months,
+		Weeks:  -d.Weeks,
+		Days:   -d.Days,
+		Clock:  -d.Clock,
+	}
+}
+
+// addTo applies the duration to t. Years and months are applied first,
+// following the month-end rule, then weeks and days on the wall clock of
+// t's location, and finally the exact clock part.
+//
+// With monthEndClamp, Jan 31 + 1 month is Feb 28 (or 29); with
+// monthEndOverflow it overflows into March like time.AddDate does.
+func (d calendarDuration) addTo(t time.Time, monthEnd string) (time.Time, error) {
+	if monthEnd != monthEndClamp && monthEnd != monthEndOverflow {
+		return time.Time{}, fmt.Errorf("invalid month-end rule %q, must be %s or %s",
+			monthEnd, monthEndClamp, monthEndOverflow)
+	}
+
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+
+	totalMonths := int(month) - 1 + d.Months + d.Years*12
+	year += totalMonths / 12
+	totalMonths %= 12
+	if totalMonths < 0 {
+		totalMonths += 12
+		year--
+	}
+	month = time.Month(totalMonths + 1)
+
+	if monthEnd == monthEndClamp {
+		if last := daysIn(year, month); day > last {
+			day = last
+		}
+	}
+
+	day += d.Weeks*7 + d.Days
+	result := time.Date(year, month, day, hour, min, sec, t.Nanosecond(), t.Location())
+
+	return result.Add(d.Clock), nil
+}
+
+// daysIn returns the number of days of a month.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
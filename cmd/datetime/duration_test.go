@@ -0,0 +1,71 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected calendarDuration
+	}{
+		{value: "P1Y2M3W4DT5H6M7S", expected: calendarDuration{Years: 1, Months: 2, Weeks: 3, Days: 4,
+			Clock: 5*time.Hour + 6*time.Minute + 7*time.Second}},
+		{value: "PT1.5H", expected: calendarDuration{Clock: 90 * time.Minute}},
+		{value: "-P1D", expected: calendarDuration{Days: -1}},
+		{value: "1h30m", expected: calendarDuration{Clock: 90 * time.Minute}},
+		{value: "-45s", expected: calendarDuration{Clock: -45 * time.Second}},
+	}
+
+	for _, tc := range testCases {
+		// act
+		d, err := parseDuration(tc.value)
+
+		// assert
+		assert.Nil(t, err, tc.value)
+		assert.Equal(t, tc.expected, d, tc.value)
+	}
+}
+
+func TestParseDurationInvalid(t *testing.T) {
+	for _, value := range []string{"", "P", "PT", "P1H", "1 day", "P1.5Y"} {
+		// act
+		_, err := parseDuration(value)
+
+		// assert
+		assert.NotNil(t, err, value)
+	}
+}
+
+func TestCalendarDurationAddToLeapYear(t *testing.T) {
+	// arrange
+	base := time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)
+	d := calendarDuration{Years: 1}
+
+	// act
+	clamped, _ := d.addTo(base, monthEndClamp)
+	overflowed, _ := d.addTo(base, monthEndOverflow)
+
+	// assert
+	assert.Equal(t, time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC), clamped)
+	assert.Equal(t, time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), overflowed)
+}
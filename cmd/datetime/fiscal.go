@@ -0,0 +1,434 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/calendar"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type fiscalPeriodOutput struct {
+	Year         int
+	YearStart    string
+	YearEnd      string
+	Weeks        int `json:",omitempty"`
+	Quarter      int
+	QuarterStart string
+	QuarterEnd   string
+	Period       int
+	PeriodStart  string
+	PeriodEnd    string
+	Week         int
+	Day          int
+}
+
+type fiscalOutput struct {
+	Date       string
+	Weekday    string
+	ISOYear    int
+	ISOWeek    int
+	ISOWeekday int
+	ISODate    string
+	DayOfYear  int
+	Quarter    int
+	Fiscal     fiscalPeriodOutput
+}
+
+type fiscalRangeOutput struct {
+	Year    int
+	Quarter int `json:",omitempty"`
+	Period  int `json:",omitempty"`
+	Week    int `json:",omitempty"`
+	Start   string
+	End     string
+	Days    int
+}
+
+const flagStartMonth = "start-month"
+const flagPattern = "pattern"
+const flagYearEnd = "year-end"
+const flagLabel = "label"
+const flagQuarter = "quarter"
+const flagPeriod = "period"
+const flagWeek = "week"
+
+const fiscalPatternMonths = "months"
+const fiscalYearEndNearest = "nearest"
+const fiscalYearEndLast = "last"
+const fiscalLabelStart = "start"
+const fiscalLabelEnd = "end"
+
+// fiscalPatterns are the weeks of the periods of each quarter of the retail
+// calendars.
+var fiscalPatterns = map[string][]int{
+	"445": {4, 4, 5},
+	"454": {4, 5, 4},
+	"544": {5, 4, 4},
+}
+
+// fiscalCalendar is a fiscal calendar of 12 months starting in startMonth
+// or, when pattern is set, of 52 or 53 weeks starting on firstWeekday,
+// split in 4 quarters of 3 periods of pattern weeks.
+//
+// A 52/53-week year ends on the last day of the week (the one before
+// firstWeekday) that is the last one of the month before startMonth or,
+// with nearest, the nearest to the end of that month. The extra week of 53
+// week years goes to the last period.
+//
+// Fiscal years are named after the calendar year they start in (label
+// start) or end in (label end, e.g. FY2027 from October 2026 to September
+// 2027), considering whole months.
+type fiscalCalendar struct {
+	startMonth   time.Month
+	pattern      []int
+	firstWeekday time.Weekday
+	nearest      bool
+	labelStart   bool
+}
+
+type fiscalYear struct {
+	label   int
+	start   time.Time
+	end     time.Time
+	weeks   int
+	periods []fiscalRange
+}
+
+// fiscalRange is a range of days, end included.
+type fiscalRange struct {
+	start time.Time
+	end   time.Time
+}
+
+func NewFiscalCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newFiscalCmd(iostreams, systemClock{})
+}
+
+func newFiscalCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var fiscalCmd = &cobra.Command{
+		Use:   "fiscal",
+		Short: "Converts a date into ISO and fiscal calendar periods",
+		Long: heredoc.Doc(`
+			Converts a date into its ISO 8601 year, week and weekday, day of
+			the year, quarter and the year, quarter, period (month), week and
+			day of a fiscal calendar.
+
+			Fiscal years have 12 calendar months starting in --start-month or,
+			with a retail --pattern (445, 454 or 544), 52 or 53 weeks starting
+			on --first-weekday, split in quarters of 3 periods with the
+			pattern's weeks. Retail years end on the last day of the week that
+			is the last (--year-end last) or the nearest (--year-end nearest)
+			to the end of the month before the start month. The 53rd week is
+			added to the last period.
+
+			Fiscal years are named after the year they end in (--label end) or
+			start in (--label start).
+
+			Use the range subcommand for the inverse conversion, from a fiscal
+			period to its dates.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime fiscal --date 2026-11-19
+			canivete datetime fiscal --date 2026-11-19 --start-month oct
+			canivete datetime fiscal --start-month feb --pattern 445 --first-weekday sun --label start
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := getFiscalCalendar(cmd)
+			if err != nil {
+				return err
+			}
+			date, err := getDateFlag(cmd, flagDate, clock.Now())
+			if err != nil {
+				return err
+			}
+
+			return iostreams.PrintOutput(runFiscal(c, date))
+		},
+	}
+
+	fiscalCmd.Flags().StringP(flagDate, "d", "now", "the date to convert")
+	fiscalCmd.Flags().String(flagTimezone, "UTC", "the IANA timezone of the date, e.g. of now or of an instant with an offset (e.g. Europe/Lisbon)")
+
+	fiscalCmd.PersistentFlags().StringP(flagStartMonth, "m", "jan", "the first month of the fiscal year")
+	fiscalCmd.PersistentFlags().StringP(flagPattern, "p", fiscalPatternMonths, "the weeks of the periods of each quarter (months, 445, 454 or 544)")
+	fiscalCmd.PersistentFlags().String(flagFirstWeekday, "mon", "the first day of the week of retail calendars")
+	fiscalCmd.PersistentFlags().String(flagYearEnd, fiscalYearEndNearest, "the end of retail years (nearest or last)")
+	fiscalCmd.PersistentFlags().String(flagLabel, fiscalLabelEnd, "name fiscal years after the year they end or start in (end or start)")
+
+	fiscalCmd.AddCommand(newFiscalRangeCmd(iostreams))
+
+	return fiscalCmd
+}
+
+func newFiscalRangeCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	var rangeCmd = &cobra.Command{
+		Use:   "range",
+		Short: "Returns the dates of a fiscal year, quarter, period or week",
+		Example: heredoc.Doc(`
+			canivete datetime fiscal range --year 2027 --start-month oct
+			canivete datetime fiscal range --year 2027 --quarter 2 --start-month oct
+			canivete datetime fiscal range --year 2026 --period 12 --pattern 445 -m feb --label start
+			canivete datetime fiscal range --year 2026 --week 53 --pattern 445
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := getFiscalCalendar(cmd)
+			if err != nil {
+				return err
+			}
+			year, _ := cmd.Flags().GetInt(flagYear)
+			quarter, _ := cmd.Flags().GetInt(flagQuarter)
+			period, _ := cmd.Flags().GetInt(flagPeriod)
+			week, _ := cmd.Flags().GetInt(flagWeek)
+
+			output, err := runFiscalRange(c, year, quarter, period, week)
+			if err != nil {
+				return err
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	rangeCmd.Flags().IntP(flagYear, "y", 0, "the fiscal year")
+	rangeCmd.MarkFlagRequired(flagYear)
+	rangeCmd.Flags().IntP(flagQuarter, "q", 0, "the quarter of the fiscal year (1 to 4)")
+	rangeCmd.Flags().Int(flagPeriod, 0, "the period (month) of the fiscal year (1 to 12)")
+	rangeCmd.Flags().IntP(flagWeek, "w", 0, "the week of the fiscal year (1 to 53)")
+
+	return rangeCmd
+}
+
+func getFiscalCalendar(cmd *cobra.Command) (fiscalCalendar, error) {
+	startMonth, _ := cmd.Flags().GetString(flagStartMonth)
+	pattern, _ := cmd.Flags().GetString(flagPattern)
+	firstWeekday, _ := cmd.Flags().GetString(flagFirstWeekday)
+	yearEnd, _ := cmd.Flags().GetString(flagYearEnd)
+	label, _ := cmd.Flags().GetString(flagLabel)
+
+	return newFiscalCalendar(startMonth, pattern, firstWeekday, yearEnd, label)
+}
+
+func newFiscalCalendar(startMonth, pattern, firstWeekday, yearEnd, label string) (fiscalCalendar, error) {
+	c := fiscalCalendar{}
+
+	month, err := parseCronValue(strings.ToUpper(startMonth), 1, 12, monthNames)
+	if err != nil {
+		return c, fmt.Errorf("invalid month %q", startMonth)
+	}
+	c.startMonth = time.Month(month)
+
+	if pattern = strings.ReplaceAll(pattern, "-", ""); pattern != fiscalPatternMonths {
+		var ok bool
+		if c.pattern, ok = fiscalPatterns[pattern]; !ok {
+			return c, fmt.Errorf("invalid pattern %q, must be months, 445, 454 or 544", pattern)
+		}
+	}
+
+	if c.firstWeekday, err = calendar.ParseWeekday(firstWeekday); err != nil {
+		return c, err
+	}
+
+	switch yearEnd {
+	case fiscalYearEndNearest, fiscalYearEndLast:
+		c.nearest = yearEnd == fiscalYearEndNearest
+	default:
+		return c, fmt.Errorf("invalid year end %q, must be %s or %s", yearEnd, fiscalYearEndNearest, fiscalYearEndLast)
+	}
+
+	switch label {
+	case fiscalLabelStart, fiscalLabelEnd:
+		c.labelStart = label == fiscalLabelStart
+	default:
+		return c, fmt.Errorf("invalid label %q, must be %s or %s", label, fiscalLabelEnd, fiscalLabelStart)
+	}
+
+	return c, nil
+}
+
+// label returns the name of the fiscal year whose nominal first month is
+// startMonth of year.
+func (c fiscalCalendar) label(year int) int {
+	if c.labelStart || c.startMonth == time.January {
+		return year
+	}
+	return year + 1
+}
+
+// yearEnd returns the last day of the retail year whose nominal first month
+// is startMonth of year.
+func (c fiscalCalendar) yearEnd(year int) time.Time {
+	lastDay := time.Date(year+1, c.startMonth, 0, 0, 0, 0, 0, time.UTC)
+	lastWeekday := (c.firstWeekday + 6) % 7
+
+	back := (int(lastDay.Weekday()) - int(lastWeekday) + 7) % 7
+	if c.nearest && back > 3 {
+		return lastDay.AddDate(0, 0, 7-back)
+	}
+	return lastDay.AddDate(0, 0, -back)
+}
+
+// year returns the fiscal year whose nominal first month is startMonth of
+// year.
+func (c fiscalCalendar) year(year int) fiscalYear {
+	fy := fiscalYear{label: c.label(year)}
+
+	if c.pattern == nil {
+		fy.start = time.Date(year, c.startMonth, 1, 0, 0, 0, 0, time.UTC)
+		fy.end = fy.start.AddDate(1, 0, -1)
+		for i := 0; i < 12; i++ {
+			start := fy.start.AddDate(0, i, 0)
+			fy.periods = append(fy.periods, fiscalRange{start, start.AddDate(0, 1, -1)})
+		}
+		return fy
+	}
+
+	fy.start = c.yearEnd(year-1).AddDate(0, 0, 1)
+	fy.end = c.yearEnd(year)
+	fy.weeks = int(fy.end.Sub(fy.start).Hours()/24+1) / 7
+
+	start := fy.start
+	for i := 0; i < 12; i++ {
+		weeks := c.pattern[i%3]
+		if i == 11 {
+			weeks += fy.weeks - 52
+		}
+		end := start.AddDate(0, 0, 7*weeks)
+		fy.periods = append(fy.periods, fiscalRange{start, end.AddDate(0, 0, -1)})
+		start = end
+	}
+
+	return fy
+}
+
+// yearOf returns the fiscal year of a date.
+func (c fiscalCalendar) yearOf(date time.Time) fiscalYear {
+	for year := date.Year() - 1; year <= date.Year(); year++ {
+		if fy := c.year(year); !date.Before(fy.start) && !date.After(fy.end) {
+			return fy
+		}
+	}
+	return c.year(date.Year() + 1)
+}
+
+// yearNamed returns the fiscal year with a name.
+func (c fiscalCalendar) yearNamed(label int) fiscalYear {
+	return c.year(label - c.label(0))
+}
+
+func runFiscal(c fiscalCalendar, date time.Time) fiscalOutput {
+	isoYear, isoWeek := date.ISOWeek()
+	isoWeekday := (int(date.Weekday())+6)%7 + 1
+
+	output := fiscalOutput{
+		Date:       date.Format(dateLayout),
+		Weekday:    date.Weekday().String(),
+		ISOYear:    isoYear,
+		ISOWeek:    isoWeek,
+		ISOWeekday: isoWeekday,
+		ISODate:    fmt.Sprintf("%04d-W%02d-%d", isoYear, isoWeek, isoWeekday),
+		DayOfYear:  date.YearDay(),
+		Quarter:    (int(date.Month())-1)/3 + 1,
+	}
+
+	fy := c.yearOf(date)
+	day := int(date.Sub(fy.start).Hours()/24) + 1
+	output.Fiscal = fiscalPeriodOutput{
+		Year:      fy.label,
+		YearStart: fy.start.Format(dateLayout),
+		YearEnd:   fy.end.Format(dateLayout),
+		Weeks:     fy.weeks,
+		Week:      (day-1)/7 + 1,
+		Day:       day,
+	}
+
+	for i, period := range fy.periods {
+		if date.After(period.end) {
+			continue
+		}
+		quarter := fy.quarter(i/3 + 1)
+		output.Fiscal.Quarter = i/3 + 1
+		output.Fiscal.QuarterStart = quarter.start.Format(dateLayout)
+		output.Fiscal.QuarterEnd = quarter.end.Format(dateLayout)
+		output.Fiscal.Period = i + 1
+		output.Fiscal.PeriodStart = period.start.Format(dateLayout)
+		output.Fiscal.PeriodEnd = period.end.Format(dateLayout)
+		break
+	}
+
+	return output
+}
+
+func (fy fiscalYear) quarter(quarter int) fiscalRange {
+	return fiscalRange{fy.periods[3*quarter-3].start, fy.periods[3*quarter-1].end}
+}
+
+// runFiscalRange returns the dates of a fiscal year or, when set, of one of
+// its quarters, periods or weeks.
+func runFiscalRange(c fiscalCalendar, year, quarter, period, week int) (fiscalRangeOutput, error) {
+	set := 0
+	for _, value := range []int{quarter, period, week} {
+		if value != 0 {
+			set++
+		}
+	}
+	if set > 1 {
+		return fiscalRangeOutput{}, fmt.Errorf("only one of quarter, period and week can be set")
+	}
+
+	fy := c.yearNamed(year)
+	r := fiscalRange{fy.start, fy.end}
+
+	switch {
+	case quarter != 0:
+		if quarter < 1 || quarter > 4 {
+			return fiscalRangeOutput{}, fmt.Errorf("invalid quarter %d, must be between 1 and 4", quarter)
+		}
+		r = fy.quarter(quarter)
+	case period != 0:
+		if period < 1 || period > 12 {
+			return fiscalRangeOutput{}, fmt.Errorf("invalid period %d, must be between 1 and 12", period)
+		}
+		r = fy.periods[period-1]
+	case week != 0:
+		weeks := int(fy.end.Sub(fy.start).Hours()/24)/7 + 1
+		if week < 1 || week > weeks {
+			return fiscalRangeOutput{}, fmt.Errorf("invalid week %d, fiscal year %d has %d weeks", week, year, weeks)
+		}
+		r.start = fy.start.AddDate(0, 0, 7*(week-1))
+		if end := r.start.AddDate(0, 0, 6); end.Before(fy.end) {
+			r.end = end
+		}
+	}
+
+	return fiscalRangeOutput{
+		Year:    year,
+		Quarter: quarter,
+		Period:  period,
+		Week:    week,
+		Start:   r.start.Format(dateLayout),
+		End:     r.end.Format(dateLayout),
+		Days:    int(r.end.Sub(r.start).Hours()/24) + 1,
+	}, nil
+}
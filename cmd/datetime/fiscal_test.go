@@ -0,0 +1,224 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+// nrfCalendar is the 4-5-4 calendar of the National Retail Federation.
+func nrfCalendar(t *testing.T) fiscalCalendar {
+	c, err := newFiscalCalendar("feb", "454", "sun", "nearest", "start")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestFiscalCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewFiscalCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"--date=2026-11-19", "--start-month=oct"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := fiscalOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	assert.Equal(t, fiscalOutput{
+		Date:       "2026-11-19",
+		Weekday:    "Thursday",
+		ISOYear:    2026,
+		ISOWeek:    47,
+		ISOWeekday: 4,
+		ISODate:    "2026-W47-4",
+		DayOfYear:  323,
+		Quarter:    4,
+		Fiscal: fiscalPeriodOutput{
+			Year:         2027,
+			YearStart:    "2026-10-01",
+			YearEnd:      "2027-09-30",
+			Quarter:      1,
+			QuarterStart: "2026-10-01",
+			QuarterEnd:   "2026-12-31",
+			Period:       2,
+			PeriodStart:  "2026-11-01",
+			PeriodEnd:    "2026-11-30",
+			Week:         8,
+			Day:          50,
+		},
+	}, output)
+}
+
+func TestFiscalCmdTimezone(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewFiscalCmd(*iostreams)
+
+	// act: already 2027 in Tokyo
+	cmd.SetArgs([]string{"--date=2026-12-31T23:30:00Z", "--tz=Asia/Tokyo"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := fiscalOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	assert.Equal(t, "2027-01-01", output.Date)
+}
+
+func TestRunFiscalIsoYearBoundary(t *testing.T) {
+	// arrange
+	c, _ := newFiscalCalendar("jan", "months", "mon", "nearest", "end")
+
+	// act
+	output := runFiscal(c, time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	// assert
+	assert.Equal(t, "2026-W53-5", output.ISODate)
+	assert.Equal(t, 2027, output.Fiscal.Year)
+	assert.Equal(t, 1, output.Fiscal.Period)
+}
+
+func TestRunFiscalRetail(t *testing.T) {
+	testCases := []struct {
+		date      time.Time
+		year      int
+		yearStart string
+		yearEnd   string
+		weeks     int
+		period    int
+		week      int
+	}{
+		{time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC), 2025, "2025-02-02", "2026-01-31", 52, 12, 52},
+		{time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC), 2026, "2026-02-01", "2027-01-30", 52, 1, 1},
+		{time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC), 2026, "2026-02-01", "2027-01-30", 52, 2, 5},
+		{time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC), 2023, "2023-01-29", "2024-02-03", 53, 12, 53},
+	}
+
+	for _, tc := range testCases {
+		// act
+		output := runFiscal(nrfCalendar(t), tc.date)
+
+		// assert
+		assert.Equal(t, tc.year, output.Fiscal.Year, tc.date)
+		assert.Equal(t, tc.yearStart, output.Fiscal.YearStart, tc.date)
+		assert.Equal(t, tc.yearEnd, output.Fiscal.YearEnd, tc.date)
+		assert.Equal(t, tc.weeks, output.Fiscal.Weeks, tc.date)
+		assert.Equal(t, tc.period, output.Fiscal.Period, tc.date)
+		assert.Equal(t, tc.week, output.Fiscal.Week, tc.date)
+	}
+}
+
+func TestFiscalYearEndLast(t *testing.T) {
+	// arrange: years ending on the last Saturday of August
+	c, _ := newFiscalCalendar("sep", "445", "sun", "last", "end")
+
+	// act
+	fy := c.yearNamed(2027)
+
+	// assert
+	assert.Equal(t, time.Date(2026, time.August, 30, 0, 0, 0, 0, time.UTC), fy.start)
+	assert.Equal(t, time.Date(2027, time.August, 28, 0, 0, 0, 0, time.UTC), fy.end)
+	assert.Equal(t, 52, fy.weeks)
+}
+
+func TestFiscalRangeCmd(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected fiscalRangeOutput
+	}{
+		{
+			[]string{"--year=2027", "--start-month=oct"},
+			fiscalRangeOutput{Year: 2027, Start: "2026-10-01", End: "2027-09-30", Days: 365},
+		},
+		{
+			[]string{"--year=2027", "--quarter=2", "--start-month=oct"},
+			fiscalRangeOutput{Year: 2027, Quarter: 2, Start: "2027-01-01", End: "2027-03-31", Days: 90},
+		},
+		{
+			[]string{"--year=2023", "--period=12", "--pattern=4-5-4", "-m=feb", "--first-weekday=sun", "--label=start"},
+			fiscalRangeOutput{Year: 2023, Period: 12, Start: "2023-12-31", End: "2024-02-03", Days: 35},
+		},
+		{
+			[]string{"--year=2023", "--week=53", "--pattern=454", "-m=feb", "--first-weekday=sun", "--label=start"},
+			fiscalRangeOutput{Year: 2023, Week: 53, Start: "2024-01-28", End: "2024-02-03", Days: 7},
+		},
+		{
+			[]string{"--year=2026", "--week=53"},
+			fiscalRangeOutput{Year: 2026, Week: 53, Start: "2026-12-31", End: "2026-12-31", Days: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewFiscalCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(append([]string{"range"}, tc.args...))
+		_, err := cmd.ExecuteC()
+
+		// assert
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := fiscalRangeOutput{}
+		assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+		assert.Equal(t, tc.expected, output, tc.args)
+	}
+}
+
+func TestFiscalRangeErrors(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--year=2026", "--quarter=1", "--week=2"}, "only one of quarter, period and week can be set"},
+		{[]string{"--year=2026", "--quarter=5"}, "invalid quarter 5, must be between 1 and 4"},
+		{[]string{"--year=2026", "--period=13"}, "invalid period 13, must be between 1 and 12"},
+		{[]string{"--year=2027", "--week=53", "--pattern=445"}, "invalid week 53, fiscal year 2027 has 52 weeks"},
+		{[]string{"--year=2026", "--pattern=446"}, `invalid pattern "446", must be months, 445, 454 or 544`},
+		{[]string{"--year=2026", "--start-month=foo"}, `invalid month "foo"`},
+		{[]string{"--year=2026", "--year-end=first"}, `invalid year end "first", must be nearest or last`},
+		{[]string{"--year=2026", "--label=middle"}, `invalid label "middle", must be end or start`},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewFiscalCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(append([]string{"range"}, tc.args...))
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.EqualError(t, err, tc.expected, tc.args)
+	}
+}
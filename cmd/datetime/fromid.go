@@ -0,0 +1,363 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/google/uuid"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type fromIdOutput struct {
+	Type string
+	fromUnixOutput
+	UnixMilliseconds int64
+	Fields           map[string]interface{} `json:",omitempty"`
+}
+
+const flagType = "type"
+const flagEpoch = "epoch"
+
+const idTypeAuto = "auto"
+const idTypeUuid = "uuid"
+const idTypeUlid = "ulid"
+const idTypeKsuid = "ksuid"
+const idTypeSnowflake = "snowflake"
+const idTypeObjectId = "objectid"
+const idTypeXid = "xid"
+
+// snowflakeEpochs are the well known Snowflake epochs, in Unix milliseconds.
+var snowflakeEpochs = map[string]int64{
+	"twitter": 1288834974657,
+	"discord": 1420070400000,
+}
+
+// uuidEpochOffset is the number of 100 nanosecond intervals between the
+// Gregorian epoch of UUIDs (1582-10-15) and the Unix epoch.
+const uuidEpochOffset = 122192928000000000
+
+// ksuidEpoch is the Unix time of the KSUID epoch (2014-05-13T16:53:20Z).
+const ksuidEpoch = 1400000000
+
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+const ksuidAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var xidEncoding = base32.NewEncoding("0123456789abcdefghijklmnopqrstuv").WithPadding(base32.NoPadding)
+
+var uuidRegexp = regexp.MustCompile(`^(?i)(?:urn:uuid:)?\{?[0-9a-f]{8}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{12}\}?$`)
+var ulidRegexp = regexp.MustCompile(`^(?i)[0-7][0-9a-hjkmnp-tv-z]{25}$`)
+var ksuidRegexp = regexp.MustCompile(`^[0-9A-Za-z]{27}$`)
+var snowflakeRegexp = regexp.MustCompile(`^[0-9]{1,20}$`)
+var objectIdRegexp = regexp.MustCompile(`^(?i)[0-9a-f]{24}$`)
+var xidRegexp = regexp.MustCompile(`^[0-9a-v]{20}$`)
+
+func NewFromIdCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	var fromIdCmd = &cobra.Command{
+		Use:   "fromid <id>",
+		Short: "Extracts the creation time of an ID",
+		Long: heredoc.Doc(`
+			Extracts the creation time embedded in an ID, along with the other
+			fields it encodes.
+
+			The type of the ID is detected from its format:
+
+			  uuid       UUID versions 1, 6 and 7
+			  ulid       26 Crockford base32 characters
+			  ksuid      27 base62 characters
+			  snowflake  a number, with the epoch set by --epoch
+			  objectid   24 hexadecimal characters (MongoDB)
+			  xid        20 base32hex characters
+
+			The Snowflake epoch is twitter, discord or a Unix time in
+			milliseconds.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime fromid 017f22e2-79b0-7cc3-98c4-dc0c0c07398f
+			canivete datetime fromid 01ARZ3NDEKTSV4RRFFQ69G5FAV --tz Europe/Lisbon
+			canivete datetime fromid 175928847299117063 --epoch discord
+			canivete datetime fromid 1400000000000 --type snowflake --epoch 1420070400000
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			idType, _ := cmd.Flags().GetString(flagType)
+			epochName, _ := cmd.Flags().GetString(flagEpoch)
+			tz, _ := cmd.Flags().GetString(flagTimezone)
+
+			epoch, err := parseSnowflakeEpoch(epochName)
+			if err != nil {
+				return err
+			}
+			loc, err := loadLocation(tz)
+			if err != nil {
+				return err
+			}
+
+			output, err := runFromId(args[0], idType, epoch, loc)
+			if err != nil {
+				return err
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	fromIdCmd.Flags().StringP(flagType, "t", idTypeAuto, "the type of the ID (auto, uuid, ulid, ksuid, snowflake, objectid or xid)")
+	fromIdCmd.Flags().String(flagEpoch, "twitter", "the epoch of Snowflake IDs (twitter, discord or Unix milliseconds)")
+	fromIdCmd.Flags().String(flagTimezone, "", "the IANA timezone of the local timestamp (e.g. Europe/Lisbon)")
+
+	return fromIdCmd
+}
+
+func parseSnowflakeEpoch(value string) (int64, error) {
+	if epoch, ok := snowflakeEpochs[strings.ToLower(value)]; ok {
+		return epoch, nil
+	}
+
+	epoch, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || epoch < 0 {
+		return 0, fmt.Errorf("invalid epoch %q, must be twitter, discord or Unix milliseconds", value)
+	}
+	return epoch, nil
+}
+
+func runFromId(id, idType string, snowflakeEpoch int64, loc *time.Location) (fromIdOutput, error) {
+	id = strings.TrimSpace(id)
+
+	if idType == idTypeAuto {
+		var err error
+		if idType, err = detectIdType(id); err != nil {
+			return fromIdOutput{}, err
+		}
+	}
+
+	var t time.Time
+	var fields map[string]interface{}
+	var err error
+
+	switch idType {
+	case idTypeUuid:
+		t, fields, err = decodeUuid(id)
+	case idTypeUlid:
+		t, fields, err = decodeUlid(id)
+	case idTypeKsuid:
+		t, fields, err = decodeKsuid(id)
+	case idTypeSnowflake:
+		t, fields, err = decodeSnowflake(id, snowflakeEpoch)
+	case idTypeObjectId:
+		t, fields, err = decodeObjectId(id)
+	case idTypeXid:
+		t, fields, err = decodeXid(id)
+	default:
+		return fromIdOutput{}, fmt.Errorf("invalid type %q", idType)
+	}
+	if err != nil {
+		return fromIdOutput{}, err
+	}
+
+	return fromIdOutput{
+		Type:             idType,
+		fromUnixOutput:   newFromUnixOutput(t.In(loc)),
+		UnixMilliseconds: t.UnixNano() / int64(time.Millisecond),
+		Fields:           fields,
+	}, nil
+}
+
+// detectIdType returns the type of an ID from its format. Numbers are
+// Snowflakes, even when they would also be valid xids.
+func detectIdType(id string) (string, error) {
+	switch {
+	case snowflakeRegexp.MatchString(id):
+		return idTypeSnowflake, nil
+	case uuidRegexp.MatchString(id):
+		return idTypeUuid, nil
+	case ulidRegexp.MatchString(id):
+		return idTypeUlid, nil
+	case ksuidRegexp.MatchString(id):
+		return idTypeKsuid, nil
+	case objectIdRegexp.MatchString(id):
+		return idTypeObjectId, nil
+	case xidRegexp.MatchString(id):
+		return idTypeXid, nil
+	}
+
+	return "", fmt.Errorf("unrecognized id %q", id)
+}
+
+// decodeUuid extracts the time of version 1 and 6 UUIDs (100 nanosecond
+// intervals since 1582-10-15) and version 7 UUIDs (Unix milliseconds).
+func decodeUuid(id string) (time.Time, map[string]interface{}, error) {
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("invalid uuid %q", id)
+	}
+
+	fields := map[string]interface{}{
+		"Version": int(u.Version()),
+		"Variant": u.Variant().String(),
+	}
+
+	var t time.Time
+	switch u.Version() {
+	case 1, 6:
+		var ticks int64
+		if u.Version() == 1 {
+			ticks = int64(binary.BigEndian.Uint32(u[0:4])) |
+				int64(binary.BigEndian.Uint16(u[4:6]))<<32 |
+				int64(binary.BigEndian.Uint16(u[6:8])&0x0fff)<<48
+		} else {
+			ticks = int64(binary.BigEndian.Uint32(u[0:4]))<<28 |
+				int64(binary.BigEndian.Uint16(u[4:6]))<<12 |
+				int64(binary.BigEndian.Uint16(u[6:8])&0x0fff)
+		}
+		ticks -= uuidEpochOffset
+		t = time.Unix(ticks/1e7, ticks%1e7*100).UTC()
+		fields["ClockSequence"] = int(binary.BigEndian.Uint16(u[8:10]) & 0x3fff)
+		fields["Node"] = formatMac(u[10:16])
+	case 7:
+		ms := int64(binary.BigEndian.Uint64(append([]byte{0, 0}, u[0:6]...)))
+		t = time.Unix(0, ms*int64(time.Millisecond)).UTC()
+		fields["Random"] = hex.EncodeToString(u[6:16])
+	default:
+		return time.Time{}, nil, fmt.Errorf("uuid version %d has no timestamp", u.Version())
+	}
+
+	return t, fields, nil
+}
+
+func formatMac(b []byte) string {
+	parts := []string{}
+	for _, octet := range b {
+		parts = append(parts, fmt.Sprintf("%02x", octet))
+	}
+	return strings.Join(parts, ":")
+}
+
+// decodeUlid extracts the time of a ULID, the Unix milliseconds of its first
+// 48 bits.
+func decodeUlid(id string) (time.Time, map[string]interface{}, error) {
+	if !ulidRegexp.MatchString(id) {
+		return time.Time{}, nil, fmt.Errorf("invalid ulid %q", id)
+	}
+
+	b := decodeBase(strings.ToUpper(id), ulidAlphabet, 16)
+	ms := int64(binary.BigEndian.Uint64(append([]byte{0, 0}, b[0:6]...)))
+
+	fields := map[string]interface{}{"Random": hex.EncodeToString(b[6:])}
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC(), fields, nil
+}
+
+// decodeKsuid extracts the time of a KSUID, the seconds since the KSUID
+// epoch of its first 32 bits.
+func decodeKsuid(id string) (time.Time, map[string]interface{}, error) {
+	var b []byte
+	if ksuidRegexp.MatchString(id) {
+		b = decodeBase(id, ksuidAlphabet, 20)
+	}
+	if b == nil {
+		return time.Time{}, nil, fmt.Errorf("invalid ksuid %q", id)
+	}
+
+	seconds := int64(binary.BigEndian.Uint32(b[0:4])) + ksuidEpoch
+
+	fields := map[string]interface{}{"Payload": hex.EncodeToString(b[4:])}
+	return time.Unix(seconds, 0).UTC(), fields, nil
+}
+
+// decodeBase decodes a number written with the digits of alphabet into
+// size bytes, returning nil when it doesn't fit.
+func decodeBase(value, alphabet string, size int) []byte {
+	n := new(big.Int)
+	base := big.NewInt(int64(len(alphabet)))
+	for _, c := range value {
+		n.Mul(n, base)
+		n.Add(n, big.NewInt(int64(strings.IndexRune(alphabet, c))))
+	}
+
+	b := n.Bytes()
+	if len(b) > size {
+		return nil
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
+
+// decodeSnowflake extracts the time of a Snowflake, the milliseconds since
+// the epoch above its 22 lower bits.
+func decodeSnowflake(id string, epoch int64) (time.Time, map[string]interface{}, error) {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("invalid snowflake %q", id)
+	}
+
+	ms := int64(n>>22) + epoch
+
+	fields := map[string]interface{}{
+		"Epoch":    epoch,
+		"Machine":  int(n >> 12 & 0x3ff),
+		"Sequence": int(n & 0xfff),
+	}
+	if epoch == snowflakeEpochs["discord"] {
+		fields["WorkerId"] = int(n >> 17 & 0x1f)
+		fields["ProcessId"] = int(n >> 12 & 0x1f)
+	} else {
+		fields["DatacenterId"] = int(n >> 17 & 0x1f)
+		fields["WorkerId"] = int(n >> 12 & 0x1f)
+	}
+
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC(), fields, nil
+}
+
+// decodeObjectId extracts the time of a MongoDB ObjectId, the Unix seconds
+// of its first 4 bytes.
+func decodeObjectId(id string) (time.Time, map[string]interface{}, error) {
+	b, err := hex.DecodeString(id)
+	if err != nil || len(b) != 12 {
+		return time.Time{}, nil, fmt.Errorf("invalid objectid %q", id)
+	}
+
+	fields := map[string]interface{}{
+		"Random":  hex.EncodeToString(b[4:9]),
+		"Counter": int(b[9])<<16 | int(b[10])<<8 | int(b[11]),
+	}
+	return time.Unix(int64(binary.BigEndian.Uint32(b[0:4])), 0).UTC(), fields, nil
+}
+
+// decodeXid extracts the time of an xid, the Unix seconds of its first 4
+// bytes.
+func decodeXid(id string) (time.Time, map[string]interface{}, error) {
+	b, err := xidEncoding.DecodeString(id)
+	if err != nil || len(b) != 12 {
+		return time.Time{}, nil, fmt.Errorf("invalid xid %q", id)
+	}
+
+	fields := map[string]interface{}{
+		"Machine":   hex.EncodeToString(b[4:7]),
+		"ProcessId": int(binary.BigEndian.Uint16(b[7:9])),
+		"Counter":   int(b[9])<<16 | int(b[10])<<8 | int(b[11]),
+	}
+	return time.Unix(int64(binary.BigEndian.Uint32(b[0:4])), 0).UTC(), fields, nil
+}
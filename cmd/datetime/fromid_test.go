@@ -0,0 +1,134 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromIdCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewFromIdCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"175928847299117063", "--epoch=discord", "--tz=Europe/Lisbon"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), `"Type": "snowflake"`)
+	assert.Contains(t, out.String(), "Sat Apr 30 11:18:25 UTC 2016")
+	assert.Contains(t, out.String(), "Sat Apr 30 12:18:25 WEST 2016")
+	assert.Contains(t, out.String(), `"UnixMilliseconds": 1462015105796`)
+}
+
+func TestRunFromId(t *testing.T) {
+	testCases := []struct {
+		id       string
+		idType   string
+		expected string
+		fields   map[string]interface{}
+	}{
+		{"c232ab00-9414-11ec-b3c8-9f6bdeced846", "uuid", "2022-02-22T19:22:22Z",
+			map[string]interface{}{"Version": 1, "Variant": "RFC4122", "ClockSequence": 0x33c8, "Node": "9f:6b:de:ce:d8:46"}},
+		{"1EC9414C-232A-6B00-B3C8-9F6BDECED846", "uuid", "2022-02-22T19:22:22Z",
+			map[string]interface{}{"Version": 6, "Variant": "RFC4122", "ClockSequence": 0x33c8, "Node": "9f:6b:de:ce:d8:46"}},
+		{"017f22e2-79b0-7cc3-98c4-dc0c0c07398f", "uuid", "2022-02-22T19:22:22Z",
+			map[string]interface{}{"Version": 7, "Variant": "RFC4122", "Random": "7cc398c4dc0c0c07398f"}},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAV", "ulid", "2016-07-30T23:54:10.259Z",
+			map[string]interface{}{"Random": "d6764c61efb99302bd5b"}},
+		{"0ujtsYcgvSTl8PAuAdqWYSMnLOv", "ksuid", "2017-10-10T04:00:47Z",
+			map[string]interface{}{"Payload": "b5a1cd34b5f99d1154fb6853345c9735"}},
+		{"507f1f77bcf86cd799439011", "objectid", "2012-10-17T21:13:27Z",
+			map[string]interface{}{"Random": "bcf86cd799", "Counter": 0x439011}},
+		{"9m4e2mr0ui3e8a215n4g", "xid", "2011-03-22T17:50:19Z",
+			map[string]interface{}{"Machine": "60f486", "ProcessId": 58408, "Counter": 4271561}},
+		{"1212161655375028224", "snowflake", "2020-01-01T00:00:34.172Z",
+			map[string]interface{}{"Epoch": int64(1288834974657), "Machine": 934, "DatacenterId": 29, "WorkerId": 6, "Sequence": 0}},
+	}
+
+	for _, tc := range testCases {
+		// act
+		output, err := runFromId(tc.id, idTypeAuto, snowflakeEpochs["twitter"], time.UTC)
+
+		// assert
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := time.Unix(0, output.UnixMilliseconds*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano)
+		assert.Equal(t, tc.idType, output.Type, tc.id)
+		assert.Equal(t, tc.expected, actual, tc.id)
+		assert.Equal(t, tc.fields, output.Fields, tc.id)
+		assert.Empty(t, output.LocalTimestamp, tc.id)
+	}
+}
+
+func TestRunFromIdCustomEpoch(t *testing.T) {
+	// act
+	output, err := runFromId("4194304", idTypeSnowflake, 1420070400000, time.UTC)
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1420070400001), output.UnixMilliseconds)
+}
+
+func TestRunFromIdErrors(t *testing.T) {
+	testCases := []struct {
+		id       string
+		idType   string
+		expected string
+	}{
+		{"not-an-id", idTypeAuto, `unrecognized id "not-an-id"`},
+		{"6ba7b810-9dad-41d1-80b4-00c04fd430c8", idTypeAuto, "uuid version 4 has no timestamp"},
+		{"abc", idTypeUuid, `invalid uuid "abc"`},
+		{"81ARZ3NDEKTSV4RRFFQ69G5FAV", idTypeUlid, `invalid ulid "81ARZ3NDEKTSV4RRFFQ69G5FAV"`},
+		{"zzzzzzzzzzzzzzzzzzzzzzzzzzz", idTypeKsuid, `invalid ksuid "zzzzzzzzzzzzzzzzzzzzzzzzzzz"`},
+		{"99999999999999999999", idTypeSnowflake, `invalid snowflake "99999999999999999999"`},
+		{"507f1f77", idTypeObjectId, `invalid objectid "507f1f77"`},
+		{"9m4e2mr0ui3e8a215n4g", "guid", `invalid type "guid"`},
+	}
+
+	for _, tc := range testCases {
+		// act
+		_, err := runFromId(tc.id, tc.idType, 0, time.UTC)
+
+		// assert
+		assert.EqualError(t, err, tc.expected, tc.id)
+	}
+}
+
+func TestParseSnowflakeEpoch(t *testing.T) {
+	// act
+	discord, err := parseSnowflakeEpoch("Discord")
+	custom, _ := parseSnowflakeEpoch("1000")
+	_, invalidErr := parseSnowflakeEpoch("mastodon")
+
+	// assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1420070400000), discord)
+	assert.Equal(t, int64(1000), custom)
+	assert.EqualError(t, invalidErr, `invalid epoch "mastodon", must be twitter, discord or Unix milliseconds`)
+}
@@ -25,8 +25,9 @@ import (
 )
 
 type fromUnixOutput struct {
-	UnixTimestamp int64
-	UtcTimestamp  string
+	UnixTimestamp  int64
+	UtcTimestamp   string
+	LocalTimestamp string `json:",omitempty"`
 }
 
 func NewFromUnixCmd(iostreams iostreams.IOStreams) *cobra.Command {
@@ -60,7 +61,21 @@ func NewFromUnixCmd(iostreams iostreams.IOStreams) *cobra.Command {
 
 func run(unixTime int64) fromUnixOutput {
 	t := time.Unix(unixTime, 0)
-	strDate := t.UTC().Format(time.UnixDate)
 
-	return fromUnixOutput{UnixTimestamp: unixTime, UtcTimestamp: strDate}
+	return newFromUnixOutput(t.UTC())
+}
+
+// newFromUnixOutput renders an instant, adding the local timestamp when t
+// is not in UTC.
+func newFromUnixOutput(t time.Time) fromUnixOutput {
+	output := fromUnixOutput{
+		UnixTimestamp: t.Unix(),
+		UtcTimestamp:  t.UTC().Format(time.UnixDate),
+	}
+
+	if t.Location() != time.UTC {
+		output.LocalTimestamp = t.Format(time.UnixDate)
+	}
+
+	return output
 }
@@ -0,0 +1,693 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const icsDateLayout = "20060102"
+const icsLocalLayout = "20060102T150405"
+const icsUTCLayout = "20060102T150405Z"
+
+// icsMaxLineOctets is the maximum length of a content line before folding.
+const icsMaxLineOctets = 75
+
+// icsProperty is a content line of an iCalendar file, e.g.
+// DTSTART;TZID=Europe/Lisbon:20220322T100000.
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// icsComponent is a BEGIN/END block, e.g. VEVENT or VTIMEZONE.
+type icsComponent struct {
+	name       string
+	properties []icsProperty
+	children   []*icsComponent
+}
+
+func (c *icsComponent) property(name string) (icsProperty, bool) {
+	for _, p := range c.properties {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return icsProperty{}, false
+}
+
+func (c *icsComponent) propertiesNamed(name string) []icsProperty {
+	properties := []icsProperty{}
+	for _, p := range c.properties {
+		if p.name == name {
+			properties = append(properties, p)
+		}
+	}
+	return properties
+}
+
+func (c *icsComponent) text(name string) string {
+	p, _ := c.property(name)
+	return unescapeIcsText(p.value)
+}
+
+func (c *icsComponent) childrenNamed(name string) []*icsComponent {
+	children := []*icsComponent{}
+	for _, child := range c.children {
+		if child.name == name {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// parseIcs parses an iCalendar stream, returning its VCALENDAR components.
+func parseIcs(r io.Reader) ([]*icsComponent, error) {
+	lines, err := unfoldIcsLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &icsComponent{}
+	stack := []*icsComponent{root}
+
+	for _, line := range lines {
+		p, err := parseIcsContentLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		current := stack[len(stack)-1]
+		switch p.name {
+		case "BEGIN":
+			child := &icsComponent{name: strings.ToUpper(p.value)}
+			current.children = append(current.children, child)
+			stack = append(stack, child)
+		case "END":
+			if len(stack) == 1 || current.name != strings.ToUpper(p.value) {
+				return nil, fmt.Errorf("unexpected END:%s", p.value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("property %s outside of a component", p.name)
+			}
+			current.properties = append(current.properties, p)
+		}
+	}
+
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("missing END:%s", stack[len(stack)-1].name)
+	}
+
+	calendars := root.childrenNamed("VCALENDAR")
+	if len(calendars) == 0 {
+		return nil, fmt.Errorf("no VCALENDAR found")
+	}
+
+	return calendars, nil
+}
+
+// unfoldIcsLines joins the lines folded with a leading space or tab.
+func unfoldIcsLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lines := []string{}
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if len(lines) > 0 {
+				lines[len(lines)-1] += line[1:]
+			}
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+func parseIcsContentLine(line string) (icsProperty, error) {
+	invalid := fmt.Errorf("invalid content line %q", line)
+
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return icsProperty{}, invalid
+	}
+	p := icsProperty{name: strings.ToUpper(line[:i]), params: map[string]string{}}
+
+	rest := line[i:]
+	for strings.HasPrefix(rest, ";") {
+		rest = rest[1:]
+		eq := strings.Index(rest, "=")
+		if eq <= 0 {
+			return icsProperty{}, invalid
+		}
+		key := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+
+		end := strings.IndexAny(rest, ";:")
+		if strings.HasPrefix(rest, `"`) {
+			end = strings.Index(rest[1:], `"`) + 2
+			if end < 2 {
+				return icsProperty{}, invalid
+			}
+		}
+		if end < 0 {
+			return icsProperty{}, invalid
+		}
+		p.params[key] = strings.Trim(rest[:end], `"`)
+		rest = rest[end:]
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return icsProperty{}, invalid
+	}
+	p.value = rest[1:]
+
+	return p, nil
+}
+
+func unescapeIcsText(value string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range value {
+		switch {
+		case escaped && (r == 'n' || r == 'N'):
+			b.WriteRune('\n')
+		case escaped:
+			b.WriteRune(r)
+		case r == '\\':
+			escaped = true
+			continue
+		default:
+			b.WriteRune(r)
+		}
+		escaped = false
+	}
+	return b.String()
+}
+
+func escapeIcsText(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return replacer.Replace(value)
+}
+
+// foldIcsLine splits a content line in lines of at most 75 octets, without
+// breaking UTF-8 characters, ending them with CRLF.
+func foldIcsLine(line string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if width+size > icsMaxLineOctets {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+
+	return b.String()
+}
+
+// icsZone converts between wall clock times, represented as floating UTC
+// times, and instants in the timezone of a TZID.
+type icsZone interface {
+	instant(wall time.Time) time.Time
+	wall(t time.Time) time.Time
+}
+
+// locationZone is a timezone of the IANA database.
+type locationZone struct {
+	loc *time.Location
+}
+
+func (z locationZone) instant(wall time.Time) time.Time {
+	return fromFloating(wall, z.loc)
+}
+
+func (z locationZone) wall(t time.Time) time.Time {
+	return floating(t.In(z.loc))
+}
+
+// icsObservance is a STANDARD or DAYLIGHT block of a VTIMEZONE.
+type icsObservance struct {
+	start      time.Time
+	offsetFrom int
+	offsetTo   int
+	name       string
+	rule       *recurrenceRule
+	rdates     []time.Time
+}
+
+// icsTimezone is a VTIMEZONE whose TZID isn't an IANA timezone, like the
+// Windows names used by Outlook (e.g. "W. Europe Standard Time").
+type icsTimezone struct {
+	id          string
+	observances []icsObservance
+}
+
+func parseIcsTimezone(c *icsComponent) (*icsTimezone, error) {
+	tz := &icsTimezone{id: c.text("TZID")}
+
+	for _, child := range c.children {
+		if child.name != "STANDARD" && child.name != "DAYLIGHT" {
+			continue
+		}
+
+		o := icsObservance{name: child.text("TZNAME")}
+		if o.name == "" {
+			o.name = tz.id
+		}
+
+		var err error
+		if o.offsetFrom, err = parseIcsOffset(child.text("TZOFFSETFROM")); err != nil {
+			return nil, err
+		}
+		if o.offsetTo, err = parseIcsOffset(child.text("TZOFFSETTO")); err != nil {
+			return nil, err
+		}
+		if o.start, err = time.Parse(icsLocalLayout, child.text("DTSTART")); err != nil {
+			return nil, fmt.Errorf("invalid DTSTART in timezone %q", tz.id)
+		}
+
+		if p, ok := child.property("RRULE"); ok {
+			if o.rule, err = parseRRule(p.value); err != nil {
+				return nil, err
+			}
+			// onsets are expressed in the local time before the transition
+			localizeUntil(o.rule, func(t time.Time) time.Time {
+				return t.UTC().Add(time.Duration(o.offsetFrom) * time.Second)
+			})
+		}
+
+		for _, p := range child.propertiesNamed("RDATE") {
+			for _, value := range strings.Split(p.value, ",") {
+				rdate, err := time.Parse(icsLocalLayout, strings.TrimSuffix(value, "Z"))
+				if err != nil {
+					return nil, fmt.Errorf("invalid RDATE in timezone %q", tz.id)
+				}
+				if strings.HasSuffix(value, "Z") {
+					rdate = rdate.Add(time.Duration(o.offsetFrom) * time.Second)
+				}
+				o.rdates = append(o.rdates, rdate)
+			}
+		}
+
+		tz.observances = append(tz.observances, o)
+	}
+
+	if len(tz.observances) == 0 {
+		return nil, fmt.Errorf("timezone %q has no STANDARD or DAYLIGHT rules", tz.id)
+	}
+
+	return tz, nil
+}
+
+// offsetAt returns the UTC offset (in seconds) and name of the observance
+// with the latest onset before the wall clock time.
+func (z *icsTimezone) offsetAt(wall time.Time) (int, string) {
+	var latest time.Time
+	var current *icsObservance
+	earliest := &z.observances[0]
+
+	for i := range z.observances {
+		o := &z.observances[i]
+		if o.start.Before(earliest.start) {
+			earliest = o
+		}
+
+		onsets := []time.Time{o.start}
+		if o.rule != nil {
+			onsets = o.rule.occurrences(o.start, wall, 0)
+		}
+		onsets = append(onsets, o.rdates...)
+		for _, onset := range onsets {
+			if !onset.After(wall) && (current == nil || onset.After(latest)) {
+				latest, current = onset, o
+			}
+		}
+	}
+
+	if current == nil {
+		return earliest.offsetFrom, earliest.name
+	}
+	return current.offsetTo, current.name
+}
+
+func (z *icsTimezone) instant(wall time.Time) time.Time {
+	offset, name := z.offsetAt(wall)
+	year, month, day := wall.Date()
+	hour, minute, second := wall.Clock()
+
+	return time.Date(year, month, day, hour, minute, second, wall.Nanosecond(), time.FixedZone(name, offset))
+}
+
+func (z *icsTimezone) wall(t time.Time) time.Time {
+	offset, _ := z.offsetAt(t.UTC())
+	offset, _ = z.offsetAt(t.UTC().Add(time.Duration(offset) * time.Second))
+
+	return t.UTC().Add(time.Duration(offset) * time.Second)
+}
+
+// parseIcsOffset parses an UTC offset like +0100 or -023045 into seconds.
+func parseIcsOffset(value string) (int, error) {
+	invalid := fmt.Errorf("invalid UTC offset %q", value)
+	if (len(value) != 5 && len(value) != 7) || (value[0] != '+' && value[0] != '-') {
+		return 0, invalid
+	}
+
+	seconds := 0
+	for i, unit := range []int{3600, 60, 1} {
+		if 1+i*2 >= len(value) {
+			break
+		}
+		v, err := strconv.Atoi(value[1+i*2 : 3+i*2])
+		if err != nil {
+			return 0, invalid
+		}
+		seconds += v * unit
+	}
+
+	if value[0] == '-' {
+		seconds = -seconds
+	}
+	return seconds, nil
+}
+
+func formatIcsOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+
+	offset := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+	if seconds%60 != 0 {
+		offset += fmt.Sprintf("%02d", seconds%60)
+	}
+	return offset
+}
+
+// localizeUntil converts an UTC UNTIL into the wall clock, so the rule can
+// be expanded on floating times.
+func localizeUntil(r *recurrenceRule, toWall func(time.Time) time.Time) {
+	if r.until == nil {
+		return
+	}
+	r.untilLocal = toWall(*r.until).Format(icsLocalLayout)
+	r.until = nil
+}
+
+// icsZones resolves the TZIDs of a calendar, preferring the IANA timezone
+// with the same name and falling back to the VTIMEZONE definition.
+type icsZones struct {
+	vtimezones map[string]*icsComponent
+	zones      map[string]icsZone
+	floating   icsZone
+}
+
+func newIcsZones(calendar *icsComponent, floatingLoc *time.Location) *icsZones {
+	z := &icsZones{
+		vtimezones: map[string]*icsComponent{},
+		zones:      map[string]icsZone{},
+		floating:   locationZone{loc: floatingLoc},
+	}
+	for _, c := range calendar.childrenNamed("VTIMEZONE") {
+		z.vtimezones[c.text("TZID")] = c
+	}
+
+	return z
+}
+
+func (z *icsZones) get(tzid string) (icsZone, error) {
+	if zone, ok := z.zones[tzid]; ok {
+		return zone, nil
+	}
+
+	var zone icsZone
+	if loc, err := time.LoadLocation(tzid); err == nil && tzid != "" && tzid != "Local" {
+		zone = locationZone{loc: loc}
+	} else if c, ok := z.vtimezones[tzid]; ok {
+		tz, err := parseIcsTimezone(c)
+		if err != nil {
+			return nil, err
+		}
+		zone = tz
+	} else {
+		return nil, fmt.Errorf("unknown timezone %q", tzid)
+	}
+
+	z.zones[tzid] = zone
+	return zone, nil
+}
+
+// icsTime is a DATE or DATE-TIME value.
+type icsTime struct {
+	wall     time.Time
+	zone     icsZone
+	dateOnly bool
+}
+
+func (t icsTime) instant() time.Time {
+	return t.zone.instant(t.wall)
+}
+
+// times parses the DATE or DATE-TIME values of a property. Floating
+// values, without TZID or Z, and dates use the floating timezone. Periods
+// (start/end) are reduced to their start.
+func (z *icsZones) times(p icsProperty) ([]icsTime, error) {
+	times := []icsTime{}
+
+	for _, value := range strings.Split(p.value, ",") {
+		value = strings.SplitN(value, "/", 2)[0]
+		invalid := fmt.Errorf("invalid %s value %q", p.name, value)
+
+		if p.params["VALUE"] == "DATE" || len(value) == len(icsDateLayout) {
+			wall, err := time.Parse(icsDateLayout, value)
+			if err != nil {
+				return nil, invalid
+			}
+			times = append(times, icsTime{wall: wall, zone: z.floating, dateOnly: true})
+			continue
+		}
+
+		if strings.HasSuffix(value, "Z") {
+			wall, err := time.Parse(icsUTCLayout, value)
+			if err != nil {
+				return nil, invalid
+			}
+			times = append(times, icsTime{wall: wall, zone: locationZone{loc: time.UTC}})
+			continue
+		}
+
+		wall, err := time.Parse(icsLocalLayout, value)
+		if err != nil {
+			return nil, invalid
+		}
+		zone := z.floating
+		if tzid, ok := p.params["TZID"]; ok {
+			if zone, err = z.get(tzid); err != nil {
+				return nil, err
+			}
+		}
+		times = append(times, icsTime{wall: wall, zone: zone})
+	}
+
+	return times, nil
+}
+
+// icsEvent is a VEVENT.
+type icsEvent struct {
+	uid          string
+	summary      string
+	description  string
+	location     string
+	start        icsTime
+	length       calendarDuration
+	rule         *recurrenceRule
+	rdates       []icsTime
+	exdates      []icsTime
+	recurrenceId *time.Time
+}
+
+// icsInstance is an occurrence of an event.
+type icsInstance struct {
+	event *icsEvent
+	start time.Time
+	end   time.Time
+}
+
+// icsMaxExpansion limits the occurrences expanded for a recurring event,
+// e.g. when searching for the ones after a given instant.
+const icsMaxExpansion = 100000
+
+func parseIcsEvent(c *icsComponent, zones *icsZones) (*icsEvent, error) {
+	e := &icsEvent{
+		uid:         c.text("UID"),
+		summary:     c.text("SUMMARY"),
+		description: c.text("DESCRIPTION"),
+		location:    c.text("LOCATION"),
+	}
+
+	p, ok := c.property("DTSTART")
+	if !ok {
+		return nil, fmt.Errorf("event %q has no DTSTART", e.uid)
+	}
+	starts, err := zones.times(p)
+	if err != nil {
+		return nil, err
+	}
+	e.start = starts[0]
+
+	if p, ok := c.property("DTEND"); ok {
+		ends, err := zones.times(p)
+		if err != nil {
+			return nil, err
+		}
+		if e.start.dateOnly || ends[0].zone == e.start.zone {
+			// the same wall clock duration, across daylight saving changes
+			length := ends[0].wall.Sub(e.start.wall)
+			e.length.Days = int(length / (24 * time.Hour))
+			e.length.Clock = length % (24 * time.Hour)
+		} else {
+			e.length.Clock = ends[0].instant().Sub(e.start.instant())
+		}
+	} else if p, ok := c.property("DURATION"); ok {
+		if e.length, err = parseDuration(p.value); err != nil {
+			return nil, err
+		}
+	} else if e.start.dateOnly {
+		e.length.Days = 1
+	}
+
+	if p, ok := c.property("RRULE"); ok {
+		if e.rule, err = parseRRule(p.value); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range c.propertiesNamed("RDATE") {
+		rdates, err := zones.times(p)
+		if err != nil {
+			return nil, err
+		}
+		e.rdates = append(e.rdates, rdates...)
+	}
+
+	for _, p := range c.propertiesNamed("EXDATE") {
+		exdates, err := zones.times(p)
+		if err != nil {
+			return nil, err
+		}
+		e.exdates = append(e.exdates, exdates...)
+	}
+
+	if p, ok := c.property("RECURRENCE-ID"); ok {
+		ids, err := zones.times(p)
+		if err != nil {
+			return nil, err
+		}
+		id := ids[0].instant()
+		e.recurrenceId = &id
+	}
+
+	return e, nil
+}
+
+// instances expands the event into the occurrences overlapping from and
+// to (when not zero), up to limit, skipping the overridden ones (moved or
+// changed occurrences, defined in other VEVENTs with a RECURRENCE-ID).
+func (e *icsEvent) instances(from, to time.Time, limit int, overridden map[int64]bool) []icsInstance {
+	zone := e.start.zone
+	starts := []time.Time{e.start.instant()}
+
+	if e.rule != nil {
+		rule := *e.rule
+		localizeUntil(&rule, zone.wall)
+
+		var wallEnd time.Time
+		if !to.IsZero() {
+			wallEnd = zone.wall(to)
+		}
+		expandLimit := limit + len(e.exdates) + len(overridden)
+		if !from.IsZero() || expandLimit < 1 || expandLimit > icsMaxExpansion {
+			expandLimit = icsMaxExpansion
+		}
+
+		starts = []time.Time{}
+		for _, wall := range rule.occurrences(e.start.wall, wallEnd, expandLimit) {
+			starts = append(starts, zone.instant(wall))
+		}
+	}
+
+	for _, rdate := range e.rdates {
+		starts = append(starts, rdate.instant())
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	// dates of EXDATE refer to the days in the timezone of the event
+	exclusions := []exclusion{}
+	for _, exdate := range e.exdates {
+		t := exdate.instant()
+		if exdate.dateOnly {
+			t = zone.instant(exdate.wall)
+		}
+		exclusions = append(exclusions, exclusion{time: t, dateOnly: exdate.dateOnly})
+	}
+	starts = excludeOccurrences(starts, exclusions)
+
+	instances := []icsInstance{}
+	for i, start := range starts {
+		if (i > 0 && start.Equal(starts[i-1])) || overridden[start.Unix()] {
+			continue
+		}
+
+		end := start.Add(e.length.Clock)
+		if e.length != (calendarDuration{Clock: e.length.Clock}) {
+			wallEnd, _ := e.length.addTo(zone.wall(start), monthEndClamp)
+			end = zone.instant(wallEnd)
+		}
+
+		if !to.IsZero() && start.After(to) {
+			break
+		}
+		if !from.IsZero() && !end.After(from) && start.Before(from) {
+			continue
+		}
+
+		instances = append(instances, icsInstance{event: e, start: start, end: end})
+		if len(instances) == limit {
+			break
+		}
+	}
+
+	return instances
+}
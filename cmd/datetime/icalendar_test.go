@@ -0,0 +1,136 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const outlookTimezone = `BEGIN:VCALENDAR
+BEGIN:VTIMEZONE
+TZID:W. Europe Standard Time
+BEGIN:STANDARD
+DTSTART:16010101T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=10
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:16010101T020000
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0200
+RRULE:FREQ=YEARLY;BYDAY=-1SU;BYMONTH=3
+END:DAYLIGHT
+END:VTIMEZONE
+END:VCALENDAR
+`
+
+func TestParseIcsFoldedLinesAndParams(t *testing.T) {
+	// arrange
+	ics := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY;LANGUAGE=en:Deploy\\, then\r\n  check\\nlogs\r\n" +
+		"ORGANIZER;CN=\"Silva; Ana\":mailto:ana@example.com\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	// act
+	calendars, err := parseIcs(strings.NewReader(ics))
+
+	// assert
+	assert.Nil(t, err)
+	event := calendars[0].childrenNamed("VEVENT")[0]
+	assert.Equal(t, "Deploy, then check\nlogs", event.text("SUMMARY"))
+	organizer, _ := event.property("ORGANIZER")
+	assert.Equal(t, "Silva; Ana", organizer.params["CN"])
+	assert.Equal(t, "mailto:ana@example.com", organizer.value)
+}
+
+func TestParseIcsInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nEND:VCALENDAR\n": "unexpected END:VCALENDAR",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\n":                "missing END:VEVENT",
+		"SUMMARY:x\n":                                    "outside of a component",
+		"BEGIN:VCALENDAR\nSUMMARY\nEND:VCALENDAR\n":      "invalid content line",
+		"BEGIN:VEVENT\nEND:VEVENT\n":                     "no VCALENDAR found",
+	}
+
+	for ics, expected := range testCases {
+		// act
+		_, err := parseIcs(strings.NewReader(ics))
+
+		// assert
+		assert.NotNil(t, err, ics)
+		assert.Contains(t, err.Error(), expected, ics)
+	}
+}
+
+func TestIcsTimezoneFromVTimezone(t *testing.T) {
+	// arrange
+	calendars, _ := parseIcs(strings.NewReader(outlookTimezone))
+	zones := newIcsZones(calendars[0], time.UTC)
+
+	// act
+	zone, err := zones.get("W. Europe Standard Time")
+
+	// assert
+	assert.Nil(t, err)
+	winter := zone.instant(time.Date(2022, time.March, 27, 1, 30, 0, 0, time.UTC))
+	summer := zone.instant(time.Date(2022, time.March, 27, 3, 30, 0, 0, time.UTC))
+	assert.Equal(t, "2022-03-27T01:30:00+01:00", winter.Format(time.RFC3339))
+	assert.Equal(t, "2022-03-27T03:30:00+02:00", summer.Format(time.RFC3339))
+	assert.Equal(t, time.Date(2022, time.July, 1, 14, 0, 0, 0, time.UTC),
+		zone.wall(time.Date(2022, time.July, 1, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestIcsOffsets(t *testing.T) {
+	// act
+	offset, err := parseIcsOffset("-023045")
+	_, invalidErr := parseIcsOffset("0100")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, -(2*3600 + 30*60 + 45), offset)
+	assert.Equal(t, "-023045", formatIcsOffset(offset))
+	assert.Equal(t, "+0530", formatIcsOffset(5*3600+30*60))
+	assert.NotNil(t, invalidErr)
+}
+
+func TestFoldIcsLine(t *testing.T) {
+	// act
+	folded := foldIcsLine("SUMMARY:" + strings.Repeat("é", 40))
+
+	// assert
+	lines := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, 74, len(lines[0]))
+	assert.Equal(t, " "+strings.Repeat("é", 7), lines[1])
+}
+
+func TestZoneTransitions(t *testing.T) {
+	// arrange
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+
+	// act
+	transitions := zoneTransitions(lisbon, 2022)
+
+	// assert
+	assert.Len(t, transitions, 2)
+	assert.Equal(t, time.Date(2022, time.March, 27, 1, 0, 0, 0, time.UTC), transitions[0].UTC())
+	assert.Equal(t, time.Date(2022, time.October, 30, 1, 0, 0, 0, time.UTC), transitions[1].UTC())
+	assert.Len(t, zoneTransitions(time.UTC, 2022), 0)
+}
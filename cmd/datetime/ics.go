@@ -0,0 +1,470 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/google/uuid"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type icsEventOutput struct {
+	UID                string
+	Summary            string
+	Description        string `json:",omitempty"`
+	Location           string `json:",omitempty"`
+	Start              string
+	End                string
+	StartUnixTimestamp int64
+	EndUnixTimestamp   int64
+	AllDay             bool `json:",omitempty"`
+	Recurring          bool `json:",omitempty"`
+}
+
+type icsParseOutput struct {
+	Calendars []string `json:",omitempty"`
+	Events    []icsEventOutput
+}
+
+const flagSummary = "summary"
+const flagDescription = "description"
+const flagLocation = "location"
+const flagAllDay = "all-day"
+const flagUid = "uid"
+
+var icalWeekdayNames = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+func NewIcsCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newIcsCmd(iostreams, systemClock{})
+}
+
+func newIcsCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var icsCmd = &cobra.Command{
+		Use:   "ics",
+		Short: "Parses and generates iCalendar (.ics) files",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fmt.Errorf("must specify a subcommand")
+		},
+	}
+
+	icsCmd.AddCommand(newIcsParseCmd(iostreams, clock))
+	icsCmd.AddCommand(newIcsNewCmd(iostreams, clock))
+
+	return icsCmd
+}
+
+func newIcsParseCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var parseCmd = &cobra.Command{
+		Use:   "parse [file]",
+		Short: "Lists the events of an iCalendar file",
+		Long: heredoc.Doc(`
+			Lists the events of an iCalendar file, read from the file or from
+			the standard input when the file is missing or "-".
+
+			Recurring events (RRULE, RDATE and EXDATE) are expanded into their
+			occurrences, and occurrences moved or changed in another event
+			(RECURRENCE-ID) are replaced. The events are sorted by start.
+
+			Timezones (TZID) are resolved with the IANA database, falling back
+			to the VTIMEZONE definitions of the file (e.g. for the Windows
+			timezone names used by Outlook). Floating times, without timezone,
+			are interpreted in the --tz timezone.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime ics parse oncall.ics --from 2022-03-01 --to 2022-04-01
+			curl -s https://example.com/team.ics | canivete datetime ics parse --from now -n 1
+		`),
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, _ := cmd.Flags().GetString(flagFrom)
+			to, _ := cmd.Flags().GetString(flagTo)
+			tz, _ := cmd.Flags().GetString(flagTimezone)
+			limit, _ := cmd.Flags().GetInt(flagLimit)
+
+			if limit < 1 {
+				return fmt.Errorf("the limit must be greater than zero")
+			}
+
+			loc, err := loadLocation(tz)
+			if err != nil {
+				return err
+			}
+
+			now := clock.Now()
+			var fromTime, toTime time.Time
+			if from != "" {
+				if fromTime, err = parseInstant(from, loc, now); err != nil {
+					return err
+				}
+			}
+			if to != "" {
+				if toTime, err = parseInstant(to, loc, now); err != nil {
+					return err
+				}
+			}
+
+			var reader io.Reader = iostreams.In
+			if len(args) == 1 && args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				reader = file
+			}
+
+			output, err := runIcsParse(reader, loc, fromTime, toTime, limit, tz != "")
+			if err != nil {
+				return err
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	parseCmd.Flags().StringP(flagFrom, "f", "", "ignore the events ending before this instant")
+	parseCmd.Flags().StringP(flagTo, "t", "", "ignore the events starting after this instant")
+	parseCmd.Flags().String(flagTimezone, "", "the IANA timezone of the output and of floating times (default UTC for floating times)")
+	parseCmd.Flags().IntP(flagLimit, "n", 100, "the maximum number of occurrences of each recurring event")
+
+	return parseCmd
+}
+
+func runIcsParse(reader io.Reader, loc *time.Location, from, to time.Time, limit int, convert bool) (icsParseOutput, error) {
+	calendars, err := parseIcs(reader)
+	if err != nil {
+		return icsParseOutput{}, err
+	}
+
+	output := icsParseOutput{Events: []icsEventOutput{}}
+	instances := []icsInstance{}
+
+	for _, calendar := range calendars {
+		if name := calendar.text("X-WR-CALNAME"); name != "" {
+			output.Calendars = append(output.Calendars, name)
+		}
+
+		zones := newIcsZones(calendar, loc)
+		events := []*icsEvent{}
+		overridden := map[string]map[int64]bool{}
+
+		for _, c := range calendar.childrenNamed("VEVENT") {
+			e, err := parseIcsEvent(c, zones)
+			if err != nil {
+				return icsParseOutput{}, err
+			}
+			if e.recurrenceId != nil {
+				if overridden[e.uid] == nil {
+					overridden[e.uid] = map[int64]bool{}
+				}
+				overridden[e.uid][e.recurrenceId.Unix()] = true
+			}
+			events = append(events, e)
+		}
+
+		for _, e := range events {
+			skip := overridden[e.uid]
+			if e.recurrenceId != nil {
+				skip = nil
+			}
+			instances = append(instances, e.instances(from, to, limit, skip)...)
+		}
+	}
+
+	sort.SliceStable(instances, func(i, j int) bool {
+		return instances[i].start.Before(instances[j].start)
+	})
+
+	for _, instance := range instances {
+		output.Events = append(output.Events, toIcsEventOutput(instance, loc, convert))
+	}
+
+	return output, nil
+}
+
+func toIcsEventOutput(instance icsInstance, loc *time.Location, convert bool) icsEventOutput {
+	e := instance.event
+	start, end := instance.start, instance.end
+	if convert {
+		start, end = start.In(loc), end.In(loc)
+	}
+
+	layout := time.RFC3339
+	if e.start.dateOnly {
+		layout = dateLayout
+	}
+
+	return icsEventOutput{
+		UID:                e.uid,
+		Summary:            e.summary,
+		Description:        e.description,
+		Location:           e.location,
+		Start:              start.Format(layout),
+		End:                end.Format(layout),
+		StartUnixTimestamp: start.Unix(),
+		EndUnixTimestamp:   end.Unix(),
+		AllDay:             e.start.dateOnly,
+		Recurring:          e.rule != nil || len(e.rdates) > 0 || e.recurrenceId != nil,
+	}
+}
+
+// icsNewEvent has the properties of a generated event.
+type icsNewEvent struct {
+	uid         string
+	summary     string
+	description string
+	location    string
+	rule        string
+	start       time.Time
+	end         time.Time
+	allDay      bool
+}
+
+func newIcsNewCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var newCmd = &cobra.Command{
+		Use:   "new",
+		Short: "Generates an iCalendar file with one event",
+		Long: heredoc.Doc(`
+			Generates an iCalendar file with one event, which can be imported
+			into calendar applications.
+
+			The end is given with --end or with --duration (one hour by
+			default, one day for all-day events). Events in a timezone other
+			than UTC include its VTIMEZONE definition.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime ics new --summary "On-call: Ana" -s 2022-03-21T09:00 -d P7D --tz Europe/Lisbon > oncall.ics
+			canivete datetime ics new --summary "Standup" -s "2022-03-21 09:30" -d 15m -r "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"
+			canivete datetime ics new --summary "Carnival" -s 2022-03-01 --all-day -l Lisbon
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			start, _ := cmd.Flags().GetString(flagStart)
+			end, _ := cmd.Flags().GetString(flagEnd)
+			duration, _ := cmd.Flags().GetString(flagDuration)
+			tz, _ := cmd.Flags().GetString(flagTimezone)
+
+			e := icsNewEvent{}
+			e.summary, _ = cmd.Flags().GetString(flagSummary)
+			e.description, _ = cmd.Flags().GetString(flagDescription)
+			e.location, _ = cmd.Flags().GetString(flagLocation)
+			e.rule, _ = cmd.Flags().GetString(flagRule)
+			e.uid, _ = cmd.Flags().GetString(flagUid)
+			e.allDay, _ = cmd.Flags().GetBool(flagAllDay)
+
+			if end != "" && duration != "" {
+				return fmt.Errorf("--%s and --%s can't be used together", flagEnd, flagDuration)
+			}
+
+			loc, err := loadLocation(tz)
+			if err != nil {
+				return err
+			}
+
+			now := clock.Now()
+			if e.start, err = parseInstant(start, loc, now); err != nil {
+				return err
+			}
+			if e.allDay {
+				e.start = time.Date(e.start.Year(), e.start.Month(), e.start.Day(), 0, 0, 0, 0, loc)
+			}
+
+			if end != "" {
+				if e.end, err = parseInstant(end, loc, now); err != nil {
+					return err
+				}
+			} else if e.end, err = icsDefaultEnd(e.start, duration, e.allDay); err != nil {
+				return err
+			}
+
+			if e.uid == "" {
+				e.uid = uuid.New().String() + "@canivete"
+			}
+
+			ics, err := runIcsNew(e, now)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprint(iostreams.Out, ics)
+			return nil
+		},
+	}
+
+	newCmd.Flags().String(flagSummary, "", "the title of the event")
+	newCmd.MarkFlagRequired(flagSummary)
+
+	newCmd.Flags().StringP(flagStart, "s", "now", "the start of the event")
+	newCmd.Flags().StringP(flagEnd, "e", "", "the end of the event (exclusive for all-day events)")
+	newCmd.Flags().StringP(flagDuration, "d", "", "the ISO 8601 or Go duration of the event (default PT1H or P1D)")
+	newCmd.Flags().String(flagTimezone, "UTC", "the IANA timezone of the event (e.g. Europe/Lisbon)")
+	newCmd.Flags().Bool(flagAllDay, false, "the event lasts whole days")
+	newCmd.Flags().StringP(flagRule, "r", "", "the recurrence rule (e.g. FREQ=WEEKLY;BYDAY=MO)")
+	newCmd.Flags().StringP(flagLocation, "l", "", "the location of the event")
+	newCmd.Flags().String(flagDescription, "", "the description of the event")
+	newCmd.Flags().String(flagUid, "", "the unique identifier of the event (default a random UUID)")
+
+	return newCmd
+}
+
+// icsDefaultEnd applies the duration to the start, which must be in whole
+// days for all-day events.
+func icsDefaultEnd(start time.Time, duration string, allDay bool) (time.Time, error) {
+	if duration == "" {
+		duration = "PT1H"
+		if allDay {
+			duration = "P1D"
+		}
+	}
+
+	d, err := parseDuration(duration)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if allDay && d.Clock != 0 {
+		return time.Time{}, fmt.Errorf("all-day events must last whole days, got %q", duration)
+	}
+
+	return d.addTo(start, monthEndClamp)
+}
+
+func runIcsNew(e icsNewEvent, now time.Time) (string, error) {
+	if !e.end.After(e.start) {
+		return "", fmt.Errorf("the end of the event must be after its start")
+	}
+	if e.rule != "" {
+		if _, err := parseRRule(e.rule); err != nil {
+			return "", err
+		}
+	}
+
+	loc := e.start.Location()
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//canivete//datetime ics//EN",
+		"CALSCALE:GREGORIAN",
+	}
+	if !e.allDay && loc != time.UTC {
+		lines = append(lines, icsTimezoneLines(loc, e.start.Year())...)
+	}
+
+	lines = append(lines,
+		"BEGIN:VEVENT",
+		"UID:"+e.uid,
+		"DTSTAMP:"+now.UTC().Format(icsUTCLayout),
+		"DTSTART"+formatIcsTime(e.start, e.allDay),
+		"DTEND"+formatIcsTime(e.end.In(loc), e.allDay),
+		"SUMMARY:"+escapeIcsText(e.summary))
+	if e.location != "" {
+		lines = append(lines, "LOCATION:"+escapeIcsText(e.location))
+	}
+	if e.description != "" {
+		lines = append(lines, "DESCRIPTION:"+escapeIcsText(e.description))
+	}
+	if e.rule != "" {
+		lines = append(lines, "RRULE:"+strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(e.rule)), "RRULE:"))
+	}
+	lines = append(lines, "END:VEVENT", "END:VCALENDAR")
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(foldIcsLine(line))
+	}
+
+	return b.String(), nil
+}
+
+// formatIcsTime formats the parameters and value of a DTSTART or DTEND.
+func formatIcsTime(t time.Time, allDay bool) string {
+	switch {
+	case allDay:
+		return ";VALUE=DATE:" + t.Format(icsDateLayout)
+	case t.Location() == time.UTC:
+		return ":" + t.Format(icsUTCLayout)
+	default:
+		return ";TZID=" + t.Location().String() + ":" + t.Format(icsLocalLayout)
+	}
+}
+
+// icsTimezoneLines builds the VTIMEZONE of loc from its transitions in the
+// year, with a yearly RRULE when the next year follows the same rule.
+func icsTimezoneLines(loc *time.Location, year int) []string {
+	lines := []string{"BEGIN:VTIMEZONE", "TZID:" + loc.String()}
+
+	transitions := zoneTransitions(loc, year)
+	// the transitions of the next year must happen at the same local time
+	nextRules := map[string]bool{}
+	for _, t := range zoneTransitions(loc, year+1) {
+		nextRules[icsTransitionRule(t)+t.Add(-time.Second).Format("150405")] = true
+	}
+
+	if len(transitions) == 0 {
+		name, offset := time.Date(year, time.January, 1, 0, 0, 0, 0, loc).Zone()
+		lines = append(lines,
+			"BEGIN:STANDARD",
+			"DTSTART:19700101T000000",
+			"TZOFFSETFROM:"+formatIcsOffset(offset),
+			"TZOFFSETTO:"+formatIcsOffset(offset),
+			"TZNAME:"+name,
+			"END:STANDARD")
+	}
+
+	for _, t := range transitions {
+		_, offsetFrom := t.Add(-time.Second).Zone()
+		name, offsetTo := t.Zone()
+
+		kind := "STANDARD"
+		if t.IsDST() {
+			kind = "DAYLIGHT"
+		}
+
+		lines = append(lines,
+			"BEGIN:"+kind,
+			"DTSTART:"+t.In(time.FixedZone("", offsetFrom)).Format(icsLocalLayout),
+			"TZOFFSETFROM:"+formatIcsOffset(offsetFrom),
+			"TZOFFSETTO:"+formatIcsOffset(offsetTo),
+			"TZNAME:"+name)
+		if rule := icsTransitionRule(t); nextRules[rule+t.Add(-time.Second).Format("150405")] {
+			lines = append(lines, "RRULE:"+rule)
+		}
+		lines = append(lines, "END:"+kind)
+	}
+
+	return append(lines, "END:VTIMEZONE")
+}
+
+// icsTransitionRule returns the yearly rule of a transition, e.g. the last
+// Sunday of March.
+func icsTransitionRule(t time.Time) string {
+	_, offsetFrom := t.Add(-time.Second).Zone()
+	local := t.In(time.FixedZone("", offsetFrom))
+
+	nth := (local.Day()-1)/7 + 1
+	if local.Day()+7 > daysIn(local.Year(), local.Month()) {
+		nth = -1
+	}
+
+	return fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", local.Month(), nth, icalWeekdayNames[local.Weekday()])
+}
@@ -0,0 +1,219 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+const onCallSchedule = `BEGIN:VCALENDAR
+VERSION:2.0
+X-WR-CALNAME:On-call
+BEGIN:VEVENT
+UID:oncall-1
+SUMMARY:On-call: Ana
+DTSTART;TZID=Europe/Lisbon:20220321T090000
+DTEND;TZID=Europe/Lisbon:20220328T090000
+RRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=4
+EXDATE;TZID=Europe/Lisbon:20220418T090000
+END:VEVENT
+BEGIN:VEVENT
+UID:oncall-1
+SUMMARY:On-call: Rui (swap)
+RECURRENCE-ID;TZID=Europe/Lisbon:20220404T090000
+DTSTART;TZID=Europe/Lisbon:20220404T090000
+DTEND;TZID=Europe/Lisbon:20220411T090000
+END:VEVENT
+BEGIN:VEVENT
+UID:freeze
+SUMMARY:Release freeze
+DTSTART;VALUE=DATE:20220401
+DTEND;VALUE=DATE:20220403
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestIcsParseCmd(t *testing.T) {
+	// arrange
+	iostreams, in, out, _ := iostreams.Test()
+	in.WriteString(onCallSchedule)
+	cmd := NewIcsCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"parse"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), `"On-call"`)
+	assert.Contains(t, out.String(), `"Start": "2022-03-21T09:00:00Z"`)
+	assert.Contains(t, out.String(), `"End": "2022-03-28T09:00:00+01:00"`)
+	assert.Contains(t, out.String(), `"Start": "2022-04-01"`)
+	assert.Contains(t, out.String(), `"End": "2022-04-03"`)
+	assert.Contains(t, out.String(), "On-call: Rui (swap)")
+	assert.Contains(t, out.String(), `"Start": "2022-05-02T09:00:00+01:00"`)
+	assert.NotContains(t, out.String(), "2022-04-18")
+	assert.Equal(t, 4, strings.Count(out.String(), `"UID"`))
+}
+
+func TestRunIcsParseWindow(t *testing.T) {
+	// arrange
+	from := time.Date(2022, time.April, 5, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, time.April, 30, 0, 0, 0, 0, time.UTC)
+
+	// act
+	output, err := runIcsParse(strings.NewReader(onCallSchedule), time.UTC, from, to, 100, true)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Len(t, output.Events, 1)
+	assert.Equal(t, "On-call: Rui (swap)", output.Events[0].Summary)
+	assert.Equal(t, "2022-04-04T08:00:00Z", output.Events[0].Start)
+}
+
+func TestIcsParseCmdUnknownTimezone(t *testing.T) {
+	// arrange
+	iostreams, in, _, _ := iostreams.Test()
+	in.WriteString("BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;TZID=Mars/Olympus:20220101T100000\nEND:VEVENT\nEND:VCALENDAR\n")
+	cmd := NewIcsCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"parse", "-"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), `unknown timezone "Mars/Olympus"`)
+}
+
+func TestIcsNewCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewIcsCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{
+		"new",
+		"--summary=On-call: Ana, Rui",
+		"-s=2022-03-21 09:00",
+		"-d=P7D",
+		"--tz=Europe/Lisbon",
+		"-r=FREQ=WEEKLY;INTERVAL=2",
+		"--uid=oncall@example.com",
+	})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n")
+	assert.Contains(t, out.String(), "TZID:Europe/Lisbon\r\n")
+	assert.Contains(t, out.String(), "RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU\r\n")
+	assert.Contains(t, out.String(), "DTSTART;TZID=Europe/Lisbon:20220321T090000\r\n")
+	assert.Contains(t, out.String(), "DTEND;TZID=Europe/Lisbon:20220328T090000\r\n")
+	assert.Contains(t, out.String(), "SUMMARY:On-call: Ana\\, Rui\r\n")
+	assert.Contains(t, out.String(), "RRULE:FREQ=WEEKLY;INTERVAL=2\r\n")
+}
+
+func TestIcsNewRoundTrip(t *testing.T) {
+	// arrange
+	start := time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC)
+	event := icsNewEvent{
+		uid:      "carnival@example.com",
+		summary:  "Carnival",
+		location: "Lisbon",
+		start:    start,
+		end:      start.AddDate(0, 0, 1),
+		allDay:   true,
+	}
+
+	// act
+	ics, err := runIcsNew(event, start)
+	output, parseErr := runIcsParse(strings.NewReader(ics), time.UTC, time.Time{}, time.Time{}, 10, false)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Nil(t, parseErr)
+	assert.Contains(t, ics, "DTSTART;VALUE=DATE:20220301\r\n")
+	assert.Equal(t, []icsEventOutput{{
+		UID:                "carnival@example.com",
+		Summary:            "Carnival",
+		Location:           "Lisbon",
+		Start:              "2022-03-01",
+		End:                "2022-03-02",
+		StartUnixTimestamp: 1646092800,
+		EndUnixTimestamp:   1646179200,
+		AllDay:             true,
+	}}, output.Events)
+}
+
+func TestIcsNewCmdInvalid(t *testing.T) {
+	testCases := map[string][]string{
+		"can't be used together": {"new", "--summary=x", "-e=2022-01-02", "-d=P1D"},
+		"whole days":             {"new", "--summary=x", "--all-day", "-d=PT1H"},
+		"must be after":          {"new", "--summary=x", "-s=2022-01-02", "-e=2022-01-01"},
+		"FREQ is required":       {"new", "--summary=x", "-r=BYDAY=MO"},
+	}
+
+	for expected, args := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewIcsCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(args)
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.NotNil(t, err, expected)
+		assert.Contains(t, err.Error(), expected)
+	}
+}
+
+func TestIcsParseCmdInvalidLimit(t *testing.T) {
+	// arrange
+	iostreams, in, _, _ := iostreams.Test()
+	in.WriteString(onCallSchedule)
+	cmd := NewIcsCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"parse", "-n=0"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	assert.EqualError(t, err, "the limit must be greater than zero")
+}
+
+func TestIcsEventInstancesWithoutLimit(t *testing.T) {
+	// arrange
+	calendar := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:daily\nDTSTART:20220321T090000Z\nRRULE:FREQ=DAILY\nEND:VEVENT\nEND:VCALENDAR\n"
+
+	// act
+	output, err := runIcsParse(strings.NewReader(calendar), time.UTC, time.Time{}, time.Time{}, 0, true)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Len(t, output.Events, icsMaxExpansion)
+}
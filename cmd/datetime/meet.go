@@ -0,0 +1,466 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/calendar"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type meetZoneOutput struct {
+	Timezone       string
+	Schedule       string
+	UTCOffset      string
+	DaylightSaving bool
+}
+
+type meetIntervalOutput struct {
+	Start    string
+	End      string
+	Duration string
+}
+
+type meetSlotOutput struct {
+	Start     string
+	End       string
+	Available int
+	Score     int
+	Local     map[string]string
+}
+
+type meetCellOutput struct {
+	Timezone string
+	Time     string
+	Status   string
+
+	local time.Time
+}
+
+type meetHourOutput struct {
+	Start   string
+	Overlap bool
+	Zones   []meetCellOutput
+
+	start time.Time
+}
+
+type meetOutput struct {
+	Date     string
+	Duration string
+	Zones    []meetZoneOutput
+	Overlap  []meetIntervalOutput
+	Slots    []meetSlotOutput
+	Hours    []meetHourOutput
+
+	date time.Time
+}
+
+const flagStep = "step"
+const flagJson = "json"
+
+// status of a zone during an hour of the grid or a slot
+const (
+	meetWorking = "working"
+	meetPartial = "partial"
+	meetAwake   = "awake"
+	meetNight   = "night"
+)
+
+var meetStatusMarks = map[string]string{
+	meetWorking: " *",
+	meetPartial: " ~",
+	meetAwake:   "",
+	meetNight:   " .",
+}
+
+// meetAwakeStart and meetAwakeEnd bound the local hours outside working
+// hours still acceptable for a meeting.
+const meetAwakeStart = 7 * time.Hour
+const meetAwakeEnd = 22 * time.Hour
+
+// meetZone is a participant's timezone with its working hours.
+type meetZone struct {
+	name     string
+	spec     string
+	loc      *time.Location
+	schedule calendar.Schedule
+	periods  []calendar.WorkingPeriod
+}
+
+func NewMeetCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newMeetCmd(iostreams, systemClock{})
+}
+
+func newMeetCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var meetCmd = &cobra.Command{
+		Use:   "meet",
+		Short: "Finds meeting slots across timezones",
+		Long: heredoc.Doc(`
+			Finds meeting slots across timezones.
+
+			The day is given in the first timezone. The working hours of each
+			timezone use the --schedule (see the sla command) or their own,
+			after an equals sign, e.g. --tz "Asia/Kolkata=10:00-18:00". Without
+			weekdays, the hours apply from Monday to Friday.
+
+			Every timezone is converted with its own daylight saving rules, so
+			the overlap is correct on the weeks when only some have changed.
+
+			Slots are ranked by the number of timezones within working hours,
+			then by how many are awake (07:00 to 22:00 local time).
+
+			In the grid, * marks a whole hour within working hours, ~ part of
+			an hour within working hours and . the night.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime meet --tz Europe/Lisbon --tz America/New_York --tz Asia/Kolkata --date 2026-11-03
+			canivete datetime meet --tz Europe/Lisbon --tz "America/Los_Angeles=mon-fri 08:00-16:00" --duration 30m --json
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			specs, _ := cmd.Flags().GetStringArray(flagTimezone)
+			dateValue, _ := cmd.Flags().GetString(flagDate)
+			defaultSchedule, _ := cmd.Flags().GetString(flagSchedule)
+			durationValue, _ := cmd.Flags().GetString(flagDuration)
+			stepValue, _ := cmd.Flags().GetString(flagStep)
+			count, _ := cmd.Flags().GetInt(flagCount)
+			asJson, _ := cmd.Flags().GetBool(flagJson)
+
+			zones, err := parseMeetZones(specs, defaultSchedule)
+			if err != nil {
+				return err
+			}
+
+			date, err := parseInstant(dateValue, zones[0].loc, clock.Now())
+			if err != nil {
+				return err
+			}
+
+			duration, err := parseClockDuration(durationValue)
+			if err != nil {
+				return err
+			}
+			step, err := parseClockDuration(stepValue)
+			if err != nil {
+				return err
+			}
+
+			output := runMeet(zones, date, duration, step, count)
+			if asJson {
+				return iostreams.PrintOutput(output)
+			}
+
+			return printMeetTables(iostreams, zones, output)
+		},
+	}
+
+	meetCmd.Flags().StringArray(flagTimezone, []string{}, "the IANA timezone of a participant, optionally with its working hours (can be repeated)")
+	meetCmd.MarkFlagRequired(flagTimezone)
+
+	meetCmd.Flags().StringP(flagDate, "d", "now", "the day of the meeting, in the first timezone")
+	meetCmd.Flags().String(flagSchedule, calendar.DefaultSchedule, "the default weekly working hours")
+	meetCmd.Flags().String(flagDuration, "1h", "the duration of the meeting")
+	meetCmd.Flags().String(flagStep, "30m", "the interval between candidate slots")
+	meetCmd.Flags().IntP(flagCount, "n", 5, "the number of ranked slots")
+	meetCmd.Flags().Bool(flagJson, false, "output JSON instead of tables")
+
+	return meetCmd
+}
+
+// parseClockDuration parses a positive duration without calendar parts.
+func parseClockDuration(value string) (time.Duration, error) {
+	d, err := parseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d.Years != 0 || d.Months != 0 || d.Weeks != 0 || d.Days != 0 || d.Clock <= 0 {
+		return 0, fmt.Errorf("the duration must be a positive number of hours, minutes or seconds")
+	}
+	return d.Clock, nil
+}
+
+func parseMeetZones(specs []string, defaultSchedule string) ([]*meetZone, error) {
+	zones := []*meetZone{}
+
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, "=", 2)
+		zone := &meetZone{name: strings.TrimSpace(parts[0]), spec: defaultSchedule}
+		if len(parts) == 2 {
+			zone.spec = strings.TrimSpace(parts[1])
+		}
+		if zone.spec != "" && zone.spec[0] >= '0' && zone.spec[0] <= '9' {
+			zone.spec = "mon-fri " + zone.spec
+		}
+
+		var err error
+		if zone.loc, err = loadLocation(zone.name); err != nil {
+			return nil, err
+		}
+		if zone.schedule, err = calendar.ParseSchedule(zone.spec); err != nil {
+			return nil, err
+		}
+
+		zones = append(zones, zone)
+	}
+
+	if len(zones) == 0 {
+		return nil, fmt.Errorf("at least one timezone is required")
+	}
+
+	return zones, nil
+}
+
+func runMeet(zones []*meetZone, date time.Time, duration, step time.Duration, count int) meetOutput {
+	first := zones[0].loc
+	year, month, day := date.In(first).Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, first)
+	end := time.Date(year, month, day+1, 0, 0, 0, 0, first)
+
+	output := meetOutput{
+		Date:     start.Format(dateLayout),
+		Duration: duration.String(),
+		Zones:    []meetZoneOutput{},
+		Overlap:  []meetIntervalOutput{},
+		Slots:    []meetSlotOutput{},
+		Hours:    []meetHourOutput{},
+		date:     start,
+	}
+
+	// the working periods of the local days around the day of the meeting
+	var overlap []calendar.WorkingPeriod
+	for i, zone := range zones {
+		zone.periods = []calendar.WorkingPeriod{}
+		localStart := start.In(zone.loc)
+		for offset := -1; offset <= 1; offset++ {
+			localDate := time.Date(localStart.Year(), localStart.Month(), localStart.Day()+offset, 0, 0, 0, 0, zone.loc)
+			for _, p := range zone.schedule.WorkingPeriods(localDate, nil, zone.loc) {
+				if p.End.After(start) && p.Start.Before(end) {
+					zone.periods = append(zone.periods, clipPeriod(p, start, end))
+				}
+			}
+		}
+
+		if i == 0 {
+			overlap = zone.periods
+		} else {
+			overlap = intersectPeriods(overlap, zone.periods)
+		}
+
+		name, offset := start.In(zone.loc).Zone()
+		output.Zones = append(output.Zones, meetZoneOutput{
+			Timezone:       zone.name,
+			Schedule:       zone.spec,
+			UTCOffset:      formatUTCOffset(offset) + " (" + name + ")",
+			DaylightSaving: start.In(zone.loc).IsDST(),
+		})
+	}
+
+	for _, p := range overlap {
+		output.Overlap = append(output.Overlap, meetIntervalOutput{
+			Start:    p.Start.In(first).Format(time.RFC3339),
+			End:      p.End.In(first).Format(time.RFC3339),
+			Duration: p.End.Sub(p.Start).String(),
+		})
+	}
+
+	output.Slots = rankMeetSlots(zones, start, end, duration, step, count)
+
+	for t := start; t.Before(end); t = t.Add(time.Hour) {
+		hour := meetHourOutput{Start: t.Format(time.RFC3339), Overlap: true, Zones: []meetCellOutput{}, start: t}
+		for _, zone := range zones {
+			status := zone.status(t, t.Add(time.Hour))
+			hour.Overlap = hour.Overlap && status == meetWorking
+			hour.Zones = append(hour.Zones, meetCellOutput{
+				Timezone: zone.name,
+				Time:     t.In(zone.loc).Format("2006-01-02 15:04"),
+				Status:   status,
+				local:    t.In(zone.loc),
+			})
+		}
+		output.Hours = append(output.Hours, hour)
+	}
+
+	return output
+}
+
+// status classifies the interval from start to end in the zone.
+func (z *meetZone) status(start, end time.Time) string {
+	for _, p := range z.periods {
+		if !start.Before(p.Start) && !end.After(p.End) {
+			return meetWorking
+		}
+	}
+	for _, p := range z.periods {
+		if start.Before(p.End) && end.After(p.Start) {
+			return meetPartial
+		}
+	}
+
+	local := start.In(z.loc)
+	sinceMidnight := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute
+	if sinceMidnight >= meetAwakeStart && sinceMidnight+end.Sub(start) <= meetAwakeEnd {
+		return meetAwake
+	}
+	return meetNight
+}
+
+// rankMeetSlots scores the slots starting every step, giving two points for
+// each zone within working hours and one for each zone awake, and returns
+// the best ones with at least one zone within working hours.
+func rankMeetSlots(zones []*meetZone, start, end time.Time, duration, step time.Duration, count int) []meetSlotOutput {
+	slots := []meetSlotOutput{}
+
+	for t := start; !t.Add(duration).After(end); t = t.Add(step) {
+		slot := meetSlotOutput{
+			Start: t.Format(time.RFC3339),
+			End:   t.Add(duration).Format(time.RFC3339),
+			Local: map[string]string{},
+		}
+		for _, zone := range zones {
+			switch zone.status(t, t.Add(duration)) {
+			case meetWorking:
+				slot.Available++
+				slot.Score += 2
+			case meetAwake, meetPartial:
+				slot.Score++
+			}
+			slot.Local[zone.name] = t.In(zone.loc).Format("15:04") + "-" + t.Add(duration).In(zone.loc).Format("15:04")
+		}
+		if slot.Available > 0 {
+			slots = append(slots, slot)
+		}
+	}
+
+	sort.SliceStable(slots, func(i, j int) bool {
+		if slots[i].Available != slots[j].Available {
+			return slots[i].Available > slots[j].Available
+		}
+		return slots[i].Score > slots[j].Score
+	})
+
+	if len(slots) > count {
+		slots = slots[:count]
+	}
+	return slots
+}
+
+func clipPeriod(p calendar.WorkingPeriod, start, end time.Time) calendar.WorkingPeriod {
+	if p.Start.Before(start) {
+		p.Start = start
+	}
+	if p.End.After(end) {
+		p.End = end
+	}
+	return p
+}
+
+// intersectPeriods returns the intersection of two sorted lists of
+// non-overlapping periods.
+func intersectPeriods(a, b []calendar.WorkingPeriod) []calendar.WorkingPeriod {
+	result := []calendar.WorkingPeriod{}
+
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start, end := a[i].Start, a[i].End
+		if b[j].Start.After(start) {
+			start = b[j].Start
+		}
+		if b[j].End.Before(end) {
+			end = b[j].End
+		}
+		if start.Before(end) {
+			result = append(result, calendar.WorkingPeriod{Start: start, End: end})
+		}
+
+		if a[i].End.Before(b[j].End) {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	return result
+}
+
+func formatUTCOffset(seconds int) string {
+	offset := formatIcsOffset(seconds)
+	return "UTC" + offset[:3] + ":" + offset[3:5]
+}
+
+func printMeetTables(iostreams iostreams.IOStreams, zones []*meetZone, output meetOutput) error {
+	headers := []string{"UTC"}
+	for _, zone := range output.Zones {
+		headers = append(headers, zone.Timezone+" "+zone.UTCOffset)
+	}
+	headers = append(headers, "ALL")
+
+	rows := [][]string{}
+	for _, hour := range output.Hours {
+		row := []string{hour.start.UTC().Format("15:04")}
+		for _, cell := range hour.Zones {
+			row = append(row, cell.local.Format("15:04")+meetDayShift(output.date, cell.local)+meetStatusMarks[cell.Status])
+		}
+		overlap := ""
+		if hour.Overlap {
+			overlap = "yes"
+		}
+		rows = append(rows, append(row, overlap))
+	}
+	if err := iostreams.PrintTable(headers, rows); err != nil {
+		return err
+	}
+
+	if len(output.Slots) == 0 {
+		fmt.Fprintln(iostreams.Out, "\nNo slot within working hours.")
+		return nil
+	}
+
+	fmt.Fprintln(iostreams.Out)
+	headers = []string{"RANK"}
+	for _, zone := range zones {
+		headers = append(headers, zone.name)
+	}
+	headers = append(headers, "AVAILABLE")
+
+	rows = [][]string{}
+	for i, slot := range output.Slots {
+		row := []string{fmt.Sprint(i + 1)}
+		for _, zone := range zones {
+			row = append(row, slot.Local[zone.name])
+		}
+		rows = append(rows, append(row, fmt.Sprintf("%d/%d", slot.Available, len(zones))))
+	}
+
+	return iostreams.PrintTable(headers, rows)
+}
+
+// meetDayShift marks the local times on the day before or after the one
+// of date, each in its own timezone.
+func meetDayShift(date, local time.Time) string {
+	switch localDate, day := calendar.Date(local), calendar.Date(date); {
+	case localDate.After(day):
+		return " (+1)"
+	case localDate.Before(day):
+		return " (-1)"
+	}
+	return ""
+}
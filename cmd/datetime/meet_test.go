@@ -0,0 +1,154 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renato0307/canivete/pkg/calendar"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunMeetWithDifferentDaylightSavingChanges(t *testing.T) {
+	// arrange: New York is already on summer time, Lisbon isn't
+	zones, _ := parseMeetZones([]string{"Europe/Lisbon", "America/New_York"}, calendar.DefaultSchedule)
+	date := time.Date(2022, time.March, 21, 0, 0, 0, 0, time.UTC)
+
+	// act
+	output := runMeet(zones, date, time.Hour, 30*time.Minute, 3)
+
+	// assert
+	assert.Equal(t, "2022-03-21", output.Date)
+	assert.Equal(t, "UTC-04:00 (EDT)", output.Zones[1].UTCOffset)
+	assert.True(t, output.Zones[1].DaylightSaving)
+	assert.Equal(t, []meetIntervalOutput{{
+		Start:    "2022-03-21T13:00:00Z",
+		End:      "2022-03-21T17:00:00Z",
+		Duration: "4h0m0s",
+	}}, output.Overlap)
+	assert.Len(t, output.Slots, 3)
+	assert.Equal(t, "2022-03-21T13:00:00Z", output.Slots[0].Start)
+	assert.Equal(t, "09:00-10:00", output.Slots[0].Local["America/New_York"])
+	assert.Equal(t, 2, output.Slots[0].Available)
+	assert.Len(t, output.Hours, 24)
+	assert.True(t, output.Hours[13].Overlap)
+	assert.Equal(t, "2022-03-21 09:00", output.Hours[13].Zones[1].Time)
+}
+
+func TestRunMeetWithoutOverlap(t *testing.T) {
+	// arrange
+	zones, _ := parseMeetZones([]string{"Europe/Lisbon", "Asia/Kolkata=10:00-18:00", "America/Los_Angeles"}, calendar.DefaultSchedule)
+	date := time.Date(2026, time.November, 3, 0, 0, 0, 0, time.UTC)
+
+	// act
+	output := runMeet(zones, date, time.Hour, 30*time.Minute, 1)
+
+	// assert
+	assert.Len(t, output.Overlap, 0)
+	assert.Equal(t, 2, output.Slots[0].Available)
+	assert.Equal(t, "mon-fri 10:00-18:00", output.Zones[1].Schedule)
+	assert.Equal(t, meetPartial, output.Hours[4].Zones[1].Status)
+	assert.Equal(t, meetNight, output.Hours[0].Zones[0].Status)
+}
+
+func TestRunMeetOnShortDay(t *testing.T) {
+	// arrange
+	zones, _ := parseMeetZones([]string{"Europe/Lisbon"}, "mon-sun 09:00-17:00")
+	date := time.Date(2022, time.March, 27, 0, 0, 0, 0, time.UTC)
+
+	// act
+	output := runMeet(zones, date, time.Hour, time.Hour, 10)
+
+	// assert
+	assert.Len(t, output.Hours, 23)
+	assert.Len(t, output.Slots, 8)
+}
+
+func TestMeetCmdTable(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewMeetCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{
+		"--tz=Asia/Kolkata",
+		"--tz=America/New_York",
+		"-d=2022-03-21",
+		"-n=1",
+	})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	assert.Contains(t, lines[0], "Asia/Kolkata UTC+05:30 (IST)")
+	assert.Contains(t, lines[1], "14:30 (-1)")
+	assert.Contains(t, out.String(), "RANK")
+	assert.Contains(t, out.String(), "1/2")
+}
+
+func TestMeetDayShift(t *testing.T) {
+	// arrange
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	date := time.Date(2026, time.November, 3, 0, 0, 0, 0, lisbon)
+	instant := time.Date(2026, time.November, 3, 16, 0, 0, 0, lisbon)
+
+	testCases := map[string]string{
+		"Europe/Lisbon":     "",
+		"Asia/Tokyo":        " (+1)",
+		"America/New_York":  "",
+		"Pacific/Pago_Pago": "",
+	}
+
+	for name, expected := range testCases {
+		loc, _ := time.LoadLocation(name)
+
+		// act
+		shift := meetDayShift(date, instant.In(loc))
+
+		// assert
+		assert.Equal(t, expected, shift, name)
+	}
+	assert.Equal(t, " (-1)", meetDayShift(date, date.In(time.UTC).Add(-time.Hour)))
+}
+
+func TestMeetCmdInvalid(t *testing.T) {
+	testCases := map[string][]string{
+		"invalid timezone":         {"--tz=Europe/Nowhere"},
+		"invalid weekday":          {"--tz=UTC=xyz 09:00-10:00"},
+		"positive number of hours": {"--tz=UTC", "--duration=P1D"},
+	}
+
+	for expected, args := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewMeetCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(args)
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.NotNil(t, err, expected)
+		assert.Contains(t, err.Error(), expected)
+	}
+}
@@ -0,0 +1,300 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Natural language dates, in English and Portuguese (accents are
+// optional), relative to the current time:
+//
+//	now, today, tomorrow, yesterday, day after tomorrow
+//	friday, next friday, last friday, this friday
+//	next week, last month, this year (the first day of the period)
+//	first day of next week, last day of next month
+//	in 3 weeks, 2 hours ago, 90 min ago
+//
+// Dates can be followed by a time, e.g. "tomorrow 9am", "next friday at
+// 14:30" or "amanhã às 9h30". A time alone refers to today.
+
+const naturalNumber = `(\d+|an?|one|two|three|four|five|six|seven|eight|nine|ten|um|uma|dois|duas|tres|quatro|cinco|seis|sete|oito|nove|dez)`
+const naturalModifier = `(next|this|last|coming|previous|proxim[oa]|est[ea]|dest[ea]|ultim[oa])`
+
+var naturalInRegexp = regexp.MustCompile(`^(?:in|em|daqui a|dentro de) ` + naturalNumber + ` ?([a-z]+)$`)
+var naturalAgoRegexp = regexp.MustCompile(`^` + naturalNumber + ` ?([a-z]+) (?:ago|atras)$`)
+var naturalHaRegexp = regexp.MustCompile(`^ha ` + naturalNumber + ` ?([a-z]+)(?: atras)?$`)
+var naturalWeekdayRegexp = regexp.MustCompile(`^(?:` + naturalModifier + ` )?([a-z]+)(?:-feira| feira)?(?: (que vem|passad[oa]))?$`)
+var naturalPeriodRegexp = regexp.MustCompile(`^(?:(?:the|o|a) )?(?:` + naturalModifier + ` )?(week|month|year|semana|mes|ano)(?: (que vem|passad[oa]))?$`)
+var naturalBoundaryRegexp = regexp.MustCompile(`^(?:the )?(first|last|primeiro|ultimo) (?:day|dia) (?:(?:of|do|da|de) )?(.+)$`)
+var naturalTimeRegexp = regexp.MustCompile(`^(.*?) ?(?:(?:at|as|a) )?(noon|midday|midnight|meio-dia|meia-noite|\d{1,2}(?::\d{2})? ?(?:am|pm)|\d{1,2}:\d{2}|\d{1,2}h(?:\d{2})?)$`)
+var naturalClockRegexp = regexp.MustCompile(`^(\d{1,2})(?:[:h](\d{2})?)? ?(am|pm)?$`)
+
+var naturalAccents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a",
+	"é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ç", "c")
+
+var naturalNumbers = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+	"um": 1, "uma": 1, "dois": 2, "duas": 2, "tres": 3, "quatro": 4, "cinco": 5,
+	"seis": 6, "sete": 7, "oito": 8, "nove": 9, "dez": 10,
+}
+
+var naturalDays = map[string]int{
+	"today":                0,
+	"hoje":                 0,
+	"tomorrow":             1,
+	"amanha":               1,
+	"yesterday":            -1,
+	"ontem":                -1,
+	"day after tomorrow":   2,
+	"depois de amanha":     2,
+	"day before yesterday": -2,
+	"anteontem":            -2,
+}
+
+var naturalWeekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday, "domingo": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday, "segunda": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday, "terca": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "quarta": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thurs": time.Thursday, "quinta": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "sexta": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday, "sabado": time.Saturday,
+}
+
+// naturalUnits converts a number of units into a duration.
+var naturalUnits = map[string]func(n int) calendarDuration{}
+
+func init() {
+	units := map[string][]string{
+		"second": {"s", "sec", "secs", "second", "seconds", "segundo", "segundos"},
+		"minute": {"m", "min", "mins", "minute", "minutes", "minuto", "minutos"},
+		"hour":   {"h", "hr", "hrs", "hour", "hours", "hora", "horas"},
+		"day":    {"d", "day", "days", "dia", "dias"},
+		"week":   {"w", "week", "weeks", "semana", "semanas"},
+		"month":  {"month", "months", "mes", "meses"},
+		"year":   {"y", "year", "years", "ano", "anos"},
+	}
+	durations := map[string]func(n int) calendarDuration{
+		"second": func(n int) calendarDuration { return calendarDuration{Clock: time.Duration(n) * time.Second} },
+		"minute": func(n int) calendarDuration { return calendarDuration{Clock: time.Duration(n) * time.Minute} },
+		"hour":   func(n int) calendarDuration { return calendarDuration{Clock: time.Duration(n) * time.Hour} },
+		"day":    func(n int) calendarDuration { return calendarDuration{Days: n} },
+		"week":   func(n int) calendarDuration { return calendarDuration{Weeks: n} },
+		"month":  func(n int) calendarDuration { return calendarDuration{Months: n} },
+		"year":   func(n int) calendarDuration { return calendarDuration{Years: n} },
+	}
+
+	for unit, words := range units {
+		for _, word := range words {
+			naturalUnits[word] = durations[unit]
+		}
+	}
+}
+
+// parseNatural parses a natural language date relative to now, returning
+// false when the phrase isn't understood.
+func parseNatural(value string, now time.Time) (time.Time, bool) {
+	text := naturalAccents.Replace(strings.ToLower(value))
+	text = strings.Join(strings.Fields(text), " ")
+
+	if t, ok := naturalDate(text, now); ok {
+		return t, true
+	}
+
+	matches := naturalTimeRegexp.FindStringSubmatch(text)
+	if matches == nil {
+		return time.Time{}, false
+	}
+
+	hour, minute, ok := naturalClock(matches[2])
+	if !ok {
+		return time.Time{}, false
+	}
+
+	day := now
+	if matches[1] != "" {
+		if day, ok = naturalDate(matches[1], now); !ok || matches[1] == "now" || matches[1] == "agora" {
+			return time.Time{}, false
+		}
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location()), true
+}
+
+// naturalDate parses the date part of a phrase, returning midnight except
+// for now and relative offsets, which keep the current time.
+func naturalDate(text string, now time.Time) (time.Time, bool) {
+	if text == "now" || text == "agora" {
+		return now, true
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if days, ok := naturalDays[text]; ok {
+		return today.AddDate(0, 0, days), true
+	}
+
+	for _, r := range []*regexp.Regexp{naturalInRegexp, naturalAgoRegexp, naturalHaRegexp} {
+		matches := r.FindStringSubmatch(text)
+		if matches == nil {
+			continue
+		}
+
+		d, ok := naturalDuration(matches[1], matches[2])
+		if !ok {
+			return time.Time{}, false
+		}
+		if r != naturalInRegexp {
+			d = d.negate()
+		}
+
+		t, _ := d.addTo(now, monthEndClamp)
+		return t, true
+	}
+
+	if matches := naturalWeekdayRegexp.FindStringSubmatch(text); matches != nil {
+		if weekday, ok := naturalWeekdays[matches[2]]; ok {
+			return naturalWeekday(today, weekday, naturalDirection(matches[1]+matches[3])), true
+		}
+	}
+
+	if matches := naturalBoundaryRegexp.FindStringSubmatch(text); matches != nil {
+		start, end, ok := naturalPeriod(matches[2], today)
+		if !ok {
+			return time.Time{}, false
+		}
+		if matches[1] == "first" || matches[1] == "primeiro" {
+			return start, true
+		}
+		return end.AddDate(0, 0, -1), true
+	}
+
+	if start, _, ok := naturalPeriod(text, today); ok {
+		return start, true
+	}
+
+	return time.Time{}, false
+}
+
+func naturalDuration(number, unit string) (calendarDuration, bool) {
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		n = naturalNumbers[number]
+	}
+
+	toDuration, ok := naturalUnits[unit]
+	if !ok {
+		return calendarDuration{}, false
+	}
+
+	return toDuration(n), true
+}
+
+// naturalDirection returns 1 for next, -1 for last and 0 otherwise.
+func naturalDirection(modifier string) int {
+	switch modifier {
+	case "next", "coming", "proximo", "proxima", "que vem":
+		return 1
+	case "last", "previous", "ultimo", "ultima", "passado", "passada":
+		return -1
+	}
+	return 0
+}
+
+// naturalWeekday returns the weekday on or after today, or strictly after
+// (next) or before (last) it.
+func naturalWeekday(today time.Time, weekday time.Weekday, direction int) time.Time {
+	switch direction {
+	case 1:
+		days := (int(weekday)-int(today.Weekday())+6)%7 + 1
+		return today.AddDate(0, 0, days)
+	case -1:
+		days := (int(today.Weekday())-int(weekday)+6)%7 + 1
+		return today.AddDate(0, 0, -days)
+	}
+
+	return today.AddDate(0, 0, (int(weekday)-int(today.Weekday())+7)%7)
+}
+
+// naturalPeriod returns the first day of a week (starting on Monday), month
+// or year, and the first day after it.
+func naturalPeriod(text string, today time.Time) (time.Time, time.Time, bool) {
+	matches := naturalPeriodRegexp.FindStringSubmatch(text)
+	if matches == nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	n := naturalDirection(matches[1] + matches[3])
+	year, month, day := today.Date()
+	loc := today.Location()
+
+	switch matches[2] {
+	case "week", "semana":
+		monday := day - (int(today.Weekday())+6)%7 + 7*n
+		return time.Date(year, month, monday, 0, 0, 0, 0, loc), time.Date(year, month, monday+7, 0, 0, 0, 0, loc), true
+	case "month", "mes":
+		return time.Date(year, month+time.Month(n), 1, 0, 0, 0, 0, loc), time.Date(year, month+time.Month(n)+1, 1, 0, 0, 0, 0, loc), true
+	default:
+		return time.Date(year+n, time.January, 1, 0, 0, 0, 0, loc), time.Date(year+n+1, time.January, 1, 0, 0, 0, 0, loc), true
+	}
+}
+
+// naturalClock parses a time of the day like 9am, 9:30pm, 14:30, 9h30 or
+// noon.
+func naturalClock(text string) (int, int, bool) {
+	switch text {
+	case "noon", "midday", "meio-dia":
+		return 12, 0, true
+	case "midnight", "meia-noite":
+		return 0, 0, true
+	}
+
+	matches := naturalClockRegexp.FindStringSubmatch(text)
+	if matches == nil {
+		return 0, 0, false
+	}
+
+	hour, _ := strconv.Atoi(matches[1])
+	minute, _ := strconv.Atoi(matches[2])
+	if minute > 59 {
+		return 0, 0, false
+	}
+
+	switch matches[3] {
+	case "":
+		if hour > 23 {
+			return 0, 0, false
+		}
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if matches[3] == "pm" {
+			hour += 12
+		}
+	}
+
+	return hour, minute, true
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.now
+}
+
+func (c fixedClock) After(d time.Duration) <-chan time.Time {
+	after := make(chan time.Time, 1)
+	after <- c.now.Add(d)
+	return after
+}
+
+func TestParseNatural(t *testing.T) {
+	// arrange: a Wednesday
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	now := time.Date(2022, time.March, 16, 15, 45, 10, 0, lisbon)
+
+	testCases := map[string]time.Time{
+		"now":                       now,
+		"Today":                     time.Date(2022, time.March, 16, 0, 0, 0, 0, lisbon),
+		"tomorrow 9am":              time.Date(2022, time.March, 17, 9, 0, 0, 0, lisbon),
+		"yesterday at 6:30 pm":      time.Date(2022, time.March, 15, 18, 30, 0, 0, lisbon),
+		"day after tomorrow noon":   time.Date(2022, time.March, 18, 12, 0, 0, 0, lisbon),
+		"friday":                    time.Date(2022, time.March, 18, 0, 0, 0, 0, lisbon),
+		"wednesday":                 time.Date(2022, time.March, 16, 0, 0, 0, 0, lisbon),
+		"next wednesday":            time.Date(2022, time.March, 23, 0, 0, 0, 0, lisbon),
+		"next friday at 14:30":      time.Date(2022, time.March, 18, 14, 30, 0, 0, lisbon),
+		"last monday":               time.Date(2022, time.March, 14, 0, 0, 0, 0, lisbon),
+		"in 3 weeks":                time.Date(2022, time.April, 6, 15, 45, 10, 0, lisbon),
+		"in an hour":                now.Add(time.Hour),
+		"2 hours ago":               now.Add(-2 * time.Hour),
+		"90 min ago":                now.Add(-90 * time.Minute),
+		"in 1 month":                time.Date(2022, time.April, 16, 15, 45, 10, 0, lisbon),
+		"next week":                 time.Date(2022, time.March, 21, 0, 0, 0, 0, lisbon),
+		"last month":                time.Date(2022, time.February, 1, 0, 0, 0, 0, lisbon),
+		"first day of this week":    time.Date(2022, time.March, 14, 0, 0, 0, 0, lisbon),
+		"last day of next month":    time.Date(2022, time.April, 30, 0, 0, 0, 0, lisbon),
+		"last day of the year":      time.Date(2022, time.December, 31, 0, 0, 0, 0, lisbon),
+		"midnight":                  time.Date(2022, time.March, 16, 0, 0, 0, 0, lisbon),
+		"agora":                     now,
+		"amanhã às 9h30":            time.Date(2022, time.March, 17, 9, 30, 0, 0, lisbon),
+		"ontem":                     time.Date(2022, time.March, 15, 0, 0, 0, 0, lisbon),
+		"depois de amanhã":          time.Date(2022, time.March, 18, 0, 0, 0, 0, lisbon),
+		"próxima sexta-feira":       time.Date(2022, time.March, 18, 0, 0, 0, 0, lisbon),
+		"sexta passada":             time.Date(2022, time.March, 11, 0, 0, 0, 0, lisbon),
+		"sábado que vem às 10h":     time.Date(2022, time.March, 19, 10, 0, 0, 0, lisbon),
+		"daqui a 3 semanas":         time.Date(2022, time.April, 6, 15, 45, 10, 0, lisbon),
+		"há 2 horas":                now.Add(-2 * time.Hour),
+		"2 dias atrás":              time.Date(2022, time.March, 14, 15, 45, 10, 0, lisbon),
+		"último dia do mês que vem": time.Date(2022, time.April, 30, 0, 0, 0, 0, lisbon),
+		"primeiro dia do ano":       time.Date(2022, time.January, 1, 0, 0, 0, 0, lisbon),
+		"semana que vem":            time.Date(2022, time.March, 21, 0, 0, 0, 0, lisbon),
+		"meio-dia":                  time.Date(2022, time.March, 16, 12, 0, 0, 0, lisbon),
+	}
+
+	for phrase, expected := range testCases {
+		// act
+		result, ok := parseNatural(phrase, now)
+
+		// assert
+		assert.True(t, ok, phrase)
+		assert.True(t, expected.Equal(result), "%s: %v", phrase, result)
+	}
+}
+
+func TestParseNaturalKeepsWallClockAcrossDaylightSaving(t *testing.T) {
+	// arrange: daylight saving starts on March 27th in Lisbon
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	now := time.Date(2022, time.March, 16, 9, 0, 0, 0, lisbon)
+
+	// act
+	result, ok := parseNatural("in 2 weeks", now)
+
+	// assert
+	assert.True(t, ok)
+	assert.Equal(t, "2022-03-30T09:00:00+01:00", result.Format(time.RFC3339))
+}
+
+func TestParseNaturalInvalid(t *testing.T) {
+	// arrange
+	now := time.Date(2022, time.March, 16, 15, 45, 10, 0, time.UTC)
+	phrases := []string{"someday", "next fortnight", "friday 25:00", "13pm", "in 3 parsecs", "now 9am"}
+
+	for _, phrase := range phrases {
+		// act
+		_, ok := parseNatural(phrase, now)
+
+		// assert
+		assert.False(t, ok, phrase)
+	}
+}
+
+func TestParseInstantNatural(t *testing.T) {
+	// arrange
+	clock := fixedClock{now: time.Date(2022, time.March, 16, 23, 30, 0, 0, time.UTC)}
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+
+	// act
+	now, nowErr := parseInstant("now", lisbon, clock.Now())
+	tomorrow, err := parseInstant("tomorrow", lisbon, clock.Now())
+
+	// assert
+	assert.Nil(t, nowErr)
+	assert.Nil(t, err)
+	assert.Equal(t, "2022-03-16T23:30:00Z", now.Format(time.RFC3339))
+	assert.Equal(t, "2022-03-17T00:00:00Z", tomorrow.Format(time.RFC3339))
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// layouts with an explicit offset, the location is taken from the value
+var zonedLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+}
+
+// layouts without offset, the value is interpreted in the given location
+var localLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseInstant converts a user supplied value into a time.Time.
+//
+// It accepts "now", Unix timestamps in seconds, RFC 3339 timestamps, ISO
+// 8601 dates and date-times without offset and natural language dates
+// (see parseNatural), the latter two being interpreted in loc. Now and
+// natural language dates are relative to now.
+func parseInstant(value string, loc *time.Location, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if value == "" || strings.EqualFold(value, "now") {
+		return now.In(loc), nil
+	}
+
+	if unixTime, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unixTime, 0).In(loc), nil
+	}
+
+	for _, layout := range zonedLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.In(loc), nil
+		}
+	}
+
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	if t, ok := parseNatural(value, now.In(loc)); ok {
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date or time %q", value)
+}
+
+// loadLocation returns the location for an IANA timezone name, defaulting
+// to UTC when the name is empty.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", name)
+	}
+
+	return loc, nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInstant(t *testing.T) {
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+
+	testCases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{value: "1638964800", expected: time.Date(2021, 12, 8, 12, 0, 0, 0, time.UTC)},
+		{value: "2021-12-08T12:00:00Z", expected: time.Date(2021, 12, 8, 12, 0, 0, 0, time.UTC)},
+		{value: "2021-12-08T13:00:00+01:00", expected: time.Date(2021, 12, 8, 12, 0, 0, 0, time.UTC)},
+		{value: "2022-07-01 10:30", expected: time.Date(2022, 7, 1, 9, 30, 0, 0, time.UTC)},
+		{value: "2022-07-01", expected: time.Date(2022, 6, 30, 23, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		// act
+		result, err := parseInstant(tc.value, lisbon, time.Now())
+
+		// assert
+		assert.Nil(t, err, tc.value)
+		assert.True(t, tc.expected.Equal(result), "%s: %v", tc.value, result)
+		assert.Equal(t, lisbon, result.Location())
+	}
+}
+
+func TestParseInstantInvalid(t *testing.T) {
+	// act
+	_, err := parseInstant("12/08/2021", time.UTC, time.Now())
+
+	// assert
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid date or time")
+}
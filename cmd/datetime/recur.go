@@ -0,0 +1,197 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type occurrenceOutput struct {
+	Time          string
+	Weekday       string
+	UnixTimestamp int64
+}
+
+type recurOutput struct {
+	Rule        string
+	Start       string
+	Timezone    string
+	Occurrences []occurrenceOutput
+}
+
+const flagRule = "rule"
+const flagExdate = "exdate"
+const flagLimit = "limit"
+
+// exclusion is an EXDATE, matching a whole day when it has no time.
+type exclusion struct {
+	time     time.Time
+	dateOnly bool
+}
+
+func NewRecurCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newRecurCmd(iostreams, systemClock{})
+}
+
+func newRecurCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var recurCmd = &cobra.Command{
+		Use:   "recur",
+		Short: "Expands an iCalendar (RFC 5545) recurrence rule",
+		Long: heredoc.Doc(`
+			Expands an iCalendar (RFC 5545) recurrence rule into occurrences.
+
+			Supported rule parts: FREQ, INTERVAL, COUNT, UNTIL, BYMONTH,
+			BYMONTHDAY, BYDAY (with ordinals, e.g. -1FR), BYSETPOS, BYHOUR,
+			BYMINUTE, BYSECOND and WKST.
+
+			The rule is expanded on the wall clock of the timezone, so an
+			event at 10:00 stays at 10:00 across daylight saving changes. The
+			start is always the first occurrence.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime recur -r "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1" -s 2022-01-31T18:00 -n 6
+			canivete datetime recur -r "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;COUNT=8" -s "2022-03-22 10:00" --tz Europe/Lisbon
+			canivete datetime recur -r "FREQ=DAILY;UNTIL=20220110" -s 2022-01-01 --exdate 2022-01-06
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rule, _ := cmd.Flags().GetString(flagRule)
+			start, _ := cmd.Flags().GetString(flagStart)
+			tz, _ := cmd.Flags().GetString(flagTimezone)
+			exdates, _ := cmd.Flags().GetStringArray(flagExdate)
+			to, _ := cmd.Flags().GetString(flagTo)
+			limit, _ := cmd.Flags().GetInt(flagLimit)
+
+			if limit < 1 {
+				return fmt.Errorf("the limit must be greater than zero")
+			}
+
+			loc, err := loadLocation(tz)
+			if err != nil {
+				return err
+			}
+
+			now := clock.Now()
+			dtstart, err := parseInstant(start, loc, now)
+			if err != nil {
+				return err
+			}
+
+			var end time.Time
+			if to != "" {
+				if end, err = parseInstant(to, loc, now); err != nil {
+					return err
+				}
+			}
+
+			exclusions := []exclusion{}
+			for _, exdate := range exdates {
+				t, err := parseInstant(exdate, loc, now)
+				if err != nil {
+					return err
+				}
+				exclusions = append(exclusions, exclusion{time: t, dateOnly: len(exdate) == len(dateLayout)})
+			}
+
+			output, err := runRecur(rule, dtstart, end, limit, exclusions)
+			if err != nil {
+				return err
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	recurCmd.Flags().StringP(flagRule, "r", "", "the recurrence rule (e.g. FREQ=WEEKLY;BYDAY=MO,WE)")
+	recurCmd.MarkFlagRequired(flagRule)
+
+	recurCmd.Flags().StringP(flagStart, "s", "now", "the start of the recurrence (DTSTART)")
+	recurCmd.Flags().String(flagTimezone, "UTC", "the IANA timezone of the recurrence (e.g. Europe/Lisbon)")
+	recurCmd.Flags().StringArray(flagExdate, []string{}, "dates or date-times to exclude (EXDATE), can be repeated")
+	recurCmd.Flags().StringP(flagTo, "t", "", "ignore occurrences after this instant")
+	recurCmd.Flags().IntP(flagLimit, "n", 10, "the maximum number of occurrences")
+
+	return recurCmd
+}
+
+func runRecur(text string, dtstart, end time.Time, limit int, exclusions []exclusion) (recurOutput, error) {
+	rule, err := parseRRule(text)
+	if err != nil {
+		return recurOutput{}, err
+	}
+
+	output := recurOutput{
+		Rule:        text,
+		Start:       dtstart.Format(time.RFC3339),
+		Timezone:    dtstart.Location().String(),
+		Occurrences: []occurrenceOutput{},
+	}
+
+	// excluded occurrences still count for COUNT, and a date excludes all
+	// the occurrences of that day, so more are expanded until enough are left
+	// or the rule ends
+	expand := limit + len(exclusions)
+	var occurrences []time.Time
+	for {
+		expanded := rule.occurrences(dtstart, end, expand)
+		occurrences = excludeOccurrences(expanded, exclusions)
+		if len(occurrences) >= limit || len(expanded) < expand {
+			break
+		}
+		expand *= 2
+	}
+	for i, occurrence := range occurrences {
+		if i == limit {
+			break
+		}
+		output.Occurrences = append(output.Occurrences, occurrenceOutput{
+			Time:          occurrence.Format(time.RFC3339),
+			Weekday:       occurrence.Weekday().String(),
+			UnixTimestamp: occurrence.Unix(),
+		})
+	}
+
+	return output, nil
+}
+
+// excludeOccurrences removes the occurrences matching the exclusions.
+func excludeOccurrences(occurrences []time.Time, exclusions []exclusion) []time.Time {
+	result := []time.Time{}
+
+	for _, occurrence := range occurrences {
+		excluded := false
+		for _, e := range exclusions {
+			if e.time.Equal(occurrence) {
+				excluded = true
+			}
+			if e.dateOnly {
+				y1, m1, d1 := e.time.Date()
+				y2, m2, d2 := occurrence.In(e.time.Location()).Date()
+				excluded = excluded || (y1 == y2 && m1 == m2 && d1 == d2)
+			}
+		}
+		if !excluded {
+			result = append(result, occurrence)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,124 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecurCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewRecurCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{
+		"-r=FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+		"-s=2022-01-31T18:00",
+		"-n=3",
+	})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), "2022-01-31T18:00:00Z")
+	assert.Contains(t, out.String(), "2022-02-28T18:00:00Z")
+	assert.Contains(t, out.String(), "2022-03-31T18:00:00Z")
+	assert.Equal(t, 3, strings.Count(out.String(), `"Time"`))
+}
+
+func TestRecurCmdWithExdateAndCount(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewRecurCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{
+		"-r=FREQ=WEEKLY;BYDAY=TU,TH;COUNT=4",
+		"-s=2022-03-22 10:00",
+		"--tz=Europe/Lisbon",
+		"--exdate=2022-03-24",
+	})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, out.String(), "2022-03-24")
+	assert.Contains(t, out.String(), "2022-03-29T10:00:00+01:00")
+	assert.Contains(t, out.String(), "2022-03-31T10:00:00+01:00")
+	assert.Equal(t, 3, strings.Count(out.String(), `"Time"`))
+}
+
+func TestRecurCmdWithExdateOfSeveralOccurrences(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewRecurCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{
+		"-r=FREQ=HOURLY;INTERVAL=6",
+		"-s=2022-01-01",
+		"--exdate=2022-01-02",
+		"-n=5",
+	})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, out.String(), "2022-01-02")
+	assert.Contains(t, out.String(), "2022-01-03T00:00:00Z")
+	assert.Equal(t, 5, strings.Count(out.String(), `"Time"`))
+}
+
+func TestRecurCmdInvalidRule(t *testing.T) {
+	// arrange
+	iostreams, _, _, _ := iostreams.Test()
+	cmd := NewRecurCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"-r=BYDAY=MO"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "FREQ is required")
+}
+
+func TestRecurCmdInvalidLimit(t *testing.T) {
+	for _, limit := range []string{"0", "-1"} {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewRecurCmd(*iostreams)
+
+		// act
+		cmd.SetArgs([]string{"-r=FREQ=DAILY", "-s=2022-01-01", "-n=" + limit})
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.EqualError(t, err, "the limit must be greater than zero", limit)
+	}
+}
@@ -0,0 +1,513 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recurrence frequencies, from the longest to the shortest
+const (
+	freqYearly = iota
+	freqMonthly
+	freqWeekly
+	freqDaily
+	freqHourly
+	freqMinutely
+	freqSecondly
+)
+
+var frequencies = map[string]int{
+	"YEARLY":   freqYearly,
+	"MONTHLY":  freqMonthly,
+	"WEEKLY":   freqWeekly,
+	"DAILY":    freqDaily,
+	"HOURLY":   freqHourly,
+	"MINUTELY": freqMinutely,
+	"SECONDLY": freqSecondly,
+}
+
+var icalWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// recurrenceWeekday is a BYDAY item, e.g. MO, 1MO (first Monday) or -1FR
+// (last Friday). A zero nth means every such weekday.
+type recurrenceWeekday struct {
+	weekday time.Weekday
+	nth     int
+}
+
+// recurrenceRule is a RFC 5545 RRULE.
+type recurrenceRule struct {
+	text       string
+	freq       int
+	interval   int
+	count      int
+	until      *time.Time
+	untilLocal string
+	byMonth    []int
+	byMonthDay []int
+	byDay      []recurrenceWeekday
+	bySetPos   []int
+	byHour     []int
+	byMinute   []int
+	bySecond   []int
+	weekStart  time.Weekday
+}
+
+var byDayRegexp = regexp.MustCompile(`^([+-]?\d{1,2})?(SU|MO|TU|WE|TH|FR|SA)$`)
+
+// parseRRule parses a RFC 5545 recurrence rule, with or without the
+// RRULE: prefix.
+func parseRRule(text string) (*recurrenceRule, error) {
+	text = strings.TrimSpace(text)
+	value := strings.TrimPrefix(strings.ToUpper(text), "RRULE:")
+
+	r := &recurrenceRule{text: text, freq: -1, interval: 1, weekStart: time.Monday}
+
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		keyValue := strings.SplitN(part, "=", 2)
+		if len(keyValue) != 2 {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+		key, val := keyValue[0], keyValue[1]
+
+		var err error
+		switch key {
+		case "FREQ":
+			freq, ok := frequencies[val]
+			if !ok {
+				return nil, fmt.Errorf("invalid rrule frequency %q", val)
+			}
+			r.freq = freq
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(val)
+			if err == nil && r.interval < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(val)
+			if err == nil && r.count < 1 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "UNTIL":
+			err = r.parseUntil(val)
+		case "BYMONTH":
+			r.byMonth, err = parseIntList(val, 1, 12, false)
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseIntList(val, 1, 31, true)
+		case "BYSETPOS":
+			r.bySetPos, err = parseIntList(val, 1, 366, true)
+		case "BYHOUR":
+			r.byHour, err = parseIntList(val, 0, 23, false)
+		case "BYMINUTE":
+			r.byMinute, err = parseIntList(val, 0, 59, false)
+		case "BYSECOND":
+			r.bySecond, err = parseIntList(val, 0, 59, false)
+		case "BYDAY":
+			err = r.parseByDay(val)
+		case "WKST":
+			weekday, ok := icalWeekdays[val]
+			if !ok {
+				err = fmt.Errorf("invalid weekday")
+			}
+			r.weekStart = weekday
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid rrule %s=%s: %v", key, val, err)
+		}
+	}
+
+	if r.freq == -1 {
+		return nil, fmt.Errorf("invalid rrule %q: FREQ is required", text)
+	}
+	if r.count > 0 && (r.until != nil || r.untilLocal != "") {
+		return nil, fmt.Errorf("invalid rrule %q: COUNT and UNTIL can't be used together", text)
+	}
+	for _, day := range r.byDay {
+		if day.nth != 0 && r.freq != freqMonthly && r.freq != freqYearly {
+			return nil, fmt.Errorf("invalid rrule %q: BYDAY ordinals are only valid with MONTHLY or YEARLY", text)
+		}
+	}
+
+	return r, nil
+}
+
+func (r *recurrenceRule) parseUntil(value string) error {
+	if strings.HasSuffix(value, "Z") {
+		until, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return err
+		}
+		r.until = &until
+		return nil
+	}
+
+	// dates and local date-times are interpreted in the timezone of DTSTART
+	if _, err := time.Parse("20060102", value); err == nil {
+		r.untilLocal = value + "T235959"
+		return nil
+	}
+	if _, err := time.Parse("20060102T150405", value); err != nil {
+		return err
+	}
+	r.untilLocal = value
+
+	return nil
+}
+
+func (r *recurrenceRule) parseByDay(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		matches := byDayRegexp.FindStringSubmatch(item)
+		if matches == nil {
+			return fmt.Errorf("invalid weekday %q", item)
+		}
+
+		nth := 0
+		if matches[1] != "" {
+			nth, _ = strconv.Atoi(matches[1])
+			if nth == 0 || nth > 53 || nth < -53 {
+				return fmt.Errorf("invalid weekday %q", item)
+			}
+		}
+		r.byDay = append(r.byDay, recurrenceWeekday{weekday: icalWeekdays[matches[2]], nth: nth})
+	}
+
+	return nil
+}
+
+// parseIntList parses a comma separated list of integers between min and
+// max, also accepting negative values when allowNegative is set.
+func parseIntList(value string, min, max int, allowNegative bool) ([]int, error) {
+	values := []int{}
+	for _, item := range strings.Split(value, ",") {
+		v, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, err
+		}
+		abs := v
+		if allowNegative && v < 0 {
+			abs = -v
+		}
+		if abs < min || abs > max {
+			return nil, fmt.Errorf("%d is out of range", v)
+		}
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// recurrenceMaxEmptyPeriods stops rules that never match (e.g. February
+// 30), allowing leap days and long gaps between matching periods.
+var recurrenceMaxEmptyPeriods = map[int]int{
+	freqYearly:   400,
+	freqMonthly:  400 * 12,
+	freqWeekly:   400 * 53,
+	freqDaily:    8 * 366,
+	freqHourly:   8 * 366 * 24,
+	freqMinutely: 366 * 24 * 60,
+	freqSecondly: 24 * 60 * 60,
+}
+
+// occurrences expands the rule from dtstart, in dtstart's location, until
+// COUNT or UNTIL are reached, the occurrence is after end (when not zero)
+// or limit occurrences are found (when positive). The first occurrence is
+// always dtstart, as RFC 5545 requires.
+func (r *recurrenceRule) occurrences(dtstart time.Time, end time.Time, limit int) []time.Time {
+	loc := dtstart.Location()
+	wallStart := floating(dtstart)
+
+	until := r.until
+	if r.untilLocal != "" {
+		localUntil, _ := time.ParseInLocation("20060102T150405", r.untilLocal, loc)
+		until = &localUntil
+	}
+
+	if !end.IsZero() && dtstart.After(end) {
+		return []time.Time{}
+	}
+
+	results := []time.Time{dtstart}
+	emptyPeriods := 0
+
+	done := func() bool {
+		return (r.count > 0 && len(results) >= r.count) || (limit > 0 && len(results) >= limit)
+	}
+
+	for period := 0; !done() && emptyPeriods < recurrenceMaxEmptyPeriods[r.freq]; period++ {
+		candidates := r.expandPeriod(wallStart, period)
+		if len(candidates) == 0 {
+			emptyPeriods++
+			continue
+		}
+		emptyPeriods = 0
+
+		for _, candidate := range candidates {
+			if !candidate.After(wallStart) {
+				continue
+			}
+
+			instant := fromFloating(candidate, loc)
+			if (until != nil && instant.After(*until)) || (!end.IsZero() && instant.After(end)) {
+				return results
+			}
+			if instant.Equal(results[len(results)-1]) {
+				// a daylight saving gap can shift an occurrence onto the next one
+				continue
+			}
+
+			results = append(results, instant)
+			if done() {
+				break
+			}
+		}
+	}
+
+	return results
+}
+
+// expandPeriod returns the sorted wall clock candidates of the nth period
+// after the one containing dtstart, with BYSETPOS applied.
+func (r *recurrenceRule) expandPeriod(wallStart time.Time, period int) []time.Time {
+	year, month, day := wallStart.Date()
+	hour, minute, second := wallStart.Clock()
+	step := period * r.interval
+
+	var days []time.Time
+	switch r.freq {
+	case freqYearly:
+		first := time.Date(year+step, time.January, 1, 0, 0, 0, 0, time.UTC)
+		days = daysFrom(first, first.AddDate(1, 0, 0))
+	case freqMonthly:
+		first := time.Date(year, month+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+		days = daysFrom(first, first.AddDate(0, 1, 0))
+	case freqWeekly:
+		offset := (int(wallStart.Weekday()) - int(r.weekStart) + 7) % 7
+		first := time.Date(year, month, day-offset+7*step, 0, 0, 0, 0, time.UTC)
+		days = daysFrom(first, first.AddDate(0, 0, 7))
+	case freqDaily:
+		days = []time.Time{time.Date(year, month, day+step, 0, 0, 0, 0, time.UTC)}
+	default:
+		// sub-daily frequencies move the clock, the day is the one the
+		// period starts on
+		unit := map[int]time.Duration{freqHourly: time.Hour, freqMinutely: time.Minute, freqSecondly: time.Second}[r.freq]
+		periodStart := wallStart.Add(time.Duration(step) * unit)
+		y, m, d := periodStart.Date()
+		days = []time.Time{time.Date(y, m, d, 0, 0, 0, 0, time.UTC)}
+		hour, minute, second = periodStart.Clock()
+	}
+
+	candidates := []time.Time{}
+	for _, d := range days {
+		if !r.matchesDay(d, wallStart) {
+			continue
+		}
+		for _, t := range r.timesOfDay(hour, minute, second) {
+			candidates = append(candidates, d.Add(t))
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+
+	return r.applySetPos(candidates)
+}
+
+func daysFrom(first, end time.Time) []time.Time {
+	days := []time.Time{}
+	for d := first; d.Before(end); d = d.AddDate(0, 0, 1) {
+		days = append(days, d)
+	}
+	return days
+}
+
+// matchesDay filters the days of a period with BYMONTH, BYMONTHDAY and
+// BYDAY, defaulting to the day (and month) of dtstart like RFC 5545 does.
+func (r *recurrenceRule) matchesDay(d time.Time, wallStart time.Time) bool {
+	byMonth := r.byMonth
+	byMonthDay := r.byMonthDay
+	byDay := r.byDay
+
+	if len(byMonthDay) == 0 && len(byDay) == 0 {
+		switch r.freq {
+		case freqYearly:
+			if len(byMonth) == 0 {
+				byMonth = []int{int(wallStart.Month())}
+			}
+			byMonthDay = []int{wallStart.Day()}
+		case freqMonthly:
+			byMonthDay = []int{wallStart.Day()}
+		case freqWeekly:
+			byDay = []recurrenceWeekday{{weekday: wallStart.Weekday()}}
+		}
+	}
+
+	if len(byMonth) > 0 && !containsInt(byMonth, int(d.Month())) {
+		return false
+	}
+
+	if len(byMonthDay) > 0 {
+		last := daysIn(d.Year(), d.Month())
+		if !containsInt(byMonthDay, d.Day()) && !containsInt(byMonthDay, d.Day()-last-1) {
+			return false
+		}
+	}
+
+	if len(byDay) > 0 {
+		// ordinals are relative to the month, unless the rule is yearly
+		// without BYMONTH where they are relative to the year
+		inYear := r.freq == freqYearly && len(r.byMonth) == 0
+		matched := false
+		for _, day := range byDay {
+			if day.weekday != d.Weekday() {
+				continue
+			}
+			if day.nth == 0 || day.nth == nthInPeriod(d, inYear, day.nth < 0) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nthInPeriod returns the position of a weekday in its month or year,
+// counting from the end (as a negative number) when fromEnd is set.
+func nthInPeriod(d time.Time, inYear bool, fromEnd bool) int {
+	position, length := d.Day(), daysIn(d.Year(), d.Month())
+	if inYear {
+		position = d.YearDay()
+		length = time.Date(d.Year(), time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	}
+
+	if fromEnd {
+		return -((length-position)/7 + 1)
+	}
+
+	return (position-1)/7 + 1
+}
+
+// timesOfDay returns the times of the day expanded with BYHOUR, BYMINUTE
+// and BYSECOND, which default to the time of the period start.
+func (r *recurrenceRule) timesOfDay(hour, minute, second int) []time.Duration {
+	hours, minutes, seconds := []int{hour}, []int{minute}, []int{second}
+
+	// expand the units larger than the frequency, filter the others
+	if len(r.byHour) > 0 {
+		if r.freq >= freqHourly && !containsInt(r.byHour, hour) {
+			return nil
+		}
+		if r.freq < freqHourly {
+			hours = r.byHour
+		}
+	}
+	if len(r.byMinute) > 0 {
+		if r.freq >= freqMinutely && !containsInt(r.byMinute, minute) {
+			return nil
+		}
+		if r.freq < freqMinutely {
+			minutes = r.byMinute
+		}
+	}
+	if len(r.bySecond) > 0 {
+		if r.freq >= freqSecondly && !containsInt(r.bySecond, second) {
+			return nil
+		}
+		if r.freq < freqSecondly {
+			seconds = r.bySecond
+		}
+	}
+
+	times := []time.Duration{}
+	for _, h := range hours {
+		for _, m := range minutes {
+			for _, s := range seconds {
+				times = append(times, time.Duration(h)*time.Hour+time.Duration(m)*time.Minute+time.Duration(s)*time.Second)
+			}
+		}
+	}
+
+	return times
+}
+
+func (r *recurrenceRule) applySetPos(candidates []time.Time) []time.Time {
+	if len(r.bySetPos) == 0 {
+		return candidates
+	}
+
+	selected := []time.Time{}
+	for i, candidate := range candidates {
+		if containsInt(r.bySetPos, i+1) || containsInt(r.bySetPos, i-len(candidates)) {
+			selected = append(selected, candidate)
+		}
+	}
+
+	return selected
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// floating returns the wall clock of t as a UTC time, so calendar
+// arithmetic isn't affected by daylight saving changes.
+func floating(t time.Time) time.Time {
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	return time.Date(year, month, day, hour, minute, second, t.Nanosecond(), time.UTC)
+}
+
+// fromFloating converts a wall clock time into an instant in loc. As RFC
+// 5545 requires, non-existent times are shifted by the length of the gap
+// and ambiguous times resolve to the first occurrence.
+func fromFloating(wall time.Time, loc *time.Location) time.Time {
+	year, month, day := wall.Date()
+	hour, minute, second := wall.Clock()
+
+	instants, shifted := resolveWallClock(year, month, day, hour, minute, second, loc)
+	if len(instants) > 0 {
+		return instants[0].Add(time.Duration(wall.Nanosecond()))
+	}
+
+	return shifted
+}
@@ -0,0 +1,147 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func expandDates(t *testing.T, rule string, dtstart time.Time, limit int) []string {
+	r, err := parseRRule(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dates := []string{}
+	for _, occurrence := range r.occurrences(dtstart, time.Time{}, limit) {
+		dates = append(dates, occurrence.Format(dateLayout))
+	}
+
+	return dates
+}
+
+// examples from RFC 5545, section 3.8.5.3
+func TestRecurrenceRuleRfcExamples(t *testing.T) {
+	testCases := []struct {
+		rule     string
+		dtstart  time.Time
+		limit    int
+		expected []string
+	}{
+		{
+			rule:     "FREQ=MONTHLY;BYDAY=-2MO;COUNT=3",
+			dtstart:  time.Date(1997, time.September, 22, 9, 0, 0, 0, time.UTC),
+			expected: []string{"1997-09-22", "1997-10-20", "1997-11-17"},
+		},
+		{
+			rule:     "FREQ=YEARLY;BYDAY=20MO",
+			dtstart:  time.Date(1997, time.May, 19, 9, 0, 0, 0, time.UTC),
+			limit:    3,
+			expected: []string{"1997-05-19", "1998-05-18", "1999-05-17"},
+		},
+		{
+			rule:     "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			dtstart:  time.Date(1998, time.February, 13, 9, 0, 0, 0, time.UTC),
+			limit:    3,
+			expected: []string{"1998-02-13", "1998-03-13", "1998-11-13"},
+		},
+		{
+			rule:     "FREQ=MONTHLY;COUNT=3;BYDAY=TU,WE,TH;BYSETPOS=3",
+			dtstart:  time.Date(1997, time.September, 4, 9, 0, 0, 0, time.UTC),
+			expected: []string{"1997-09-04", "1997-10-07", "1997-11-06"},
+		},
+		{
+			rule:     "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO",
+			dtstart:  time.Date(1997, time.August, 5, 9, 0, 0, 0, time.UTC),
+			expected: []string{"1997-08-05", "1997-08-10", "1997-08-19", "1997-08-24"},
+		},
+		{
+			rule:     "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+			dtstart:  time.Date(1997, time.August, 5, 9, 0, 0, 0, time.UTC),
+			expected: []string{"1997-08-05", "1997-08-17", "1997-08-19", "1997-08-31"},
+		},
+		{
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=-3;COUNT=3",
+			dtstart:  time.Date(1997, time.September, 28, 9, 0, 0, 0, time.UTC),
+			expected: []string{"1997-09-28", "1997-10-29", "1997-11-28"},
+		},
+		{
+			rule:     "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29",
+			dtstart:  time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC),
+			limit:    3,
+			expected: []string{"2020-02-29", "2024-02-29", "2028-02-29"},
+		},
+	}
+
+	for _, tc := range testCases {
+		// act
+		dates := expandDates(t, tc.rule, tc.dtstart, tc.limit)
+
+		// assert
+		assert.Equal(t, tc.expected, dates, tc.rule)
+	}
+}
+
+func TestRecurrenceRuleUntilInTimezone(t *testing.T) {
+	// arrange
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	r, _ := parseRRule("RRULE:FREQ=WEEKLY;UNTIL=20220405T090000Z")
+	dtstart := time.Date(2022, time.March, 22, 10, 0, 0, 0, lisbon)
+
+	// act
+	occurrences := r.occurrences(dtstart, time.Time{}, 0)
+
+	// assert: the wall clock is kept, so the last one is 10:00 WEST = 09:00 UTC
+	assert.Len(t, occurrences, 3)
+	assert.Equal(t, time.Date(2022, time.April, 5, 10, 0, 0, 0, lisbon), occurrences[2])
+}
+
+func TestRecurrenceRuleNeverMatching(t *testing.T) {
+	// arrange
+	r, _ := parseRRule("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30")
+	dtstart := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	// act
+	occurrences := r.occurrences(dtstart, time.Time{}, 10)
+
+	// assert
+	assert.Equal(t, []time.Time{dtstart}, occurrences)
+}
+
+func TestParseRRuleInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"INTERVAL=2":                        "FREQ is required",
+		"FREQ=FORTNIGHTLY":                  "invalid rrule frequency",
+		"FREQ=DAILY;COUNT=2;UNTIL=20220101": "COUNT and UNTIL",
+		"FREQ=WEEKLY;BYDAY=1MO":             "only valid with MONTHLY or YEARLY",
+		"FREQ=DAILY;BYMONTH=13":             "out of range",
+		"FREQ=DAILY;BYWEEKNO=1":             "unsupported rrule part",
+		"FREQ=DAILY;INTERVAL=0":             "must be positive",
+	}
+
+	for rule, expected := range testCases {
+		// act
+		_, err := parseRRule(rule)
+
+		// assert
+		assert.NotNil(t, err, rule)
+		assert.Contains(t, err.Error(), expected, rule)
+	}
+}
@@ -0,0 +1,312 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/calendar"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type slaIntervalOutput struct {
+	Start    string
+	End      string
+	Duration string
+}
+
+type slaDueOutput struct {
+	Start     string
+	Duration  string
+	Due       fromUnixOutput
+	Intervals []slaIntervalOutput
+}
+
+type slaElapsedOutput struct {
+	Start         string
+	End           string
+	Duration      string
+	BusinessHours float64
+	Intervals     []slaIntervalOutput
+}
+
+const flagStart = "start"
+const flagEnd = "end"
+const flagSchedule = "schedule"
+
+// slaSearchDays limits how far working periods are searched for.
+const slaSearchDays = 366 * 5
+
+func NewSlaCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newSlaCmd(iostreams, systemClock{})
+}
+
+func newSlaCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var slaCmd = &cobra.Command{
+		Use:   "sla",
+		Short: "Business hours calculations for SLAs",
+		Long: heredoc.Doc(`
+			Business hours calculations for SLAs.
+
+			The working hours are given as a weekly schedule, with groups of
+			days and their intervals separated by semicolons, e.g.:
+				mon-thu 09:00-13:00,14:00-18:00; fri 09:00-15:00
+				mon+wed+fri 08:00-12:00
+
+			Holidays of the calendar (see the workdays command) are not
+			working days, use --calendar none to ignore holidays.
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fmt.Errorf("must specify a subcommand")
+		},
+	}
+
+	slaCmd.PersistentFlags().String(
+		flagSchedule,
+		calendar.DefaultSchedule,
+		"the weekly working hours")
+
+	slaCmd.PersistentFlags().StringP(
+		flagCalendar,
+		"c",
+		"pt",
+		"the holiday calendar, none to ignore holidays")
+
+	slaCmd.PersistentFlags().String(
+		flagTimezone,
+		"UTC",
+		"the IANA timezone of the working hours (e.g. Europe/Lisbon)")
+
+	slaCmd.PersistentFlags().StringP(
+		flagStart,
+		"s",
+		"now",
+		"the start instant (e.g. when the ticket was opened)")
+
+	slaCmd.AddCommand(newSlaDueCmd(iostreams, clock))
+	slaCmd.AddCommand(newSlaElapsedCmd(iostreams, clock))
+
+	return slaCmd
+}
+
+func newSlaDueCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var dueCmd = &cobra.Command{
+		Use:   "due",
+		Short: "Calculates when a duration in business hours expires",
+		Example: heredoc.Doc(`
+			canivete datetime sla due -s "2022-12-02 17:30" -d 16h --tz Europe/Lisbon
+			canivete datetime sla due -s 2022-12-02T10:00:00Z -d PT4H --schedule "mon-fri 09:00-13:00,14:00-18:00"
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schedule, cal, loc, err := getSlaSettings(cmd)
+			if err != nil {
+				return err
+			}
+
+			now := clock.Now()
+			startValue, _ := cmd.Flags().GetString(flagStart)
+			start, err := parseInstant(startValue, loc, now)
+			if err != nil {
+				return err
+			}
+
+			durationValue, _ := cmd.Flags().GetString(flagDuration)
+			d, err := parseDuration(durationValue)
+			if err != nil {
+				return err
+			}
+			if d.Years != 0 || d.Months != 0 || d.Weeks != 0 || d.Days != 0 || d.Clock <= 0 {
+				return fmt.Errorf("the duration must be a positive number of hours, minutes or seconds")
+			}
+
+			due, periods, err := slaDue(start, d.Clock, schedule, cal)
+			if err != nil {
+				return err
+			}
+
+			output := slaDueOutput{
+				Start:     start.Format(time.RFC3339),
+				Duration:  d.Clock.String(),
+				Due:       newFromUnixOutput(due),
+				Intervals: toSlaIntervalOutputs(periods),
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	dueCmd.Flags().StringP(flagDuration, "d", "", "the duration in business hours (e.g. 16h, PT4H30M)")
+	dueCmd.MarkFlagRequired(flagDuration)
+
+	return dueCmd
+}
+
+func newSlaElapsedCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var elapsedCmd = &cobra.Command{
+		Use:   "elapsed",
+		Short: "Calculates the business hours elapsed between two instants",
+		Example: heredoc.Doc(`
+			canivete datetime sla elapsed -s "2022-12-02 17:30" -e "2022-12-06 10:00" --tz Europe/Lisbon
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			schedule, cal, loc, err := getSlaSettings(cmd)
+			if err != nil {
+				return err
+			}
+
+			now := clock.Now()
+			startValue, _ := cmd.Flags().GetString(flagStart)
+			start, err := parseInstant(startValue, loc, now)
+			if err != nil {
+				return err
+			}
+
+			endValue, _ := cmd.Flags().GetString(flagEnd)
+			end, err := parseInstant(endValue, loc, now)
+			if err != nil {
+				return err
+			}
+			if end.Before(start) {
+				return fmt.Errorf("the end must be after the start")
+			}
+
+			elapsed, periods := slaElapsed(start, end, schedule, cal)
+
+			output := slaElapsedOutput{
+				Start:         start.Format(time.RFC3339),
+				End:           end.Format(time.RFC3339),
+				Duration:      elapsed.String(),
+				BusinessHours: elapsed.Hours(),
+				Intervals:     toSlaIntervalOutputs(periods),
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	elapsedCmd.Flags().StringP(flagEnd, "e", "now", "the end instant")
+
+	return elapsedCmd
+}
+
+func getSlaSettings(cmd *cobra.Command) (calendar.Schedule, *calendar.Calendar, *time.Location, error) {
+	scheduleSpec, _ := cmd.Flags().GetString(flagSchedule)
+	calendarName, _ := cmd.Flags().GetString(flagCalendar)
+	tz, _ := cmd.Flags().GetString(flagTimezone)
+
+	schedule, err := calendar.ParseSchedule(scheduleSpec)
+	if err != nil {
+		return calendar.Schedule{}, nil, nil, err
+	}
+	if schedule.IsEmpty() {
+		return calendar.Schedule{}, nil, nil, fmt.Errorf("the schedule has no working hours")
+	}
+
+	var cal *calendar.Calendar
+	if !strings.EqualFold(calendarName, "none") {
+		if cal, err = loadCalendar(calendarName); err != nil {
+			return calendar.Schedule{}, nil, nil, err
+		}
+	}
+
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return calendar.Schedule{}, nil, nil, err
+	}
+
+	return schedule, cal, loc, nil
+}
+
+// slaDue returns the instant when the business duration expires and the
+// working periods consumed until then.
+func slaDue(start time.Time, duration time.Duration, schedule calendar.Schedule, cal *calendar.Calendar) (time.Time, []calendar.WorkingPeriod, error) {
+	remaining := duration
+	consumed := []calendar.WorkingPeriod{}
+	year, month, day := start.Date()
+
+	for i := 0; i < slaSearchDays; i++ {
+		date := time.Date(year, month, day+i, 0, 0, 0, 0, start.Location())
+		for _, period := range schedule.WorkingPeriods(date, cal, start.Location()) {
+			if period.Start.Before(start) {
+				period.Start = start
+			}
+			available := period.End.Sub(period.Start)
+			if available <= 0 {
+				continue
+			}
+
+			if remaining <= available {
+				period.End = period.Start.Add(remaining)
+				return period.End, append(consumed, period), nil
+			}
+
+			remaining -= available
+			consumed = append(consumed, period)
+		}
+	}
+
+	return time.Time{}, nil, fmt.Errorf("no working hours found in the next %d days", slaSearchDays)
+}
+
+// slaElapsed returns the business time between two instants and the
+// working periods it was made of.
+func slaElapsed(start, end time.Time, schedule calendar.Schedule, cal *calendar.Calendar) (time.Duration, []calendar.WorkingPeriod) {
+	elapsed := time.Duration(0)
+	periods := []calendar.WorkingPeriod{}
+	year, month, day := start.Date()
+
+	for i := 0; ; i++ {
+		date := time.Date(year, month, day+i, 0, 0, 0, 0, start.Location())
+		if date.After(end) {
+			break
+		}
+
+		for _, period := range schedule.WorkingPeriods(date, cal, start.Location()) {
+			if period.Start.Before(start) {
+				period.Start = start
+			}
+			if period.End.After(end) {
+				period.End = end
+			}
+			if !period.End.After(period.Start) {
+				continue
+			}
+
+			elapsed += period.End.Sub(period.Start)
+			periods = append(periods, period)
+		}
+	}
+
+	return elapsed, periods
+}
+
+func toSlaIntervalOutputs(periods []calendar.WorkingPeriod) []slaIntervalOutput {
+	outputs := []slaIntervalOutput{}
+	for _, period := range periods {
+		outputs = append(outputs, slaIntervalOutput{
+			Start:    period.Start.Format(time.RFC3339),
+			End:      period.End.Format(time.RFC3339),
+			Duration: period.End.Sub(period.Start).String(),
+		})
+	}
+
+	return outputs
+}
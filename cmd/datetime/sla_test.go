@@ -0,0 +1,130 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"testing"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSlaCmd(t *testing.T) {
+	// arrange
+	iostreams, _, _, _ := iostreams.Test()
+	cmd := NewSlaCmd(*iostreams)
+
+	// act
+	_, err := cmd.ExecuteC()
+
+	// assert
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "must specify a subcommand")
+}
+
+func TestSlaDueCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewSlaCmd(*iostreams)
+
+	// act: opened on a Friday after hours, Thursday the 8th is a holiday
+	cmd.SetArgs([]string{"due", "-s=2022-12-02 17:30", "-d=25h", "--tz=Europe/Lisbon"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), "Fri Dec  9 10:00:00 WET 2022")
+	assert.Contains(t, out.String(), `"Start": "2022-12-05T09:00:00Z"`)
+	assert.NotContains(t, out.String(), "2022-12-08")
+}
+
+func TestSlaDueCmdWithScheduleInSummerTime(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewSlaCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{
+		"due",
+		"-s=2022-07-01 11:00",
+		"-d=PT6H",
+		"--tz=Europe/Lisbon",
+		"--calendar=none",
+		"--schedule=mon-thu 09:00-13:00,14:00-18:00; fri 09:00-13:00",
+	})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), "Mon Jul  4 13:00:00 WEST 2022")
+	assert.Contains(t, out.String(), `"Start": "2022-07-01T11:00:00+01:00"`)
+}
+
+func TestSlaElapsedCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewSlaCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{
+		"elapsed",
+		"-s=2022-12-02 16:30",
+		"-e=2022-12-05 10:15",
+		"--tz=Europe/Lisbon",
+		"--schedule=mon-fri 09:00-13:00,14:00-18:00",
+	})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), `"Duration": "2h45m0s"`)
+	assert.Contains(t, out.String(), `"BusinessHours": 2.75`)
+}
+
+func TestSlaCmdInvalidValues(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"due", "-d=P1D"}, expected: "positive number of hours"},
+		{args: []string{"due", "-d=1h", "--schedule=mon-fri"}, expected: "has no working hours"},
+		{args: []string{"due", "-d=1h", "--schedule=mon-fri 18:00-09:00"}, expected: "the end must be after the start"},
+		{args: []string{"elapsed", "-s=2022-12-02", "-e=2022-12-01"}, expected: "the end must be after the start"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewSlaCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(tc.args)
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.NotNil(t, err, tc.args)
+		if err != nil {
+			assert.Contains(t, err.Error(), tc.expected)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"math"
+	"time"
+)
+
+// The NOAA solar calculator algorithm, based on Astronomical Algorithms by
+// Jean Meeus, accurate to about a minute between 1800 and 2100 for
+// latitudes below 72º.
+
+// Zenith angles of the center of the sun, in degrees: sunrise and sunset
+// account for the refraction and the radius of the sun.
+const (
+	zenithSunrise      = 90.833
+	zenithCivil        = 96.0
+	zenithNautical     = 102.0
+	zenithAstronomical = 108.0
+)
+
+// solarIterations refines the events with the position of the sun at the
+// time of the previous estimate.
+const solarIterations = 3
+
+// solarPosition returns the declination of the sun, in degrees, and the
+// equation of time, in minutes, at t.
+func solarPosition(t time.Time) (float64, float64) {
+	julianDay := float64(t.Unix())/86400 + 2440587.5
+	jc := (julianDay - 2451545) / 36525
+
+	meanLongitude := math.Mod(280.46646+jc*(36000.76983+jc*0.0003032), 360)
+	meanAnomaly := 357.52911 + jc*(35999.05029-0.0001537*jc)
+	eccentricity := 0.016708634 - jc*(0.000042037+0.0000001267*jc)
+
+	center := math.Sin(radians(meanAnomaly))*(1.914602-jc*(0.004817+0.000014*jc)) +
+		math.Sin(radians(2*meanAnomaly))*(0.019993-0.000101*jc) +
+		math.Sin(radians(3*meanAnomaly))*0.000289
+	omega := 125.04 - 1934.136*jc
+	apparentLongitude := meanLongitude + center - 0.00569 - 0.00478*math.Sin(radians(omega))
+
+	meanObliquity := 23 + (26+(21.448-jc*(46.815+jc*(0.00059-jc*0.001813)))/60)/60
+	obliquity := meanObliquity + 0.00256*math.Cos(radians(omega))
+
+	declination := degrees(math.Asin(math.Sin(radians(obliquity)) * math.Sin(radians(apparentLongitude))))
+
+	y := math.Pow(math.Tan(radians(obliquity/2)), 2)
+	l, m := radians(meanLongitude), radians(meanAnomaly)
+	equationOfTime := 4 * degrees(y*math.Sin(2*l)-
+		2*eccentricity*math.Sin(m)+
+		4*eccentricity*y*math.Sin(m)*math.Cos(2*l)-
+		0.5*y*y*math.Sin(4*l)-
+		1.25*eccentricity*eccentricity*math.Sin(2*m))
+
+	return declination, equationOfTime
+}
+
+// solarNoon returns when the sun crosses the meridian at longitude lon
+// (east positive) on the UTC day starting at day.
+func solarNoon(day time.Time, lon float64) time.Time {
+	noon := addMinutes(day, 720-4*lon)
+	for i := 0; i < solarIterations; i++ {
+		_, equationOfTime := solarPosition(noon)
+		noon = addMinutes(day, 720-4*lon-equationOfTime)
+	}
+	return noon
+}
+
+// solarEvent returns when the sun crosses the zenith angle before (rising)
+// or after solar noon on the UTC day starting at day. When it doesn't, the
+// sign tells if the sun stays above (1) or below (-1) the zenith angle.
+func solarEvent(day time.Time, lat, lon, zenith float64, rising bool) (time.Time, int) {
+	direction := 1.0
+	if rising {
+		direction = -1
+	}
+
+	t := solarNoon(day, lon)
+	for i := 0; i < solarIterations; i++ {
+		declination, equationOfTime := solarPosition(t)
+
+		cosHourAngle := math.Cos(radians(zenith))/(math.Cos(radians(lat))*math.Cos(radians(declination))) -
+			math.Tan(radians(lat))*math.Tan(radians(declination))
+		if cosHourAngle > 1 {
+			return time.Time{}, -1
+		}
+		if cosHourAngle < -1 {
+			return time.Time{}, 1
+		}
+
+		hourAngle := degrees(math.Acos(cosHourAngle))
+		t = addMinutes(day, 720-4*lon-equationOfTime+direction*4*hourAngle)
+	}
+
+	return t, 0
+}
+
+func addMinutes(t time.Time, minutes float64) time.Time {
+	return t.Add(time.Duration(minutes * float64(time.Minute)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
@@ -0,0 +1,67 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolarPosition(t *testing.T) {
+	// act: the June solstice and the December solstice of 2026
+	juneDeclination, juneEquation := solarPosition(time.Date(2026, time.June, 21, 8, 24, 0, 0, time.UTC))
+	decemberDeclination, decemberEquation := solarPosition(time.Date(2026, time.December, 21, 20, 50, 0, 0, time.UTC))
+
+	// assert
+	assert.InDelta(t, 23.44, juneDeclination, 0.01)
+	assert.InDelta(t, -1.7, juneEquation, 0.2)
+	assert.InDelta(t, -23.44, decemberDeclination, 0.01)
+	assert.InDelta(t, 1.8, decemberEquation, 0.2)
+}
+
+func TestSolarEvents(t *testing.T) {
+	// arrange: Greenwich, on the March equinox
+	day := time.Date(2026, time.March, 20, 0, 0, 0, 0, time.UTC)
+
+	// act
+	noon := solarNoon(day, 0)
+	sunrise, sunrisePolar := solarEvent(day, 51.48, 0, zenithSunrise, true)
+	sunset, sunsetPolar := solarEvent(day, 51.48, 0, zenithSunrise, false)
+
+	// assert
+	assert.WithinDuration(t, time.Date(2026, time.March, 20, 12, 7, 30, 0, time.UTC), noon, time.Minute)
+	assert.Equal(t, 0, sunrisePolar)
+	assert.Equal(t, 0, sunsetPolar)
+	assert.WithinDuration(t, time.Date(2026, time.March, 20, 6, 3, 0, 0, time.UTC), sunrise, time.Minute)
+	assert.WithinDuration(t, time.Date(2026, time.March, 20, 18, 13, 0, 0, time.UTC), sunset, time.Minute)
+}
+
+func TestSolarEventPolar(t *testing.T) {
+	// arrange: the North Pole
+	june := time.Date(2026, time.June, 21, 0, 0, 0, 0, time.UTC)
+	december := time.Date(2026, time.December, 21, 0, 0, 0, 0, time.UTC)
+
+	// act
+	_, midnightSun := solarEvent(june, 90, 0, zenithSunrise, true)
+	_, polarNight := solarEvent(december, 90, 0, zenithAstronomical, true)
+
+	// assert
+	assert.Equal(t, 1, midnightSun)
+	assert.Equal(t, -1, polarNight)
+}
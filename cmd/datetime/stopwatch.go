@@ -0,0 +1,162 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type stopwatchLapOutput struct {
+	Lap   int
+	Time  string
+	Total string
+}
+
+type stopwatchOutput struct {
+	Start   string
+	Stop    string
+	Elapsed string
+	Laps    []stopwatchLapOutput `json:",omitempty"`
+}
+
+// stopwatchRefresh is the interval between updates of the elapsed time.
+const stopwatchRefresh = 100 * time.Millisecond
+
+// stopwatchStopWords stop the stopwatch when entered.
+var stopwatchStopWords = map[string]bool{"q": true, "quit": true, "stop": true}
+
+func NewStopwatchCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newStopwatchCmd(iostreams, systemClock{})
+}
+
+func newStopwatchCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var stopwatchCmd = &cobra.Command{
+		Use:   "stopwatch",
+		Short: "Measures the elapsed time, with laps",
+		Long: heredoc.Doc(`
+			Measures the elapsed time until stopped, then prints a JSON record.
+
+			Each line read from the standard input (e.g. pressing Enter)
+			records a lap. Entering q, quit or stop, closing the input
+			(Ctrl+D) or interrupting (Ctrl+C) stops the stopwatch. When there
+			are laps, the time since the last one is recorded as the final
+			lap.
+
+			When the output is a terminal the elapsed time is displayed and
+			updated every tenth of a second.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime stopwatch
+			canivete datetime stopwatch --tz Europe/Lisbon
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tz, _ := cmd.Flags().GetString(flagTimezone)
+
+			loc, err := loadLocation(tz)
+			if err != nil {
+				return err
+			}
+
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt)
+			defer signal.Stop(interrupt)
+
+			return iostreams.PrintOutput(runStopwatch(iostreams, clock, loc, interrupt))
+		},
+	}
+
+	stopwatchCmd.Flags().String(flagTimezone, "", "the IANA timezone of the instants (e.g. Europe/Lisbon)")
+
+	return stopwatchCmd
+}
+
+// runStopwatch measures the time until a stop word, the end of the input or
+// an interrupt, recording a lap for each other line of the input.
+func runStopwatch(iostreams iostreams.IOStreams, clock clock, loc *time.Location, interrupt <-chan os.Signal) stopwatchOutput {
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(iostreams.In)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	start := clock.Now()
+	lapStart := start
+	laps := []stopwatchLapOutput{}
+
+	var stop time.Time
+	for stop.IsZero() {
+		var refresh <-chan time.Time
+		if iostreams.OutIsTerminal {
+			fmt.Fprintf(iostreams.Out, "\r%s ", formatCountdown(clock.Now().Sub(start), true))
+			refresh = clock.After(stopwatchRefresh)
+		}
+
+		select {
+		case line, ok := <-lines:
+			now := clock.Now()
+			if !ok || stopwatchStopWords[strings.ToLower(strings.TrimSpace(line))] {
+				stop = now
+				continue
+			}
+
+			laps = append(laps, newStopwatchLapOutput(len(laps)+1, lapStart, now, start))
+			if iostreams.OutIsTerminal {
+				fmt.Fprintf(iostreams.Out, "\rLap %d  %s  %s\n", len(laps), formatCountdown(now.Sub(lapStart), true), formatCountdown(now.Sub(start), true))
+			}
+			lapStart = now
+		case <-refresh:
+		case <-interrupt:
+			stop = clock.Now()
+		}
+	}
+
+	if iostreams.OutIsTerminal {
+		fmt.Fprintf(iostreams.Out, "\r%s \n", formatCountdown(stop.Sub(start), true))
+	}
+
+	output := stopwatchOutput{
+		Start:   start.In(loc).Format(time.RFC3339),
+		Stop:    stop.In(loc).Format(time.RFC3339),
+		Elapsed: stop.Sub(start).Round(time.Millisecond).String(),
+	}
+	if len(laps) > 0 {
+		output.Laps = append(laps, newStopwatchLapOutput(len(laps)+1, lapStart, stop, start))
+	}
+
+	return output
+}
+
+func newStopwatchLapOutput(lap int, lapStart, lapEnd, start time.Time) stopwatchLapOutput {
+	return stopwatchLapOutput{
+		Lap:   lap,
+		Time:  lapEnd.Sub(lapStart).Round(time.Millisecond).String(),
+		Total: lapEnd.Sub(start).Round(time.Millisecond).String(),
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopwatchCmdLaps(t *testing.T) {
+	// arrange
+	clock := &steppingClock{now: time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC), step: 1500 * time.Millisecond}
+	iostreams, in, out, _ := iostreams.Test()
+	in.WriteString("\n\nQuit\nignored\n")
+	cmd := newStopwatchCmd(*iostreams, clock)
+
+	// act
+	cmd.SetArgs([]string{"--tz=Europe/Lisbon"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := stopwatchOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	assert.Equal(t, stopwatchOutput{
+		Start:   "2026-10-19T11:00:00+01:00",
+		Stop:    "2026-10-19T11:00:04+01:00",
+		Elapsed: "4.5s",
+		Laps: []stopwatchLapOutput{
+			{Lap: 1, Time: "1.5s", Total: "1.5s"},
+			{Lap: 2, Time: "1.5s", Total: "3s"},
+			{Lap: 3, Time: "1.5s", Total: "4.5s"},
+		},
+	}, output)
+}
+
+func TestStopwatchCmdEndOfInput(t *testing.T) {
+	// arrange
+	clock := &steppingClock{now: time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC), step: time.Minute}
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := newStopwatchCmd(*iostreams, clock)
+
+	// act
+	cmd.SetArgs([]string{})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := stopwatchOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	assert.Equal(t, stopwatchOutput{
+		Start:   "2026-10-19T10:00:00Z",
+		Stop:    "2026-10-19T10:01:00Z",
+		Elapsed: "1m0s",
+	}, output)
+	assert.NotContains(t, out.String(), "Laps")
+}
+
+func TestStopwatchCmdInvalidTimezone(t *testing.T) {
+	// arrange
+	iostreams, _, _, _ := iostreams.Test()
+	cmd := NewStopwatchCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"--tz=Mars/Olympus"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	assert.EqualError(t, err, `invalid timezone "Mars/Olympus"`)
+}
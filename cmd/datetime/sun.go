@@ -0,0 +1,286 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/renato0307/canivete/pkg/zones"
+	"github.com/spf13/cobra"
+)
+
+type sunDayOutput struct {
+	Date             string
+	AstronomicalDawn string `json:",omitempty"`
+	NauticalDawn     string `json:",omitempty"`
+	CivilDawn        string `json:",omitempty"`
+	Sunrise          string `json:",omitempty"`
+	SolarNoon        string
+	Sunset           string `json:",omitempty"`
+	CivilDusk        string `json:",omitempty"`
+	NauticalDusk     string `json:",omitempty"`
+	AstronomicalDusk string `json:",omitempty"`
+	DayLength        string
+	// Polar is day when the sun doesn't set and night when it doesn't rise.
+	Polar string `json:",omitempty"`
+
+	// date and times are the day and its events in the order of the table
+	// columns, zero when they don't happen
+	date  time.Time
+	times []time.Time
+}
+
+type sunOutput struct {
+	Latitude  float64
+	Longitude float64
+	Timezone  string
+	Days      []sunDayOutput
+}
+
+const flagLatitude = "lat"
+const flagLongitude = "lon"
+
+// sunMaxDays limits the number of days of a range.
+const sunMaxDays = 3660
+
+// sunEvents are the events of a day, in chronological order, with the
+// column of the table that shows them.
+var sunEvents = []struct {
+	zenith float64
+	rising bool
+	header string
+	field  func(day *sunDayOutput) *string
+}{
+	{zenithAstronomical, true, "ASTRO DAWN", func(day *sunDayOutput) *string { return &day.AstronomicalDawn }},
+	{zenithNautical, true, "NAUTICAL DAWN", func(day *sunDayOutput) *string { return &day.NauticalDawn }},
+	{zenithCivil, true, "CIVIL DAWN", func(day *sunDayOutput) *string { return &day.CivilDawn }},
+	{zenithSunrise, true, "SUNRISE", func(day *sunDayOutput) *string { return &day.Sunrise }},
+	{zenithSunrise, false, "SUNSET", func(day *sunDayOutput) *string { return &day.Sunset }},
+	{zenithCivil, false, "CIVIL DUSK", func(day *sunDayOutput) *string { return &day.CivilDusk }},
+	{zenithNautical, false, "NAUTICAL DUSK", func(day *sunDayOutput) *string { return &day.NauticalDusk }},
+	{zenithAstronomical, false, "ASTRO DUSK", func(day *sunDayOutput) *string { return &day.AstronomicalDusk }},
+}
+
+func NewSunCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newSunCmd(iostreams, systemClock{})
+}
+
+func newSunCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var sunCmd = &cobra.Command{
+		Use:   "sun",
+		Short: "Calculates the sunrise, sunset and twilight times of a place",
+		Long: heredoc.Doc(`
+			Calculates the sunrise, sunset, solar noon, day length and the
+			civil, nautical and astronomical twilights of a place, using the
+			NOAA solar calculator algorithm (accurate to about a minute).
+
+			Coordinates are in decimal degrees, north and east positive.
+			Times are in the timezone of the nearest timezone location (e.g.
+			Lisbon for Porto) unless --tz is given.
+
+			Dawns and dusks that don't happen (e.g. during the summer at high
+			latitudes) are shown as -.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime sun --lat 38.72 --lon -9.14
+			canivete datetime sun --lat 38.72 --lon -9.14 --date 2026-12-21
+			canivete datetime sun --lat 69.65 --lon 18.96 -d 2026-06-01 -t 2026-06-30
+			canivete datetime sun --lat 40.71 --lon -74.01 --tz America/New_York --json
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			latitude, _ := cmd.Flags().GetFloat64(flagLatitude)
+			longitude, _ := cmd.Flags().GetFloat64(flagLongitude)
+			tz, _ := cmd.Flags().GetString(flagTimezone)
+			dateValue, _ := cmd.Flags().GetString(flagDate)
+			toValue, _ := cmd.Flags().GetString(flagTo)
+			asJson, _ := cmd.Flags().GetBool(flagJson)
+
+			if latitude < -90 || latitude > 90 {
+				return fmt.Errorf("invalid latitude %v, must be between -90 and 90", latitude)
+			}
+			if longitude < -180 || longitude > 180 {
+				return fmt.Errorf("invalid longitude %v, must be between -180 and 180", longitude)
+			}
+
+			if tz == "" {
+				tz = zones.Nearest(latitude, longitude).Name
+			}
+			loc, err := loadLocation(tz)
+			if err != nil {
+				return err
+			}
+
+			now := clock.Now()
+			from, err := parseInstant(dateValue, loc, now)
+			if err != nil {
+				return err
+			}
+			to := from
+			if toValue != "" {
+				if to, err = parseInstant(toValue, loc, now); err != nil {
+					return err
+				}
+			}
+
+			output, err := runSun(latitude, longitude, loc, from, to)
+			if err != nil {
+				return err
+			}
+			if asJson {
+				return iostreams.PrintOutput(output)
+			}
+
+			return printSunTable(iostreams, output)
+		},
+	}
+
+	sunCmd.Flags().Float64(flagLatitude, 0, "the latitude, in decimal degrees (north positive)")
+	sunCmd.MarkFlagRequired(flagLatitude)
+	sunCmd.Flags().Float64(flagLongitude, 0, "the longitude, in decimal degrees (east positive)")
+	sunCmd.MarkFlagRequired(flagLongitude)
+
+	sunCmd.Flags().StringP(flagDate, "d", "now", "the day, or the first day of a range")
+	sunCmd.Flags().StringP(flagTo, "t", "", "the last day of a range")
+	sunCmd.Flags().String(flagTimezone, "", "the IANA timezone of the times, the one of the place by default")
+	sunCmd.Flags().Bool(flagJson, false, "output JSON instead of a table")
+
+	return sunCmd
+}
+
+func runSun(latitude, longitude float64, loc *time.Location, from, to time.Time) (sunOutput, error) {
+	first := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	if last.Before(first) {
+		return sunOutput{}, fmt.Errorf("the last day can't be before the first one")
+	}
+	if last.Sub(first) >= sunMaxDays*24*time.Hour {
+		return sunOutput{}, fmt.Errorf("the range can't have more than %d days", sunMaxDays)
+	}
+
+	output := sunOutput{
+		Latitude:  latitude,
+		Longitude: longitude,
+		Timezone:  loc.String(),
+		Days:      []sunDayOutput{},
+	}
+	for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+		output.Days = append(output.Days, sunDay(latitude, longitude, loc, date))
+	}
+
+	return output, nil
+}
+
+// sunDay calculates the events of the day whose solar noon falls on date
+// in loc (e.g. in zones more than 12 hours ahead of their mean solar time,
+// the solar day starts in the previous UTC day).
+func sunDay(latitude, longitude float64, loc *time.Location, date time.Time) sunDayOutput {
+	day := date
+	noon := solarNoon(day, longitude)
+
+	localNoon := noon.In(loc)
+	localDate := time.Date(localNoon.Year(), localNoon.Month(), localNoon.Day(), 0, 0, 0, 0, time.UTC)
+	if shift := localDate.Sub(date); shift != 0 {
+		day = day.Add(-shift)
+		noon = solarNoon(day, longitude)
+	}
+
+	output := sunDayOutput{
+		Date:      date.Format(dateLayout),
+		SolarNoon: formatSunTime(noon, loc),
+		date:      date,
+	}
+
+	var sunrise, sunset time.Time
+	times := make([]time.Time, len(sunEvents))
+	for i, event := range sunEvents {
+		t, polar := solarEvent(day, latitude, longitude, event.zenith, event.rising)
+		if polar != 0 {
+			if event.zenith == zenithSunrise {
+				output.Polar = map[int]string{1: "day", -1: "night"}[polar]
+			}
+			continue
+		}
+
+		*event.field(&output) = formatSunTime(t, loc)
+		times[i] = t.Round(time.Second).In(loc)
+		if event.zenith == zenithSunrise && event.rising {
+			sunrise = t
+		} else if event.zenith == zenithSunrise {
+			sunset = t
+		}
+	}
+
+	output.times = append(times[:4], append([]time.Time{noon.Round(time.Second).In(loc)}, times[4:]...)...)
+
+	switch output.Polar {
+	case "day":
+		output.DayLength = formatDayLength(24 * time.Hour)
+	case "night":
+		output.DayLength = formatDayLength(0)
+	default:
+		output.DayLength = formatDayLength(sunset.Sub(sunrise))
+	}
+
+	return output
+}
+
+func formatSunTime(t time.Time, loc *time.Location) string {
+	return t.Round(time.Second).In(loc).Format(time.RFC3339)
+}
+
+func formatDayLength(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// printSunTable prints a row per day with the times rounded to the minute,
+// marking the ones on another day.
+func printSunTable(iostreams iostreams.IOStreams, output sunOutput) error {
+	fmt.Fprintf(iostreams.Out, "%.4f, %.4f (%s)\n\n", output.Latitude, output.Longitude, output.Timezone)
+
+	headers := []string{"DATE"}
+	for _, event := range sunEvents[:4] {
+		headers = append(headers, event.header)
+	}
+	headers = append(headers, "SOLAR NOON")
+	for _, event := range sunEvents[4:] {
+		headers = append(headers, event.header)
+	}
+	headers = append(headers, "DAY LENGTH")
+
+	rows := [][]string{}
+	for _, day := range output.Days {
+		row := []string{day.Date}
+		for _, t := range day.times {
+			if t.IsZero() {
+				row = append(row, "-")
+				continue
+			}
+			t = t.Round(time.Minute)
+			row = append(row, t.Format("15:04")+meetDayShift(day.date, t))
+		}
+		row = append(row, day.DayLength)
+
+		rows = append(rows, row)
+	}
+
+	return iostreams.PrintTable(headers, rows)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSunCmdTable(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewSunCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"--lat=38.72", "--lon=-9.14", "--date=2026-12-21", "--to=2026-12-22"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join([]string{
+		"38.7200, -9.1400 (Europe/Lisbon)",
+		"",
+		"DATE        ASTRO DAWN  NAUTICAL DAWN  CIVIL DAWN  SUNRISE  SOLAR NOON  SUNSET  CIVIL DUSK  NAUTICAL DUSK  ASTRO DUSK  DAY LENGTH",
+		"2026-12-21  06:15       06:48          07:21       07:51    12:35       17:18   17:48       18:22          18:54       9h27m",
+		"2026-12-22  06:16       06:48          07:22       07:51    12:35       17:19   17:49       18:22          18:55       9h27m",
+		"",
+	}, "\n")
+	assert.Equal(t, expected, out.String())
+}
+
+func TestSunCmdTableDayShiftAndPolar(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewSunCmd(*iostreams)
+
+	// act: the polar night of Tromsø, in Tokyo time
+	cmd.SetArgs([]string{"--lat=69.65", "--lon=18.96", "--date=2026-12-21", "--tz=Asia/Tokyo"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	assert.Equal(t, "2026-12-21  14:28       15:47          17:31       -        19:42       -       21:53       23:38          00:56 (+1)  0h00m", lines[3])
+}
+
+func TestSunCmdJsonPolar(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewSunCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"--lat=69.65", "--lon=18.96", "--date=2026-12-21", "--tz=Europe/Oslo", "--json"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := sunOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	assert.Equal(t, "Europe/Oslo", output.Timezone)
+	assert.Len(t, output.Days, 1)
+	assert.Equal(t, "night", output.Days[0].Polar)
+	assert.Equal(t, "0h00m", output.Days[0].DayLength)
+	assert.Empty(t, output.Days[0].Sunrise)
+	assert.Empty(t, output.Days[0].Sunset)
+	assert.True(t, strings.HasPrefix(output.Days[0].CivilDawn, "2026-12-21T09:31:"), output.Days[0].CivilDawn)
+}
+
+func TestSunDayAheadOfSolarTime(t *testing.T) {
+	// arrange: Kiritimati is at UTC+14 but 157ºW
+	loc, _ := time.LoadLocation("Pacific/Kiritimati")
+
+	// act
+	day := sunDay(1.87, -157.4, loc, time.Date(2026, time.March, 20, 0, 0, 0, 0, time.UTC))
+
+	// assert
+	assert.Equal(t, "2026-03-20", day.Date)
+	assert.True(t, strings.HasPrefix(day.SolarNoon, "2026-03-20T12:"), day.SolarNoon)
+	assert.True(t, strings.HasPrefix(day.Sunrise, "2026-03-20T06:"), day.Sunrise)
+}
+
+func TestSunCmdErrors(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--lat=91", "--lon=0"}, "invalid latitude 91, must be between -90 and 90"},
+		{[]string{"--lat=0", "--lon=-181"}, "invalid longitude -181, must be between -180 and 180"},
+		{[]string{"--lat=0", "--lon=0", "--tz=Mars/Olympus"}, `invalid timezone "Mars/Olympus"`},
+		{[]string{"--lat=0", "--lon=0", "-d=2026-12-21", "-t=2026-12-20"}, "the last day can't be before the first one"},
+		{[]string{"--lat=0", "--lon=0", "-d=2026-01-01", "-t=2036-12-31"}, "the range can't have more than 3660 days"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewSunCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(tc.args)
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.EqualError(t, err, tc.expected, tc.args)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type timerOutput struct {
+	Start     string
+	End       string
+	Duration  string
+	Elapsed   string
+	Completed bool
+}
+
+func NewTimerCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newTimerCmd(iostreams, systemClock{})
+}
+
+func newTimerCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var timerCmd = &cobra.Command{
+		Use:   "timer <duration|instant>",
+		Short: "Counts down to an instant or for a duration",
+		Long: heredoc.Doc(`
+			Counts down for a duration (e.g. 25m or PT1H30M) or until an
+			instant (e.g. 2026-12-31T23:59:59 or tomorrow 9am), then prints a
+			JSON record.
+
+			When the output is a terminal the remaining time is displayed and
+			updated every second. Interrupting the timer (Ctrl+C) prints the
+			record with Completed set to false.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime timer 25m
+			canivete datetime timer PT1H30M
+			canivete datetime timer "tomorrow 9am" --tz Europe/Lisbon
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tz, _ := cmd.Flags().GetString(flagTimezone)
+
+			loc, err := loadLocation(tz)
+			if err != nil {
+				return err
+			}
+
+			start := clock.Now().In(loc)
+			end, err := parseTimerEnd(args[0], start)
+			if err != nil {
+				return err
+			}
+
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt)
+			defer signal.Stop(interrupt)
+
+			return iostreams.PrintOutput(runTimer(iostreams, clock, start, end, interrupt))
+		},
+	}
+
+	timerCmd.Flags().String(flagTimezone, "", "the IANA timezone of the instants (e.g. Europe/Lisbon)")
+
+	return timerCmd
+}
+
+// parseTimerEnd returns the end of a timer, given a duration from start or
+// an instant.
+func parseTimerEnd(value string, start time.Time) (time.Time, error) {
+	var end time.Time
+	var err error
+	if d, durationErr := parseDuration(value); durationErr == nil {
+		end, err = d.addTo(start, monthEndClamp)
+	} else {
+		end, err = parseInstant(value, start.Location(), start)
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !end.After(start) {
+		return time.Time{}, fmt.Errorf("the timer must end in the future")
+	}
+
+	return end, nil
+}
+
+// runTimer waits until end, displaying the remaining time when the output
+// is a terminal, or until interrupted.
+func runTimer(iostreams iostreams.IOStreams, clock clock, start, end time.Time, interrupt <-chan os.Signal) timerOutput {
+	completed := true
+
+countdown:
+	for {
+		remaining := end.Sub(clock.Now())
+		if remaining <= 0 {
+			break
+		}
+
+		// refresh when the displayed seconds, rounded up, change
+		wait := remaining
+		if iostreams.OutIsTerminal {
+			seconds := (remaining + time.Second - 1) / time.Second
+			fmt.Fprintf(iostreams.Out, "\r%s ", formatCountdown(seconds*time.Second, false))
+			wait = remaining - (seconds-1)*time.Second
+		}
+
+		select {
+		case <-clock.After(wait):
+		case <-interrupt:
+			completed = false
+			break countdown
+		}
+	}
+
+	stop := clock.Now()
+	if iostreams.OutIsTerminal {
+		if completed {
+			fmt.Fprintf(iostreams.Out, "\r%s \a", formatCountdown(0, false))
+		}
+		fmt.Fprintln(iostreams.Out)
+	}
+
+	return timerOutput{
+		Start:     start.Format(time.RFC3339),
+		End:       end.Format(time.RFC3339),
+		Duration:  end.Sub(start).String(),
+		Elapsed:   stop.Sub(start).Round(time.Millisecond).String(),
+		Completed: completed,
+	}
+}
+
+// formatCountdown formats a duration as hours, minutes and seconds,
+// optionally with tenths of a second.
+func formatCountdown(d time.Duration, tenths bool) string {
+	if !tenths {
+		d = d.Round(time.Second)
+		return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+	}
+
+	d = d.Truncate(100 * time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d.%d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60, d.Milliseconds()%1000/100)
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+// steppingClock advances by step on each call to Now and, immediately, by
+// the waited duration on each call to After.
+type steppingClock struct {
+	now  time.Time
+	step time.Duration
+}
+
+func (c *steppingClock) Now() time.Time {
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+func (c *steppingClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	after := make(chan time.Time, 1)
+	after <- c.now
+	return after
+}
+
+// stoppedClock never stops waiting.
+type stoppedClock struct {
+	fixedClock
+}
+
+func (stoppedClock) After(d time.Duration) <-chan time.Time {
+	return nil
+}
+
+func TestTimerCmd(t *testing.T) {
+	// arrange
+	clock := &steppingClock{now: time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)}
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := newTimerCmd(*iostreams, clock)
+
+	// act
+	cmd.SetArgs([]string{"90s", "--tz=Europe/Lisbon"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := timerOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	assert.Equal(t, timerOutput{
+		Start:     "2026-10-19T11:00:00+01:00",
+		End:       "2026-10-19T11:01:30+01:00",
+		Duration:  "1m30s",
+		Elapsed:   "1m30s",
+		Completed: true,
+	}, output)
+}
+
+func TestTimerCmdTerminal(t *testing.T) {
+	// arrange
+	clock := &steppingClock{now: time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)}
+	iostreams, _, out, _ := iostreams.Test()
+	iostreams.OutIsTerminal = true
+	cmd := newTimerCmd(*iostreams, clock)
+
+	// act
+	cmd.SetArgs([]string{"2026-10-19T10:00:02Z"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, strings.HasPrefix(out.String(), "\r00:00:02 \r00:00:01 \r00:00:00 \a\n{"), out.String())
+}
+
+func TestRunTimerInterrupted(t *testing.T) {
+	// arrange
+	start := time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)
+	clock := stoppedClock{fixedClock{now: start}}
+	iostreams, _, _, _ := iostreams.Test()
+	interrupt := make(chan os.Signal, 1)
+	interrupt <- os.Interrupt
+
+	// act
+	output := runTimer(*iostreams, clock, start, start.Add(time.Minute), interrupt)
+
+	// assert
+	assert.False(t, output.Completed)
+	assert.Equal(t, "0s", output.Elapsed)
+}
+
+func TestTimerCmdErrors(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"2026-10-19T09:00:00Z"}, "the timer must end in the future"},
+		{[]string{"0s"}, "the timer must end in the future"},
+		{[]string{"5m", "--tz=Mars/Olympus"}, `invalid timezone "Mars/Olympus"`},
+	}
+	clock := fixedClock{now: time.Date(2026, time.October, 19, 10, 0, 0, 0, time.UTC)}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := newTimerCmd(*iostreams, clock)
+
+		// act
+		cmd.SetArgs(tc.args)
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.EqualError(t, err, tc.expected, tc.args)
+	}
+}
+
+func TestFormatCountdown(t *testing.T) {
+	testCases := []struct {
+		d        time.Duration
+		tenths   bool
+		expected string
+	}{
+		{0, false, "00:00:00"},
+		{90 * time.Minute, false, "01:30:00"},
+		{26*time.Hour + 3*time.Second, false, "26:00:03"},
+		{1234 * time.Millisecond, true, "00:00:01.2"},
+		{59*time.Second + 999*time.Millisecond, true, "00:00:59.9"},
+	}
+
+	for _, tc := range testCases {
+		// act
+		actual := formatCountdown(tc.d, tc.tenths)
+
+		// assert
+		assert.Equal(t, tc.expected, actual, tc.d)
+	}
+}
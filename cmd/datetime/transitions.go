@@ -0,0 +1,60 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"time"
+)
+
+// zoneTransitions returns the instants, in loc, when the UTC offset of loc
+// changes during the year. The year is scanned hour by hour and each change
+// is then narrowed down to the second.
+func zoneTransitions(loc *time.Location, year int) []time.Time {
+	transitions := []time.Time{}
+
+	offsetAt := func(unix int64) int {
+		_, offset := time.Unix(unix, 0).In(loc).Zone()
+		return offset
+	}
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc).Unix()
+	end := time.Date(year+1, time.January, 1, 0, 0, 0, 0, loc).Unix()
+	offset := offsetAt(start)
+
+	for t := start; t < end; t += 3600 {
+		next := t + 3600
+		nextOffset := offsetAt(next)
+		if nextOffset == offset {
+			continue
+		}
+
+		low, high := t, next
+		for high-low > 1 {
+			middle := (low + high) / 2
+			if offsetAt(middle) == offset {
+				low = middle
+			} else {
+				high = middle
+			}
+		}
+
+		transitions = append(transitions, time.Unix(high, 0).In(loc))
+		offset = nextOffset
+	}
+
+	return transitions
+}
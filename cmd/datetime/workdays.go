@@ -0,0 +1,342 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/calendar"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+type holidayOutput struct {
+	Date     string
+	Weekday  string
+	Name     string
+	Observed bool `json:",omitempty"`
+}
+
+type workdaysBetweenOutput struct {
+	Calendar    string
+	From        string
+	To          string
+	Workdays    int
+	WeekendDays int
+	Holidays    []holidayOutput
+}
+
+type workdaysAddOutput struct {
+	Calendar string
+	From     string
+	Days     int
+	Date     string
+	Weekday  string
+}
+
+type workdaysCheckOutput struct {
+	Calendar string
+	Date     string
+	Weekday  string
+	Workday  bool
+	Weekend  bool
+	Holiday  string `json:",omitempty"`
+}
+
+type workdaysHolidaysOutput struct {
+	Calendar string
+	Year     int
+	Holidays []holidayOutput
+}
+
+const flagCalendar = "calendar"
+const flagTo = "to"
+const flagDays = "days"
+const flagDate = "date"
+const flagYear = "year"
+
+const dateLayout = "2006-01-02"
+
+func NewWorkdaysCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newWorkdaysCmd(iostreams, systemClock{})
+}
+
+func newWorkdaysCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var workdaysCmd = &cobra.Command{
+		Use:   "workdays",
+		Short: "Business days calculations using holiday calendars",
+		Long: heredoc.Doc(`
+			Business days calculations using holiday calendars.
+
+			Embedded calendars: br, de, es, fr, gb, pt and us.
+
+			Custom calendars can be defined in the config file, optionally
+			extending an embedded one:
+
+				calendars:
+				  lisbon:
+				    extends: pt
+				    rules:
+				      - name: St. Anthony's Day
+				        type: fixed
+				        month: 6
+				        day: 13
+
+			Rule types are fixed (month and day), easter (offset days from
+			Easter Sunday) and nth-weekday (month, weekday and nth, -1 for the
+			last). Weekend holidays can be moved with observed: substitute or
+			observed: nearest.
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fmt.Errorf("must specify a subcommand")
+		},
+	}
+
+	workdaysCmd.PersistentFlags().StringP(
+		flagCalendar,
+		"c",
+		"pt",
+		"the holiday calendar (embedded or defined in the config file)")
+
+	workdaysCmd.PersistentFlags().String(
+		flagTimezone,
+		"UTC",
+		"the IANA timezone of the dates, e.g. of now or of instants with an offset (e.g. Europe/Lisbon)")
+
+	workdaysCmd.AddCommand(newWorkdaysBetweenCmd(iostreams, clock))
+	workdaysCmd.AddCommand(newWorkdaysAddCmd(iostreams, clock))
+	workdaysCmd.AddCommand(newWorkdaysCheckCmd(iostreams, clock))
+	workdaysCmd.AddCommand(newWorkdaysHolidaysCmd(iostreams, clock))
+
+	return workdaysCmd
+}
+
+func newWorkdaysBetweenCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var betweenCmd = &cobra.Command{
+		Use:   "between",
+		Short: "Counts the business days between two dates (end date excluded)",
+		Example: heredoc.Doc(`
+			canivete datetime workdays between --from 2022-12-01 --to 2023-01-01 -c pt
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cal, err := getCalendar(cmd)
+			if err != nil {
+				return err
+			}
+			now := clock.Now()
+			from, err := getDateFlag(cmd, flagFrom, now)
+			if err != nil {
+				return err
+			}
+			to, err := getDateFlag(cmd, flagTo, now)
+			if err != nil {
+				return err
+			}
+
+			output := workdaysBetweenOutput{
+				Calendar: cal.Name,
+				From:     from.Format(dateLayout),
+				To:       to.Format(dateLayout),
+				Workdays: cal.WorkdaysBetween(from, to),
+				Holidays: []holidayOutput{},
+			}
+
+			start, end := from, to
+			if end.Before(start) {
+				start, end = end, start
+			}
+			for date := start; date.Before(end); date = date.AddDate(0, 0, 1) {
+				if cal.IsWeekend(date) {
+					output.WeekendDays++
+				} else if holiday, ok := cal.Holiday(date); ok {
+					output.Holidays = append(output.Holidays, toHolidayOutput(holiday))
+				}
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	betweenCmd.Flags().StringP(flagFrom, "f", "now", "the start date (included)")
+	betweenCmd.Flags().StringP(flagTo, "t", "", "the end date (excluded)")
+	betweenCmd.MarkFlagRequired(flagTo)
+
+	return betweenCmd
+}
+
+func newWorkdaysAddCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var addCmd = &cobra.Command{
+		Use:   "add",
+		Short: "Adds business days to a date (negative values subtract)",
+		Example: heredoc.Doc(`
+			canivete datetime workdays add --from 2022-12-23 --days 30 -c pt
+			canivete datetime workdays add --from 2022-12-23 --days -5 -c gb
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cal, err := getCalendar(cmd)
+			if err != nil {
+				return err
+			}
+			from, err := getDateFlag(cmd, flagFrom, clock.Now())
+			if err != nil {
+				return err
+			}
+			days, _ := cmd.Flags().GetInt(flagDays)
+
+			date := cal.AddWorkdays(from, days)
+			output := workdaysAddOutput{
+				Calendar: cal.Name,
+				From:     from.Format(dateLayout),
+				Days:     days,
+				Date:     date.Format(dateLayout),
+				Weekday:  date.Weekday().String(),
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	addCmd.Flags().StringP(flagFrom, "f", "now", "the start date")
+	addCmd.Flags().IntP(flagDays, "d", 0, "the number of business days")
+	addCmd.MarkFlagRequired(flagDays)
+
+	return addCmd
+}
+
+func newWorkdaysCheckCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var checkCmd = &cobra.Command{
+		Use:   "check",
+		Short: "Checks if a date is a business day",
+		Example: heredoc.Doc(`
+			canivete datetime workdays check --date 2022-06-10 -c pt
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cal, err := getCalendar(cmd)
+			if err != nil {
+				return err
+			}
+			date, err := getDateFlag(cmd, flagDate, clock.Now())
+			if err != nil {
+				return err
+			}
+
+			output := workdaysCheckOutput{
+				Calendar: cal.Name,
+				Date:     date.Format(dateLayout),
+				Weekday:  date.Weekday().String(),
+				Workday:  cal.IsWorkday(date),
+				Weekend:  cal.IsWeekend(date),
+			}
+			if holiday, ok := cal.Holiday(date); ok {
+				output.Holiday = holiday.Name
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	checkCmd.Flags().StringP(flagDate, "d", "now", "the date to check")
+
+	return checkCmd
+}
+
+func newWorkdaysHolidaysCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var holidaysCmd = &cobra.Command{
+		Use:   "holidays",
+		Short: "Lists the holidays of a year",
+		Example: heredoc.Doc(`
+			canivete datetime workdays holidays --year 2023 -c pt
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cal, err := getCalendar(cmd)
+			if err != nil {
+				return err
+			}
+			year, _ := cmd.Flags().GetInt(flagYear)
+			if year == 0 {
+				loc, err := getLocationFlag(cmd)
+				if err != nil {
+					return err
+				}
+				year = clock.Now().In(loc).Year()
+			}
+
+			output := workdaysHolidaysOutput{Calendar: cal.Name, Year: year, Holidays: []holidayOutput{}}
+			for _, holiday := range cal.Holidays(year) {
+				output.Holidays = append(output.Holidays, toHolidayOutput(holiday))
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	holidaysCmd.Flags().IntP(flagYear, "y", 0, "the year (default is the current year)")
+
+	return holidaysCmd
+}
+
+// getCalendar returns the calendar selected with the calendar flag,
+// including the custom calendars defined in the config file.
+func getCalendar(cmd *cobra.Command) (*calendar.Calendar, error) {
+	name, _ := cmd.Flags().GetString(flagCalendar)
+	return loadCalendar(name)
+}
+
+func loadCalendar(name string) (*calendar.Calendar, error) {
+	custom := map[string]calendar.Calendar{}
+	if err := viper.UnmarshalKey("calendars", &custom); err != nil {
+		return nil, fmt.Errorf("invalid calendars in the config file: %w", err)
+	}
+
+	return calendar.Get(name, custom)
+}
+
+// getLocationFlag returns the location of the tz flag.
+func getLocationFlag(cmd *cobra.Command) (*time.Location, error) {
+	tz, _ := cmd.Flags().GetString(flagTimezone)
+	return loadLocation(tz)
+}
+
+// getDateFlag parses a flag with a date, relative to now, in the timezone
+// of the tz flag and ignoring the time of the day.
+func getDateFlag(cmd *cobra.Command, name string, now time.Time) (time.Time, error) {
+	value, _ := cmd.Flags().GetString(name)
+
+	loc, err := getLocationFlag(cmd)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t, err := parseInstant(value, loc, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return calendar.Date(t), nil
+}
+
+func toHolidayOutput(holiday calendar.Holiday) holidayOutput {
+	return holidayOutput{
+		Date:     holiday.Date.Format(dateLayout),
+		Weekday:  holiday.Date.Weekday().String(),
+		Name:     holiday.Name,
+		Observed: holiday.Observed,
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWorkdaysCmd(t *testing.T) {
+	// arrange
+	iostreams, _, _, _ := iostreams.Test()
+	cmd := NewWorkdaysCmd(*iostreams)
+
+	// act
+	_, err := cmd.ExecuteC()
+
+	// assert
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "must specify a subcommand")
+}
+
+func TestWorkdaysBetweenCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewWorkdaysCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"between", "--from=2022-12-01", "--to=2023-01-01", "-c=pt"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), `"Workdays": 20`)
+	assert.Contains(t, out.String(), `"WeekendDays": 9`)
+	assert.Contains(t, out.String(), "Restoration of Independence")
+}
+
+func TestWorkdaysAddCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewWorkdaysCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"add", "--from=2022-12-23", "--days=30", "-c=pt"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), `"Date": "2023-02-03"`)
+}
+
+func TestWorkdaysCheckCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewWorkdaysCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"check", "--date=2022-06-10"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), `"Workday": false`)
+	assert.Contains(t, out.String(), `"Holiday": "Portugal Day"`)
+}
+
+func TestWorkdaysCheckCmdTimezone(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--date=2022-06-09T23:30:00Z"}, `"Date": "2022-06-09"`},
+		{[]string{"--date=2022-06-09T23:30:00Z", "--tz=Europe/Lisbon"}, `"Holiday": "Portugal Day"`},
+		{[]string{"--date=2022-06-10 00:30", "--tz=Europe/Lisbon"}, `"Date": "2022-06-10"`},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewWorkdaysCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(append([]string{"check", "-c=pt"}, tc.args...))
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.Nil(t, err, tc.args)
+		assert.Contains(t, out.String(), tc.expected, tc.args)
+	}
+}
+
+func TestWorkdaysHolidaysCmdCurrentYear(t *testing.T) {
+	// arrange: already 2023 in Lisbon
+	clock := fixedClock{now: time.Date(2022, time.December, 31, 23, 30, 0, 0, time.FixedZone("", -3600))}
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := newWorkdaysCmd(*iostreams, clock)
+
+	// act
+	cmd.SetArgs([]string{"holidays", "-c=pt", "--tz=Europe/Lisbon"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), `"Year": 2023`)
+}
+
+func TestWorkdaysHolidaysCmdWithConfigCalendar(t *testing.T) {
+	// arrange
+	viper.Set("calendars", map[string]interface{}{
+		"lisbon": map[string]interface{}{
+			"extends": "pt",
+			"rules": []interface{}{
+				map[string]interface{}{"name": "St. Anthony's Day", "type": "fixed", "month": 6, "day": 13},
+			},
+		},
+	})
+	defer viper.Set("calendars", nil)
+
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewWorkdaysCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"holidays", "--year=2023", "-c=lisbon"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), `"Date": "2023-06-13"`)
+	assert.Contains(t, out.String(), "St. Anthony's Day")
+}
+
+func TestWorkdaysCmdUnknownCalendar(t *testing.T) {
+	// arrange
+	iostreams, _, _, _ := iostreams.Test()
+	cmd := NewWorkdaysCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"check", "-c=atlantis"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "unknown calendar")
+}
@@ -0,0 +1,265 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/renato0307/canivete/pkg/zones"
+	"github.com/spf13/cobra"
+)
+
+type zoneOffsetOutput struct {
+	Abbreviation string
+	UTCOffset    string
+	DST          bool
+}
+
+type zoneTransitionOutput struct {
+	Utc    string
+	Before zoneOffsetOutput
+	After  zoneOffsetOutput
+	// Kind is gap when the local times from LocalStart to LocalEnd (excluded)
+	// don't exist and overlap when they happen twice.
+	Kind       string
+	LocalStart string
+	LocalEnd   string
+}
+
+type zoneOutput struct {
+	Zone        string
+	Country     string `json:",omitempty"`
+	Current     zoneOffsetOutput
+	Transitions []zoneTransitionOutput
+}
+
+type zoneListOutput struct {
+	Zone        string
+	Country     string
+	CountryName string
+	Comment     string `json:",omitempty"`
+	Current     zoneOffsetOutput
+}
+
+const flagCountry = "country"
+
+const zoneLocalLayout = "2006-01-02T15:04:05"
+
+// zoneMaxYears limits the number of years of a range.
+const zoneMaxYears = 100
+
+var zoneYearsRegexp = regexp.MustCompile(`^(\d{4})(?:-(\d{4}))?$`)
+
+func NewZoneCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	return newZoneCmd(iostreams, systemClock{})
+}
+
+func newZoneCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var zoneCmd = &cobra.Command{
+		Use:   "zone <IANA name>",
+		Short: "Lists the UTC offset transitions of a timezone",
+		Long: heredoc.Doc(`
+			Lists the UTC offset transitions (e.g. DST changes) of a timezone
+			in a year or range of years, with the abbreviation and offset
+			before and after each one.
+
+			Transitions that move clocks forward create a gap of local times
+			that don't exist, while the ones that move clocks back create an
+			overlap of local times that happen twice: jobs scheduled in a
+			gap don't run and jobs scheduled in an overlap can run twice.
+
+			The timezone database of the system is used, and the embedded one
+			only when the system has none.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime zone Europe/Lisbon
+			canivete datetime zone America/New_York --year 2027
+			canivete datetime zone Australia/Lord_Howe --year 2026-2028
+			canivete datetime zone list --country PT
+		`),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			years, _ := cmd.Flags().GetString(flagYear)
+
+			loc, err := loadLocation(args[0])
+			if err != nil {
+				return err
+			}
+
+			now := clock.Now()
+			from, to, err := parseZoneYears(years, now)
+			if err != nil {
+				return err
+			}
+
+			return iostreams.PrintOutput(runZone(loc, from, to, now))
+		},
+	}
+
+	zoneCmd.Flags().StringP(flagYear, "y", "", "the year or range of years (e.g. 2027 or 2027-2030), the current one by default")
+
+	zoneCmd.AddCommand(newZoneListCmd(iostreams, clock))
+
+	return zoneCmd
+}
+
+func newZoneListCmd(iostreams iostreams.IOStreams, clock clock) *cobra.Command {
+	var zoneListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "Lists the timezones of a country or of all countries",
+		Long: heredoc.Doc(`
+			Lists the timezones of a country, given its ISO 3166 code, or of
+			all countries, with their current abbreviation and offset.
+
+			The principal timezone of a country is listed first.
+		`),
+		Example: heredoc.Doc(`
+			canivete datetime zone list --country PT
+			canivete datetime zone list
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			country, _ := cmd.Flags().GetString(flagCountry)
+
+			output, err := runZoneList(country, clock.Now())
+			if err != nil {
+				return err
+			}
+
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	zoneListCmd.Flags().StringP(flagCountry, "c", "", "the ISO 3166 country code (e.g. PT)")
+
+	return zoneListCmd
+}
+
+// parseZoneYears parses a year or a range of years, returning the current
+// year when value is empty.
+func parseZoneYears(value string, now time.Time) (int, int, error) {
+	if value == "" {
+		return now.Year(), now.Year(), nil
+	}
+
+	matches := zoneYearsRegexp.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, 0, fmt.Errorf("invalid year %q", value)
+	}
+
+	from, _ := strconv.Atoi(matches[1])
+	to := from
+	if matches[2] != "" {
+		to, _ = strconv.Atoi(matches[2])
+	}
+
+	if from < 1 || to < from || to-from >= zoneMaxYears {
+		return 0, 0, fmt.Errorf("invalid year %q, must be a range of up to %d years", value, zoneMaxYears)
+	}
+
+	return from, to, nil
+}
+
+func runZone(loc *time.Location, from, to int, now time.Time) zoneOutput {
+	output := zoneOutput{
+		Zone:        loc.String(),
+		Current:     newZoneOffsetOutput(now.In(loc)),
+		Transitions: []zoneTransitionOutput{},
+	}
+	if zone, ok := zones.Find(loc.String()); ok {
+		output.Country = zone.Country
+	}
+
+	for year := from; year <= to; year++ {
+		for _, t := range zoneTransitions(loc, year) {
+			output.Transitions = append(output.Transitions, newZoneTransitionOutput(t))
+		}
+	}
+
+	return output
+}
+
+// newZoneTransitionOutput describes the transition at t, comparing the
+// offset in effect just before it with the one from t on.
+func newZoneTransitionOutput(t time.Time) zoneTransitionOutput {
+	before := t.Add(-time.Second)
+	_, offsetBefore := before.Zone()
+	_, offsetAfter := t.Zone()
+
+	output := zoneTransitionOutput{
+		Utc:    t.UTC().Format(time.RFC3339),
+		Before: newZoneOffsetOutput(before),
+		After:  newZoneOffsetOutput(t),
+	}
+
+	// local times are shown as wall clocks, without offset
+	wallBefore := t.UTC().Add(time.Duration(offsetBefore) * time.Second)
+	wallAfter := t.UTC().Add(time.Duration(offsetAfter) * time.Second)
+
+	if offsetAfter > offsetBefore {
+		output.Kind = "gap"
+		output.LocalStart = wallBefore.Format(zoneLocalLayout)
+		output.LocalEnd = wallAfter.Format(zoneLocalLayout)
+	} else {
+		output.Kind = "overlap"
+		output.LocalStart = wallAfter.Format(zoneLocalLayout)
+		output.LocalEnd = wallBefore.Format(zoneLocalLayout)
+	}
+
+	return output
+}
+
+func newZoneOffsetOutput(t time.Time) zoneOffsetOutput {
+	name, offset := t.Zone()
+	return zoneOffsetOutput{
+		Abbreviation: name,
+		UTCOffset:    formatUTCOffset(offset),
+		DST:          t.IsDST(),
+	}
+}
+
+func runZoneList(country string, now time.Time) ([]zoneListOutput, error) {
+	list := zones.All()
+	if country != "" {
+		var err error
+		if list, err = zones.ByCountry(country); err != nil {
+			return nil, err
+		}
+	}
+
+	output := []zoneListOutput{}
+	for _, zone := range list {
+		loc, err := loadLocation(zone.Name)
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, zoneListOutput{
+			Zone:        zone.Name,
+			Country:     zone.Country,
+			CountryName: zone.CountryName,
+			Comment:     zone.Comment,
+			Current:     newZoneOffsetOutput(now.In(loc)),
+		})
+	}
+
+	return output, nil
+}
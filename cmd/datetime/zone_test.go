@@ -0,0 +1,163 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package datetime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZoneCmd(t *testing.T) {
+	// arrange
+	clock := fixedClock{now: time.Date(2027, time.January, 15, 12, 0, 0, 0, time.UTC)}
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := newZoneCmd(*iostreams, clock)
+
+	// act
+	cmd.SetArgs([]string{"America/New_York"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := zoneOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	assert.Equal(t, "US", output.Country)
+	assert.Equal(t, zoneOffsetOutput{Abbreviation: "EST", UTCOffset: "UTC-05:00"}, output.Current)
+	assert.Equal(t, []zoneTransitionOutput{
+		{
+			Utc:        "2027-03-14T07:00:00Z",
+			Before:     zoneOffsetOutput{Abbreviation: "EST", UTCOffset: "UTC-05:00"},
+			After:      zoneOffsetOutput{Abbreviation: "EDT", UTCOffset: "UTC-04:00", DST: true},
+			Kind:       "gap",
+			LocalStart: "2027-03-14T02:00:00",
+			LocalEnd:   "2027-03-14T03:00:00",
+		},
+		{
+			Utc:        "2027-11-07T06:00:00Z",
+			Before:     zoneOffsetOutput{Abbreviation: "EDT", UTCOffset: "UTC-04:00", DST: true},
+			After:      zoneOffsetOutput{Abbreviation: "EST", UTCOffset: "UTC-05:00"},
+			Kind:       "overlap",
+			LocalStart: "2027-11-07T01:00:00",
+			LocalEnd:   "2027-11-07T02:00:00",
+		},
+	}, output.Transitions)
+}
+
+func TestRunZoneRange(t *testing.T) {
+	// arrange
+	lordHowe, _ := time.LoadLocation("Australia/Lord_Howe")
+	utc, _ := time.LoadLocation("UTC")
+
+	// act
+	output := runZone(lordHowe, 2026, 2027, time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC))
+	utcOutput := runZone(utc, 2026, 2027, time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC))
+
+	// assert
+	assert.Len(t, output.Transitions, 4)
+	assert.Equal(t, "2026-10-04T02:00:00", output.Transitions[1].LocalStart)
+	assert.Equal(t, "2026-10-04T02:30:00", output.Transitions[1].LocalEnd)
+	assert.Empty(t, utcOutput.Transitions)
+	assert.Empty(t, utcOutput.Country)
+}
+
+func TestParseZoneYears(t *testing.T) {
+	testCases := []struct {
+		value    string
+		from     int
+		to       int
+		expected string
+	}{
+		{"", 2026, 2026, ""},
+		{"2027", 2027, 2027, ""},
+		{"2027-2030", 2027, 2030, ""},
+		{"2030-2027", 0, 0, `invalid year "2030-2027", must be a range of up to 100 years`},
+		{"2000-2100", 0, 0, `invalid year "2000-2100", must be a range of up to 100 years`},
+		{"27", 0, 0, `invalid year "27"`},
+	}
+
+	for _, tc := range testCases {
+		// act
+		from, to, err := parseZoneYears(tc.value, time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC))
+
+		// assert
+		if tc.expected != "" {
+			assert.EqualError(t, err, tc.expected, tc.value)
+			continue
+		}
+		assert.Nil(t, err, tc.value)
+		assert.Equal(t, tc.from, from, tc.value)
+		assert.Equal(t, tc.to, to, tc.value)
+	}
+}
+
+func TestZoneListCmd(t *testing.T) {
+	// arrange
+	clock := fixedClock{now: time.Date(2027, time.July, 1, 12, 0, 0, 0, time.UTC)}
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := newZoneCmd(*iostreams, clock)
+
+	// act
+	cmd.SetArgs([]string{"list", "--country=pt"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := []zoneListOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	assert.Len(t, output, 3)
+	assert.Equal(t, zoneListOutput{
+		Zone:        "Europe/Lisbon",
+		Country:     "PT",
+		CountryName: "Portugal",
+		Comment:     "Portugal (mainland)",
+		Current:     zoneOffsetOutput{Abbreviation: "WEST", UTCOffset: "UTC+01:00", DST: true},
+	}, output[0])
+}
+
+func TestZoneListCmdUnknownCountry(t *testing.T) {
+	// arrange
+	iostreams, _, _, _ := iostreams.Test()
+	cmd := NewZoneCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"list", "--country=XX"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	assert.EqualError(t, err, `unknown country "XX"`)
+}
+
+func TestZoneCmdInvalidTimezone(t *testing.T) {
+	// arrange
+	iostreams, _, _, _ := iostreams.Test()
+	cmd := NewZoneCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"Europe/Atlantis"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	assert.EqualError(t, err, `invalid timezone "Europe/Atlantis"`)
+}
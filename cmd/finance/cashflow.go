@@ -0,0 +1,426 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/decimal"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type cashflowOutput struct {
+	Flows int
+	// the rates are percentages, per period or, for dated flows, per year
+	DiscountRate decimal.Decimal
+	NPV          decimal.Decimal
+	IRR          *decimal.Decimal `json:",omitempty"`
+	XIRR         *decimal.Decimal `json:",omitempty"`
+	MIRR         decimal.Decimal
+	// the payback periods are in periods or, for dated flows, in years,
+	// and are missing when the flows never pay back
+	PaybackPeriod           *decimal.Decimal `json:",omitempty"`
+	DiscountedPaybackPeriod *decimal.Decimal `json:",omitempty"`
+}
+
+const flagFlow = "flow"
+const flagFile = "file"
+const flagDiscountRate = "discount-rate"
+const flagFinanceRate = "finance-rate"
+const flagReinvestRate = "reinvest-rate"
+
+// daysPerYear is the days of a year of dated flows, as in spreadsheets.
+const daysPerYear = 365
+
+// cashFlow is an amount received, or paid if negative, in a date or, for
+// periodic flows, in a period.
+type cashFlow struct {
+	date   time.Time
+	amount decimal.Decimal
+}
+
+func NewCashflowCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	var cashflowCmd = &cobra.Command{
+		Use:   "cashflow",
+		Short: "Analyses cash flows with NPV, IRR, XIRR, MIRR and payback periods",
+		Long: heredoc.Doc(`
+			Analyses cash flows, calculating the net present value (NPV) at
+			a discount rate, the internal rate of return (IRR), the modified
+			internal rate of return (MIRR) and the payback and discounted
+			payback periods.
+
+			The flows are amounts received, or paid if negative, either
+			periodic (e.g. -1000) or dated (e.g. 2026-01-15:-1000). Periodic
+			flows start at period 0, which isn't discounted. Dated flows are
+			discounted by the years since the first one, of 365 days, and
+			have an XIRR instead of an IRR.
+
+			The flows are given with flags or read from a CSV file, or from
+			the standard input, with amount or date,amount lines and an
+			optional header.
+
+			The IRR is found with the Newton-Raphson method, falling back to
+			bisection. The MIRR finances the payments at the finance rate and
+			reinvests the receipts at the reinvestment rate, both the
+			discount rate by default.
+		`),
+		Example: heredoc.Doc(`
+			canivete finance cashflow -r 8 --flow -1000 --flow 300 --flow 400 --flow 500
+			canivete finance cashflow -r 8 --flow 2026-01-01:-10000 --flow 2026-06-30:2750 --flow 2027-03-15:8500
+			canivete finance cashflow -r 8 --file flows.csv
+			cat flows.csv | canivete finance cashflow -r 8
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flowValues, _ := cmd.Flags().GetStringArray(flagFlow)
+			file, _ := cmd.Flags().GetString(flagFile)
+
+			rates, err := getFlagDecimals(cmd, flagDiscountRate, flagFinanceRate, flagReinvestRate)
+			if err != nil {
+				return err
+			}
+			discountRate, financeRate, reinvestRate := rates[0], rates[1], rates[2]
+			if !cmd.Flags().Changed(flagFinanceRate) {
+				financeRate = discountRate
+			}
+			if !cmd.Flags().Changed(flagReinvestRate) {
+				reinvestRate = discountRate
+			}
+
+			for _, rate := range []decimal.Decimal{discountRate, financeRate, reinvestRate} {
+				if rate.Cmp(decimal.Hundred.Neg()) <= 0 {
+					return fmt.Errorf("the discount, finance and reinvest rates must be greater than -100")
+				}
+			}
+			if len(flowValues) > 0 && file != "" {
+				return fmt.Errorf("the flows can't be given with flags and a file")
+			}
+
+			var flows []cashFlow
+			switch {
+			case len(flowValues) > 0:
+				flows, err = parseCashFlows(flowValues)
+			case file != "" && file != "-":
+				var f *os.File
+				f, err = os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				flows, err = readCashFlows(f)
+			default:
+				flows, err = readCashFlows(iostreams.In)
+			}
+			if err != nil {
+				return err
+			}
+
+			output, err := analyseCashFlows(flows, discountRate, financeRate, reinvestRate)
+			if err != nil {
+				return err
+			}
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	cashflowCmd.Flags().StringArray(flagFlow, []string{}, "a periodic (e.g. -1000) or dated (e.g. 2026-01-15:-1000) cash flow")
+	cashflowCmd.Flags().StringP(flagFile, "f", "", "a CSV file with the cash flows, or - for the standard input")
+	cashflowCmd.Flags().StringP(flagDiscountRate, "r", "", "the discount rate, per period or per year for dated flows (decimal, percentage)")
+	cashflowCmd.Flags().String(flagFinanceRate, "", "the rate of the financing of the payments, for the MIRR (decimal, percentage)")
+	cashflowCmd.Flags().String(flagReinvestRate, "", "the rate of the reinvestment of the receipts, for the MIRR (decimal, percentage)")
+
+	return cashflowCmd
+}
+
+// parseCashFlows returns the cash flows of values like -1000 or
+// 2026-01-15:-1000.
+func parseCashFlows(values []string) ([]cashFlow, error) {
+	flows := []cashFlow{}
+	for _, value := range values {
+		fields := strings.SplitN(value, ":", 2)
+		flow, err := newCashFlow(fields)
+		if err != nil {
+			return nil, err
+		}
+		flows = append(flows, flow)
+	}
+	return flows, nil
+}
+
+// readCashFlows reads cash flows from CSV lines with amount or date,amount
+// fields, skipping a first line without amounts or dates as the header.
+func readCashFlows(r io.Reader) ([]cashFlow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	flows := []cashFlow{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if line == 1 && isCashFlowHeader(record) {
+			continue
+		}
+		flow, err := newCashFlow(record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		flows = append(flows, flow)
+	}
+	return flows, nil
+}
+
+// isCashFlowHeader tells if a CSV record is a header, with no amount or
+// date fields.
+func isCashFlowHeader(record []string) bool {
+	for _, field := range record {
+		field = strings.TrimSpace(field)
+		if _, err := decimal.Parse(field); err == nil {
+			return false
+		}
+		if _, err := time.Parse("2006-01-02", field); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// newCashFlow returns the cash flow of an amount, or of a date and an
+// amount.
+func newCashFlow(fields []string) (cashFlow, error) {
+	if len(fields) < 1 || len(fields) > 2 {
+		return cashFlow{}, fmt.Errorf("invalid cash flow %q, must be amount or date:amount", strings.Join(fields, ","))
+	}
+
+	flow := cashFlow{}
+	amount := fields[0]
+	if len(fields) == 2 {
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(fields[0]))
+		if err != nil {
+			return cashFlow{}, fmt.Errorf("invalid cash flow date %q, must be like 2026-01-15", fields[0])
+		}
+		flow.date = date
+		amount = fields[1]
+	}
+
+	value, err := decimal.Parse(strings.TrimSpace(amount))
+	if err != nil {
+		return cashFlow{}, fmt.Errorf("invalid cash flow amount %q", amount)
+	}
+	flow.amount = value
+
+	return flow, nil
+}
+
+// analyseCashFlows returns the analysis of cash flows, with the rates as
+// percentages.
+func analyseCashFlows(flows []cashFlow, discountRate, financeRate, reinvestRate decimal.Decimal) (cashflowOutput, error) {
+	if len(flows) < 2 {
+		return cashflowOutput{}, fmt.Errorf("at least two cash flows are needed")
+	}
+
+	dated := !flows[0].date.IsZero()
+	positive, negative := false, false
+	for _, flow := range flows {
+		if flow.date.IsZero() == dated {
+			return cashflowOutput{}, fmt.Errorf("the cash flows must be all periodic or all dated")
+		}
+		positive = positive || flow.amount.Sign() > 0
+		negative = negative || flow.amount.Sign() < 0
+	}
+	if !positive || !negative {
+		return cashflowOutput{}, fmt.Errorf("the cash flows must have positive and negative amounts")
+	}
+
+	// the times of the flows, in periods or years
+	times := make([]float64, len(flows))
+	if dated {
+		sort.SliceStable(flows, func(i, j int) bool {
+			return flows[i].date.Before(flows[j].date)
+		})
+		for i, flow := range flows {
+			times[i] = flow.date.Sub(flows[0].date).Hours() / 24 / daysPerYear
+		}
+	} else {
+		for i := range flows {
+			times[i] = float64(i)
+		}
+	}
+
+	if times[len(times)-1] == 0 {
+		return cashflowOutput{}, fmt.Errorf("the cash flows must span more than one date")
+	}
+
+	r := discountRate.Div(decimal.Hundred)
+	npv, err := netPresentValue(flows, times, r, dated)
+	if err != nil {
+		return cashflowOutput{}, err
+	}
+	output := cashflowOutput{
+		Flows:        len(flows),
+		DiscountRate: discountRate,
+		NPV:          npv.Round(2, decimal.HalfEven),
+	}
+
+	irr, err := internalRateOfReturn(flows, times)
+	if err != nil {
+		return cashflowOutput{}, err
+	}
+	irrPercent := toPercent(irr)
+	if dated {
+		output.XIRR = &irrPercent
+	} else {
+		output.IRR = &irrPercent
+	}
+
+	mirr := modifiedInternalRateOfReturn(flows, times, financeRate.Div(decimal.Hundred).Float64(), reinvestRate.Div(decimal.Hundred).Float64())
+	output.MIRR = toPercent(mirr)
+
+	discounted := make([]decimal.Decimal, len(flows))
+	undiscounted := make([]decimal.Decimal, len(flows))
+	for i, flow := range flows {
+		undiscounted[i] = flow.amount
+		// the flows were already discounted by the net present value
+		discounted[i], _ = discount(flow.amount, r, times[i], dated)
+	}
+	output.PaybackPeriod = paybackPeriod(undiscounted, times)
+	output.DiscountedPaybackPeriod = paybackPeriod(discounted, times)
+
+	return output, nil
+}
+
+// netPresentValue returns the sum of the flows discounted to the time of
+// the first one.
+func netPresentValue(flows []cashFlow, times []float64, rate decimal.Decimal, dated bool) (decimal.Decimal, error) {
+	npv := decimal.Zero
+	for i, flow := range flows {
+		value, err := discount(flow.amount, rate, times[i], dated)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		npv = npv.Add(value)
+	}
+	return npv, nil
+}
+
+// discount returns the present value of an amount at a time. Periodic flows
+// are discounted exactly, as their times are whole periods, while dated
+// flows can overflow.
+func discount(amount, rate decimal.Decimal, t float64, dated bool) (decimal.Decimal, error) {
+	if !dated {
+		return amount.Mul(decimal.One.Add(rate).Pow(-int(t))), nil
+	}
+	value := amount.Float64() / math.Pow(1+rate.Float64(), t)
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return decimal.Zero, fmt.Errorf("the discounted value of %s is too large, try a higher discount-rate", amount)
+	}
+	return decimal.NewFromFloat(value), nil
+}
+
+// internalRateOfReturn returns the rate that makes the net present value of
+// the flows zero.
+func internalRateOfReturn(flows []cashFlow, times []float64) (float64, error) {
+	amounts := make([]float64, len(flows))
+	for i, flow := range flows {
+		amounts[i] = flow.amount.Float64()
+	}
+
+	npv := func(rate float64) float64 {
+		total := 0.0
+		for i, amount := range amounts {
+			total += amount / math.Pow(1+rate, times[i])
+		}
+		return total
+	}
+	dnpv := func(rate float64) float64 {
+		total := 0.0
+		for i, amount := range amounts {
+			total -= times[i] * amount / math.Pow(1+rate, times[i]+1)
+		}
+		return total
+	}
+
+	rate, err := findRoot(npv, dnpv, 0.1, -0.999999, 1e6)
+	if err != nil {
+		return 0, fmt.Errorf("the cash flows have no internal rate of return")
+	}
+	return rate, nil
+}
+
+// modifiedInternalRateOfReturn returns the rate that grows the payments,
+// discounted at the finance rate, to the receipts, compounded to the time
+// of the last flow at the reinvestment rate.
+func modifiedInternalRateOfReturn(flows []cashFlow, times []float64, financeRate, reinvestRate float64) float64 {
+	end := times[len(times)-1]
+
+	payments, receipts := 0.0, 0.0
+	for i, flow := range flows {
+		amount := flow.amount.Float64()
+		if amount < 0 {
+			payments += amount / math.Pow(1+financeRate, times[i])
+		} else {
+			receipts += amount * math.Pow(1+reinvestRate, end-times[i])
+		}
+	}
+
+	return math.Pow(receipts/-payments, 1/end) - 1
+}
+
+// paybackPeriod returns the time when the cumulative amounts become, for
+// good, non-negative, interpolated linearly in the period where it happens,
+// or nil if it never happens.
+func paybackPeriod(amounts []decimal.Decimal, times []float64) *decimal.Decimal {
+	var payback *decimal.Decimal
+	cumulative := decimal.Zero
+	for i, amount := range amounts {
+		previous := cumulative
+		cumulative = cumulative.Add(amount)
+		switch {
+		case cumulative.Sign() < 0:
+			payback = nil
+		case i == 0:
+			value := decimal.Zero
+			payback = &value
+		case previous.Sign() < 0:
+			fraction := previous.Neg().Div(amount)
+			span := decimal.NewFromFloat(times[i] - times[i-1])
+			value := decimal.NewFromFloat(times[i-1]).Add(fraction.Mul(span)).Round(2, decimal.HalfEven)
+			payback = &value
+		}
+	}
+	return payback
+}
+
+// toPercent returns a rate as a percentage with 4 decimal places.
+func toPercent(rate float64) decimal.Decimal {
+	return decimal.NewFromFloat(rate*100).Round(4, decimal.HalfEven)
+}
@@ -0,0 +1,196 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/renato0307/canivete/pkg/decimal"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCashflowCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewCashflowCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"-r=8", "--flow=-1000", "--flow=300", "--flow=400", "--flow=500"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+		"Flows": 4,
+		"DiscountRate": 8,
+		"NPV": 17.63,
+		"IRR": 8.8963,
+		"MIRR": 8.631,
+		"PaybackPeriod": 2.6,
+		"DiscountedPaybackPeriod": 2.96
+	}`
+	assert.JSONEq(t, expected, out.String())
+}
+
+func TestCashflowCmdDatedFromStdin(t *testing.T) {
+	// arrange
+	iostreams, in, out, _ := iostreams.Test()
+	cmd := NewCashflowCmd(*iostreams)
+	in.WriteString(strings.Join([]string{
+		"date,amount",
+		"2008-01-01,-10000",
+		"2008-03-01,2750",
+		"2008-10-30,4250",
+		"2009-02-15,3250",
+		"2009-04-01,2750",
+	}, "\n"))
+
+	// act
+	cmd.SetArgs([]string{"-r=9"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+		"Flows": 5,
+		"DiscountRate": 9,
+		"NPV": 2086.65,
+		"XIRR": 37.3363,
+		"MIRR": 26.8549,
+		"PaybackPeriod": 1.1,
+		"DiscountedPaybackPeriod": 1.15
+	}`
+	assert.JSONEq(t, expected, out.String())
+}
+
+func TestCashflowCmdFile(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewCashflowCmd(*iostreams)
+	path := writeIndexFile(t, "flows.csv", "-1000\n300\n400\n500\n")
+
+	// act
+	cmd.SetArgs([]string{"-r=8", "--reinvest-rate=5", "--file=" + path})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), `"IRR": 8.8963`)
+	assert.Contains(t, out.String(), `"MIRR": 7.7433`)
+}
+
+func TestPaybackPeriod(t *testing.T) {
+	testCases := []struct {
+		amounts  []string
+		expected string
+	}{
+		{[]string{"-1000", "500", "500"}, "2"},
+		{[]string{"-1000", "400", "400", "400"}, "2.5"},
+		{[]string{"100", "-50", "100"}, "0"},
+		{[]string{"-1000", "1500", "-1000", "1000"}, "2.5"},
+		{[]string{"-1000", "400", "400"}, "never"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		amounts := []decimal.Decimal{}
+		times := []float64{}
+		for i, amount := range tc.amounts {
+			value, _ := decimal.Parse(amount)
+			amounts = append(amounts, value)
+			times = append(times, float64(i))
+		}
+
+		// act
+		payback := paybackPeriod(amounts, times)
+
+		// assert
+		actual := "never"
+		if payback != nil {
+			actual = payback.String()
+		}
+		assert.Equal(t, tc.expected, actual, tc.amounts)
+	}
+}
+
+func TestCashflowCmdErrors(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--flow=-1000"}, "at least two cash flows are needed"},
+		{[]string{"--flow=-1000", "--flow=-100"}, "the cash flows must have positive and negative amounts"},
+		{[]string{"--flow=100", "--flow=-50", "--flow=100"}, "the cash flows have no internal rate of return"},
+		{[]string{"--flow=-1000", "--flow=2026-01-15:1100"}, "the cash flows must be all periodic or all dated"},
+		{[]string{"--flow=2026-01-15:-1000", "--flow=2026-01-15:1100"}, "the cash flows must span more than one date"},
+		{[]string{"--flow=-1000", "--flow=abc"}, `invalid cash flow amount "abc"`},
+		{[]string{"--flow=-1000", "--flow=15/01/2026:1100"}, `invalid cash flow date "15/01/2026", must be like 2026-01-15`},
+		{[]string{"--flow=-1000", "--file=flows.csv"}, "the flows can't be given with flags and a file"},
+		{[]string{"-r=-100", "--flow=-1000", "--flow=300", "--flow=800"}, "the discount, finance and reinvest rates must be greater than -100"},
+		{[]string{"--finance-rate=-120", "--flow=-1000", "--flow=1100"}, "the discount, finance and reinvest rates must be greater than -100"},
+		{[]string{"--reinvest-rate=-100", "--flow=-1000", "--flow=1100"}, "the discount, finance and reinvest rates must be greater than -100"},
+		{[]string{"-r=eight", "--flow=-1000", "--flow=1100"}, `invalid discount-rate "eight", must be a number`},
+		{[]string{"-r=-99.99", "--flow=2000-01-01:-10", "--flow=2100-01-01:1"}, "the discounted value of 1 is too large, try a higher discount-rate"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewCashflowCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(tc.args)
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.EqualError(t, err, tc.expected, tc.args)
+	}
+}
+
+func TestReadCashFlowsErrors(t *testing.T) {
+	// act
+	_, err := readCashFlows(strings.NewReader("amount\n-1000\n2026-01-15,1,2\n"))
+
+	// assert
+	assert.EqualError(t, err, `line 3: invalid cash flow "2026-01-15,1,2", must be amount or date:amount`)
+}
+
+func TestReadCashFlowsInvalidFirstLine(t *testing.T) {
+	testCases := []struct {
+		content  string
+		expected string
+	}{
+		{"2026-01-15,abc\n2026-06-30,300\n", `line 1: invalid cash flow amount "abc"`},
+		{"15/01/2026,-1000\n2026-06-30,300\n", `line 1: invalid cash flow date "15/01/2026", must be like 2026-01-15`},
+	}
+
+	for _, tc := range testCases {
+		// act
+		_, err := readCashFlows(strings.NewReader(tc.content))
+
+		// assert
+		assert.EqualError(t, err, tc.expected, tc.content)
+	}
+}
@@ -19,21 +19,40 @@ package finance
 import (
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 
 	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/decimal"
 	"github.com/renato0307/canivete/pkg/iostreams"
 	"github.com/spf13/cobra"
 )
 
 type compoundInterestsDetailOutput struct {
-	FinalAmount        float64
-	TotalContributions float64
-	Interests          float64
+	FinalAmount        decimal.Decimal
+	TotalContributions decimal.Decimal
+	// Interests are the gross interests earned, before fees and taxes
+	Interests decimal.Decimal
+	// NetGain is the final amount less the total contributions, which is
+	// the interests net of fees and taxes
+	NetGain  decimal.Decimal
+	FeesPaid decimal.Decimal
+	TaxPaid  decimal.Decimal
+	// NetAmount is the final amount after the gains tax due on withdrawal
+	NetAmount decimal.Decimal
+	// RealAmount is the net amount in today's money, adjusted for inflation
+	RealAmount decimal.Decimal
 }
 
 type compoundInterestsHistoryEntryOutput struct {
-	Period string
-	Totals compoundInterestsDetailOutput
+	Period         string
+	OpeningBalance decimal.Decimal
+	Contributions  decimal.Decimal
+	Interests      decimal.Decimal
+	Fees           decimal.Decimal
+	Taxes          decimal.Decimal
+	ClosingBalance decimal.Decimal
+	Totals         compoundInterestsDetailOutput
 }
 
 type compoundInterestsOutput struct {
@@ -47,6 +66,72 @@ const flagTime = "time"
 const flagRegularContributions = "regular-contributions"
 const flagRegularContributionsPeriod = "regular-contributions-period"
 const flagAnnualInterestRate = "annual-interest-rate"
+const flagRounding = "rounding"
+const flagGranularity = "granularity"
+const flagContributionTiming = "contribution-timing"
+const flagContributionGrowth = "contribution-growth"
+const flagLumpSum = "lump-sum"
+const flagInflation = "inflation"
+const flagAnnualFee = "annual-fee"
+const flagEntryFee = "entry-fee"
+const flagGainsTax = "gains-tax"
+const flagGainsTaxTiming = "gains-tax-timing"
+
+// granularities are the units of the history rows, with their number per
+// year. The compounding period has 0, as its number is given by the user.
+var granularities = map[string]int{"period": 0, "month": 12, "quarter": 4, "year": 1}
+
+// balancePlaces is the number of decimal places kept in the balances, so
+// that long schedules don't accumulate huge fractions.
+const balancePlaces = 16
+
+// compoundInterestsParams are the parameters of an investment.
+type compoundInterestsParams struct {
+	principal            decimal.Decimal
+	periodsPerYear       int
+	years                decimal.Decimal
+	contribution         decimal.Decimal
+	contributionsPerYear int
+	// contributeAtBegin adds the contributions at the beginning of the
+	// periods (an annuity-due) instead of at the end
+	contributeAtBegin bool
+	// contributionGrowth is the annual growth of the contributions, as a
+	// fraction
+	contributionGrowth decimal.Decimal
+	// lumpSums are the deposits (or withdrawals, if negative) by period
+	lumpSums map[int]decimal.Decimal
+	// rate is the annual interest rate, as a fraction (e.g. 0.05)
+	rate decimal.Decimal
+	// inflation, annualFee, entryFee and gainsTax are fractions
+	inflation decimal.Decimal
+	annualFee decimal.Decimal
+	entryFee  decimal.Decimal
+	gainsTax  decimal.Decimal
+	// gainsTaxYearly taxes the gains of each year, instead of the gains
+	// on withdrawal
+	gainsTaxYearly bool
+	rounding       decimal.RoundingMode
+}
+
+// compoundInterestsPeriod is a compounding period of a schedule, with
+// unrounded amounts.
+type compoundInterestsPeriod struct {
+	number int
+	// end is the number of periods since the start, which is fractional
+	// for a final partial period
+	end          decimal.Decimal
+	opening      decimal.Decimal
+	contribution decimal.Decimal
+	interest     decimal.Decimal
+	fee          decimal.Decimal
+	tax          decimal.Decimal
+	closing      decimal.Decimal
+	// the totals since the start, with the principal
+	totalContributions decimal.Decimal
+	totalInterests     decimal.Decimal
+	totalFees          decimal.Decimal
+	totalTaxes         decimal.Decimal
+}
 
 func NewCompoundInterestsCmd(iostreams iostreams.IOStreams) *cobra.Command {
 
@@ -57,10 +142,30 @@ func NewCompoundInterestsCmd(iostreams iostreams.IOStreams) *cobra.Command {
 		Long: heredoc.Doc(`
 			Calculates compound interests.
 	
-			The formula for compound interests is a = p*((1+r/n)^(n * t))
-			With different periodic payments an extra is needed:
+			The balance is calculated period by period, adding the interests
+			r/n of the balance and the contributions m * (y/n) at the end of
+			each compounding period. Without partial periods this equals the
+			formula a = p*((1+r/n)^(n * t)) plus, for regular contributions,
 				a_series = m * (y/n) {[(1 + r/n)^(n * t) - 1] / (r/n)}
-				total = a + a_series
+
+			When n * t is fractional, the last period is partial and earns
+			simple interests and contributions for its fraction.
+
+			With the begin contribution timing (an annuity-due) the
+			contributions are added at the beginning of each period, earning
+			its interests. The contributions can grow every year, e.g. 3%
+			with the salary, and lump sums can be deposited or withdrawn
+			(negative amounts) in given compounding periods, with the same
+			timing of the contributions.
+
+			The entry fee is charged on each deposit, including the principal,
+			and the annual fee (e.g. a management fee or TER) on the balance
+			of each period. The gains tax is charged on the gains of each
+			year, or on the gains when the amount is withdrawn at the end.
+			The interests are always gross, before fees and taxes, while the
+			net gain is the final amount less the contributions. The real
+			amount is the net amount in today's money, given the annual
+			inflation.
 	
 			Where:
 				a = the future value of the investment/loan, including interest
@@ -70,119 +175,478 @@ func NewCompoundInterestsCmd(iostreams iostreams.IOStreams) *cobra.Command {
 				t = the time the money is invested or borrowed for
 				m = the regular contribution
 				y = regular contributions in the compounded period
+
+			The calculations are exact and the amounts are rounded to cents
+			using the rounding mode: half-even (banker's rounding), half-up or
+			truncate.
+
+			The history has a row per year, or per compounding period, month
+			or quarter with the granularity. Each row aggregates the
+			compounding periods ending in it.
 		`),
 		Example: heredoc.Doc(`
 			canivete finance compoundinterests -t 10 -p 1000 -r 5 -n 1
 			canivete finance compoundinterests -t 25 -p 15000 -r 5 -n 1 -m 400 -y 12
+			canivete finance compoundinterests -t 5 -p 1500.50 -r 3.25 -n 12 --rounding half-up
+			canivete finance compoundinterests -t 1.5 -p 1000 -r 4 -n 12 -m 50 -y 12 --granularity month
+			canivete finance compoundinterests -t 20 -p 0 -r 6 -n 12 -m 200 --contribution-timing begin --contribution-growth 3
+			canivete finance compoundinterests -t 10 -p 5000 -r 5 -n 1 --lump-sum 3:2000 --lump-sum 8:-1500
+			canivete finance compoundinterests -t 30 -p 10000 -r 7 -n 12 -m 300 --annual-fee 0.2 --gains-tax 28 --inflation 2
 		`),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			p, _ := getFlagIntAsFloat64(cmd, flagInvestAmount)
-			n, _ := getFlagIntAsFloat64(cmd, flagCompoundPeriods)
-			t, _ := getFlagIntAsFloat64(cmd, flagTime)
-			m, _ := getFlagIntAsFloat64(cmd, flagRegularContributions)
-			y, _ := getFlagIntAsFloat64(cmd, flagRegularContributionsPeriod)
-			rint, _ := cmd.Flags().GetFloat64(flagAnnualInterestRate)
-
-			if m > 0 && y == 0 {
-				return fmt.Errorf("the regular-contributions-period cannot be zero")
+			granularity, _ := cmd.Flags().GetString(flagGranularity)
+
+			params, err := getCompoundInterestsParams(cmd)
+			if err != nil {
+				return err
+			}
+			if err := checkLumpSums(params); err != nil {
+				return err
+			}
+
+			unitsPerYear, ok := granularities[granularity]
+			if !ok {
+				return fmt.Errorf("invalid granularity %q, must be period, month, quarter or year", granularity)
+			}
+			if unitsPerYear == 0 {
+				unitsPerYear = params.periodsPerYear
 			}
 
-			output := run(p, n, t, m, y, rint)
-			err := iostreams.PrintOutput(output)
+			output := run(params, unitsPerYear)
+			err = iostreams.PrintOutput(output)
 
 			return err
 		},
 	}
 
 	// Command flags
-	compoundInterestsCmd.Flags().IntP(
+	addCompoundInterestsFlags(compoundInterestsCmd)
+	compoundInterestsCmd.MarkFlagRequired(flagInvestAmount)
+	compoundInterestsCmd.MarkFlagRequired(flagAnnualInterestRate)
+	compoundInterestsCmd.MarkFlagRequired(flagCompoundPeriods)
+	compoundInterestsCmd.MarkFlagRequired(flagTime)
+
+	compoundInterestsCmd.Flags().String(
+		flagGranularity,
+		"year",
+		"the unit of the history rows: period, month, quarter or year")
+
+	return compoundInterestsCmd
+}
+
+// addCompoundInterestsFlags adds the flags of the parameters of an
+// investment to a command.
+func addCompoundInterestsFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(
 		flagInvestAmount,
 		"p",
-		0,
+		"",
 		"the principal investment amount (the initial deposit or loan amount)")
-	compoundInterestsCmd.MarkFlagRequired(flagInvestAmount)
 
-	compoundInterestsCmd.Flags().Float64P(
+	cmd.Flags().StringP(
 		flagAnnualInterestRate,
 		"r",
-		0,
+		"",
 		"the annual interest rate (decimal, percentage)")
-	compoundInterestsCmd.MarkFlagRequired(flagAnnualInterestRate)
 
-	compoundInterestsCmd.Flags().IntP(
+	cmd.Flags().IntP(
 		flagCompoundPeriods,
 		"n",
 		0,
 		"number of times interest compounds, i.e. 12 = monthly, 4 = quarterly, 2 = semi-annually, 1 = annually")
-	compoundInterestsCmd.MarkFlagRequired(flagCompoundPeriods)
 
-	compoundInterestsCmd.Flags().IntP(
+	cmd.Flags().StringP(
 		flagTime,
 		"t",
-		0,
-		"the time the money is invested or borrowed for (e.g. 10 years)")
-	compoundInterestsCmd.MarkFlagRequired(flagTime)
+		"",
+		"the time the money is invested or borrowed for (e.g. 10 or 2.5 years)")
 
-	compoundInterestsCmd.Flags().IntP(
+	cmd.Flags().StringP(
 		flagRegularContributions,
 		"m",
-		0,
+		"",
 		"regular contributions (additional money added to investment)")
 
-	compoundInterestsCmd.Flags().IntP(
+	cmd.Flags().IntP(
 		flagRegularContributionsPeriod,
 		"y",
 		12,
 		"regular contributions in the compounded period (e.g. 12 if every month in a year)")
 
-	return compoundInterestsCmd
+	cmd.Flags().String(
+		flagRounding,
+		decimal.HalfEven.String(),
+		"how the amounts are rounded to cents: half-even, half-up or truncate")
+
+	cmd.Flags().String(
+		flagContributionTiming,
+		"end",
+		"when the contributions are added to the periods: begin or end")
+
+	cmd.Flags().String(
+		flagContributionGrowth,
+		"",
+		"the annual growth of the regular contributions (decimal, percentage)")
+
+	cmd.Flags().StringArray(
+		flagLumpSum,
+		[]string{},
+		"a deposit, or a withdrawal if negative, in a compounding period (e.g. 12:5000)")
+
+	cmd.Flags().String(
+		flagInflation,
+		"",
+		"the annual inflation, for the real amounts (decimal, percentage)")
+
+	cmd.Flags().String(
+		flagAnnualFee,
+		"",
+		"the annual fee on the balance, e.g. a management fee (decimal, percentage)")
+
+	cmd.Flags().String(
+		flagEntryFee,
+		"",
+		"the fee on each deposit (decimal, percentage)")
+
+	cmd.Flags().String(
+		flagGainsTax,
+		"",
+		"the tax on the gains (decimal, percentage)")
+
+	cmd.Flags().String(
+		flagGainsTaxTiming,
+		"withdrawal",
+		"when the gains are taxed: withdrawal or yearly")
+}
+
+// getCompoundInterestsParams returns the parameters of an investment given
+// by the flags.
+func getCompoundInterestsParams(cmd *cobra.Command) (compoundInterestsParams, error) {
+	n, _ := cmd.Flags().GetInt(flagCompoundPeriods)
+	y, _ := cmd.Flags().GetInt(flagRegularContributionsPeriod)
+	roundingName, _ := cmd.Flags().GetString(flagRounding)
+	timing, _ := cmd.Flags().GetString(flagContributionTiming)
+	lumpSumValues, _ := cmd.Flags().GetStringArray(flagLumpSum)
+	gainsTaxTiming, _ := cmd.Flags().GetString(flagGainsTaxTiming)
+
+	values, err := getFlagDecimals(cmd, flagInvestAmount, flagTime, flagRegularContributions,
+		flagAnnualInterestRate, flagContributionGrowth, flagInflation, flagAnnualFee, flagEntryFee, flagGainsTax)
+	if err != nil {
+		return compoundInterestsParams{}, err
+	}
+	p, t, m, rint, growth := values[0], values[1], values[2], values[3], values[4]
+	inflation, annualFee, entryFee, gainsTax := values[5], values[6], values[7], values[8]
+
+	if m.Sign() > 0 && y == 0 {
+		return compoundInterestsParams{}, fmt.Errorf("the regular-contributions-period cannot be zero")
+	}
+	if n <= 0 {
+		return compoundInterestsParams{}, fmt.Errorf("the compound-periods must be greater than zero")
+	}
+	if t.Sign() < 0 {
+		return compoundInterestsParams{}, fmt.Errorf("the time cannot be negative")
+	}
+	if timing != "begin" && timing != "end" {
+		return compoundInterestsParams{}, fmt.Errorf("invalid contribution timing %q, must be begin or end", timing)
+	}
+	if gainsTaxTiming != "withdrawal" && gainsTaxTiming != "yearly" {
+		return compoundInterestsParams{}, fmt.Errorf("invalid gains tax timing %q, must be withdrawal or yearly", gainsTaxTiming)
+	}
+	if inflation.Cmp(decimal.Hundred.Neg()) <= 0 {
+		return compoundInterestsParams{}, fmt.Errorf("the inflation must be greater than -100")
+	}
+
+	rounding, err := decimal.ParseRoundingMode(roundingName)
+	if err != nil {
+		return compoundInterestsParams{}, err
+	}
+
+	lumpSums, err := parseLumpSums(lumpSumValues)
+	if err != nil {
+		return compoundInterestsParams{}, err
+	}
+	return compoundInterestsParams{
+		principal:            p,
+		periodsPerYear:       n,
+		years:                t,
+		contribution:         m,
+		contributionsPerYear: y,
+		contributeAtBegin:    timing == "begin",
+		contributionGrowth:   growth.Div(decimal.Hundred),
+		lumpSums:             lumpSums,
+		rate:                 rint.Div(decimal.Hundred),
+		inflation:            inflation.Div(decimal.Hundred),
+		annualFee:            annualFee.Div(decimal.Hundred),
+		entryFee:             entryFee.Div(decimal.Hundred),
+		gainsTax:             gainsTax.Div(decimal.Hundred),
+		gainsTaxYearly:       gainsTaxTiming == "yearly",
+		rounding:             rounding,
+	}, nil
+}
+
+// checkLumpSums checks the lump sums of an investment are in its periods.
+func checkLumpSums(params compoundInterestsParams) error {
+	periods := params.years.Mul(decimal.NewFromInt(int64(params.periodsPerYear))).Ceil().Int64()
+	for period := range params.lumpSums {
+		if int64(period) > periods {
+			return fmt.Errorf("the lump sum period %d is after the last period (%d)", period, periods)
+		}
+	}
+	return nil
+}
+
+// parseLumpSums returns the amounts of lump sums like 12:5000 by period.
+func parseLumpSums(values []string) (map[int]decimal.Decimal, error) {
+	lumpSums := map[int]decimal.Decimal{}
+	for _, value := range values {
+		parts := strings.Split(value, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid lump sum %q, must be period:amount (e.g. 12:5000)", value)
+		}
+
+		period, err := strconv.Atoi(parts[0])
+		if err != nil || period < 1 {
+			return nil, fmt.Errorf("invalid lump sum period %q, must be a positive integer", parts[0])
+		}
+		amount, err := decimal.Parse(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid lump sum amount %q", parts[1])
+		}
+
+		lumpSums[period] = lumpSums[period].Add(amount)
+	}
+
+	return lumpSums, nil
 }
 
-func run(p, n, t, m, y, rInt float64) compoundInterestsOutput {
+// run calculates the schedule of an investment, with history rows for a
+// number of units per year (e.g. 12 for months).
+func run(params compoundInterestsParams, unitsPerYear int) compoundInterestsOutput {
 	output := compoundInterestsOutput{
-		Total:   compoundInterestsDetailOutput{},
+		Total:   newCompoundInterestsDetailOutput(params, initialPeriod(params)),
 		History: []compoundInterestsHistoryEntryOutput{},
 	}
 
-	r := rInt / 100
-
-	output.Total = calculateValues(p, n, t, m, y, r)
+	units := decimal.NewFromInt(int64(unitsPerYear))
+	n := decimal.NewFromInt(int64(params.periodsPerYear))
+
+	var entry *compoundInterestsHistoryEntryOutput
+	var row int64
+	for _, period := range schedule(params) {
+		// the row of the history unit where the period ends
+		periodRow := period.end.Mul(units).Div(n).Ceil().Int64()
+		if entry == nil || periodRow != row {
+			output.History = append(output.History, compoundInterestsHistoryEntryOutput{
+				Period:         fmt.Sprint(periodRow),
+				OpeningBalance: period.opening,
+			})
+			entry = &output.History[len(output.History)-1]
+			row = periodRow
+		}
+
+		entry.Contributions = entry.Contributions.Add(period.contribution)
+		entry.Interests = entry.Interests.Add(period.interest)
+		entry.Fees = entry.Fees.Add(period.fee)
+		entry.Taxes = entry.Taxes.Add(period.tax)
+		entry.ClosingBalance = period.closing
+		entry.Totals = newCompoundInterestsDetailOutput(params, period)
+	}
 
-	// history
-	for i := 1; i <= int(t); i++ {
-		historyEntry := compoundInterestsHistoryEntryOutput{}
-		historyEntry.Totals = calculateValues(p, n, float64(i), m, y, r)
-		historyEntry.Period = fmt.Sprint(i)
-		output.History = append(output.History, historyEntry)
+	for i := range output.History {
+		entry := &output.History[i]
+		entry.OpeningBalance = roundTwoDecimalPlaces(entry.OpeningBalance, params.rounding)
+		entry.Contributions = roundTwoDecimalPlaces(entry.Contributions, params.rounding)
+		entry.Interests = roundTwoDecimalPlaces(entry.Interests, params.rounding)
+		entry.Fees = roundTwoDecimalPlaces(entry.Fees, params.rounding)
+		entry.Taxes = roundTwoDecimalPlaces(entry.Taxes, params.rounding)
+		entry.ClosingBalance = roundTwoDecimalPlaces(entry.ClosingBalance, params.rounding)
+	}
+	if len(output.History) > 0 {
+		output.Total = output.History[len(output.History)-1].Totals
 	}
 
 	return output
 }
 
-func calculateValues(p, n, t, m, y, r float64) compoundInterestsDetailOutput {
-	// base calculation
-	a := p * math.Pow(1+r/n, n*t)
+// calculateValues returns the totals at the end of an investment.
+func calculateValues(params compoundInterestsParams) compoundInterestsDetailOutput {
+	return newCompoundInterestsDetailOutput(params, finalPeriod(params))
+}
+
+// finalPeriod returns the last period of an investment, or the initial
+// period when it has none.
+func finalPeriod(params compoundInterestsParams) compoundInterestsPeriod {
+	periods := schedule(params)
+	if len(periods) == 0 {
+		return initialPeriod(params)
+	}
+	return periods[len(periods)-1]
+}
 
-	// calculation for regular contributions
-	aseries := 0.0
-	if m > 0 {
-		aseries = m * (y / n) * ((math.Pow(1+r/n, n*t) - 1) / (r / n))
+// initialPeriod returns a period closing with the principal, before the
+// first compounding period.
+func initialPeriod(params compoundInterestsParams) compoundInterestsPeriod {
+	fee := params.principal.Mul(params.entryFee)
+	if params.principal.Sign() < 0 {
+		fee = decimal.Zero
 	}
 
-	// set output
+	return compoundInterestsPeriod{
+		contribution:       params.principal,
+		fee:                fee,
+		closing:            params.principal.Sub(fee),
+		totalContributions: params.principal,
+		totalFees:          fee,
+	}
+}
+
+// schedule calculates the balance period by period. The interests and the
+// annual fee are added at the end of each period and the contributions at the
+// beginning or at the end, as the params tell. The gains tax is charged at
+// the end of each year, when it's yearly.
+func schedule(params compoundInterestsParams) []compoundInterestsPeriod {
+	n := decimal.NewFromInt(int64(params.periodsPerYear))
+	total := params.years.Mul(n)
+	periodRate := params.rate.Div(n)
+	periodFee := params.annualFee.Div(n)
+	periodContribution := params.contribution.Mul(decimal.NewFromInt(int64(params.contributionsPerYear))).Div(n)
+	growth := decimal.One.Add(params.contributionGrowth)
+
+	periods := []compoundInterestsPeriod{}
+	previous := initialPeriod(params)
+	// the gains of the year, taxed at its end when the tax is yearly
+	gains := decimal.Zero
+	for number := 1; total.Cmp(decimal.NewFromInt(int64(number-1))) > 0; number++ {
+		// the fraction of the period, which is less than 1 for a final
+		// partial period
+		end := decimal.NewFromInt(int64(number))
+		fraction := decimal.One
+		if end.Cmp(total) > 0 {
+			end = total
+			fraction = total.Sub(decimal.NewFromInt(int64(number - 1)))
+		}
+
+		// the contributions grow once a year
+		year := (number - 1) / params.periodsPerYear
+		contribution := periodContribution.Mul(growth.Pow(year)).Mul(fraction)
+		contribution = contribution.Add(params.lumpSums[number])
+
+		period := compoundInterestsPeriod{
+			number:       number,
+			end:          end,
+			opening:      previous.closing,
+			contribution: contribution,
+		}
+
+		// the entry fee is charged on the deposits only
+		deposit := contribution
+		if contribution.Sign() > 0 {
+			period.fee = contribution.Mul(params.entryFee)
+			deposit = contribution.Sub(period.fee)
+		}
+
+		balance := previous.closing
+		if params.contributeAtBegin {
+			balance = balance.Add(deposit)
+		}
+		period.interest = balance.Mul(periodRate).Mul(fraction)
+		annualFee := balance.Mul(periodFee).Mul(fraction)
+		period.fee = period.fee.Add(annualFee)
+		balance = balance.Add(period.interest).Sub(annualFee)
+		if !params.contributeAtBegin {
+			balance = balance.Add(deposit)
+		}
+
+		gains = gains.Add(period.interest).Sub(annualFee)
+		if params.gainsTaxYearly && (number%params.periodsPerYear == 0 || end.Cmp(total) == 0) {
+			if gains.Sign() > 0 {
+				period.tax = gains.Mul(params.gainsTax)
+				balance = balance.Sub(period.tax)
+			}
+			gains = decimal.Zero
+		}
+
+		period.closing = balance.Round(balancePlaces, decimal.HalfEven)
+		period.totalContributions = previous.totalContributions.Add(contribution)
+		period.totalInterests = previous.totalInterests.Add(period.interest)
+		period.totalFees = previous.totalFees.Add(period.fee)
+		period.totalTaxes = previous.totalTaxes.Add(period.tax)
+
+		periods = append(periods, period)
+		previous = period
+	}
+
+	return periods
+}
+
+// newCompoundInterestsDetailOutput returns the rounded totals at the end of a
+// period.
+func newCompoundInterestsDetailOutput(params compoundInterestsParams, period compoundInterestsPeriod) compoundInterestsDetailOutput {
+	// the gains tax due when the amount is withdrawn
+	due := decimal.Zero
+	if !params.gainsTaxYearly {
+		gains := period.closing.Sub(period.totalContributions)
+		if gains.Sign() > 0 {
+			due = gains.Mul(params.gainsTax)
+		}
+	}
+	net := period.closing.Sub(due)
+	years := period.end.Div(decimal.NewFromInt(int64(params.periodsPerYear)))
+
 	output := compoundInterestsDetailOutput{}
-	output.FinalAmount = roundTwoDecimalPlaces(a + aseries)
-	output.TotalContributions = roundTwoDecimalPlaces(p + (m * y * t))
-	output.Interests = roundTwoDecimalPlaces(output.FinalAmount - output.TotalContributions)
+	output.FinalAmount = roundTwoDecimalPlaces(period.closing, params.rounding)
+	output.TotalContributions = roundTwoDecimalPlaces(period.totalContributions, params.rounding)
+	output.Interests = roundTwoDecimalPlaces(period.totalInterests, params.rounding)
+	output.NetGain = output.FinalAmount.Sub(output.TotalContributions)
+	output.FeesPaid = roundTwoDecimalPlaces(period.totalFees, params.rounding)
+	output.TaxPaid = roundTwoDecimalPlaces(period.totalTaxes.Add(due), params.rounding)
+	output.NetAmount = roundTwoDecimalPlaces(net, params.rounding)
+	output.RealAmount = roundTwoDecimalPlaces(deflate(net, params.inflation, years), params.rounding)
 
 	return output
 }
 
-func getFlagIntAsFloat64(cmd *cobra.Command, name string) (float64, error) {
-	valueInt, err := cmd.Flags().GetInt(name)
-	return float64(valueInt), err
+// deflate returns an amount in the money of a number of years before, given
+// the annual inflation. Fractions of a year use floats, as their factors are
+// irrational.
+func deflate(amount, inflation, years decimal.Decimal) decimal.Decimal {
+	whole := years.Floor()
+	factor := decimal.One.Add(inflation).Pow(int(whole.Int64()))
+	if fraction := years.Sub(whole); !fraction.IsZero() {
+		factor = factor.Mul(decimal.NewFromFloat(math.Pow(1+inflation.Float64(), fraction.Float64())))
+	}
+	return amount.Div(factor)
+}
+
+// getFlagDecimal returns the exact decimal of a string flag, zero when the
+// flag is empty.
+func getFlagDecimal(cmd *cobra.Command, name string) (decimal.Decimal, error) {
+	value, _ := cmd.Flags().GetString(name)
+	if value == "" {
+		return decimal.Zero, nil
+	}
+
+	d, err := decimal.Parse(value)
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("invalid %s %q, must be a number", name, value)
+	}
+	return d, nil
+}
+
+// getFlagDecimals returns the exact decimals of string flags, in the order
+// of the names, failing on the first invalid one.
+func getFlagDecimals(cmd *cobra.Command, names ...string) ([]decimal.Decimal, error) {
+	values := []decimal.Decimal{}
+	for _, name := range names {
+		value, err := getFlagDecimal(cmd, name)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
 }
 
-func roundTwoDecimalPlaces(value float64) float64 {
-	return math.Ceil(value*100) / 100
+func roundTwoDecimalPlaces(value decimal.Decimal, rounding decimal.RoundingMode) decimal.Decimal {
+	return value.Round(2, rounding)
 }
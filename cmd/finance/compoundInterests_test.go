@@ -17,8 +17,10 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package finance
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/renato0307/canivete/pkg/decimal"
 	"github.com/renato0307/canivete/pkg/iostreams"
 	"github.com/stretchr/testify/assert"
 )
@@ -41,7 +43,354 @@ func TestCompoundInterestsCmd(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	assert.Contains(t, out.String(), "1628.9")
+	assert.Contains(t, out.String(), "1628.89")
+}
+
+func TestCompoundInterestsReferenceTable(t *testing.T) {
+	// 1000 invested at 5%, compounded annually
+	expected := []string{"1050", "1102.5", "1157.62", "1215.51", "1276.28", "1340.1", "1407.1", "1477.46", "1551.33", "1628.89"}
+
+	// act
+	output := run(compoundInterestsParams{
+		principal:            decimal.NewFromInt(1000),
+		periodsPerYear:       1,
+		years:                decimal.NewFromInt(10),
+		contributionsPerYear: 12,
+		rate:                 decimal.New(5, -2),
+	}, 1)
+
+	// assert
+	assert.Len(t, output.History, len(expected))
+	for i, entry := range output.History {
+		assert.Equal(t, expected[i], entry.Totals.FinalAmount.String(), entry.Period)
+	}
+}
+
+func TestCompoundInterestsGranularityCmd(t *testing.T) {
+	testCases := []struct {
+		granularity string
+		rows        int
+		lastPeriod  string
+	}{
+		{"period", 18, "18"},
+		{"month", 18, "18"},
+		{"quarter", 6, "6"},
+		{"year", 2, "2"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewCompoundInterestsCmd(*iostreams)
+
+		// act
+		cmd.SetArgs([]string{"-t=1.5", "-p=1000", "-r=4", "-n=12", "-m=50", "--granularity=" + tc.granularity})
+		_, err := cmd.ExecuteC()
+
+		// assert
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := compoundInterestsOutput{}
+		assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+		assert.Len(t, output.History, tc.rows, tc.granularity)
+		last := output.History[len(output.History)-1]
+		assert.Equal(t, tc.lastPeriod, last.Period, tc.granularity)
+		assert.Equal(t, "1987.69", last.ClosingBalance.String(), tc.granularity)
+		assert.Equal(t, output.Total, last.Totals, tc.granularity)
+		for i := 1; i < len(output.History); i++ {
+			assert.Equal(t, output.History[i-1].ClosingBalance, output.History[i].OpeningBalance, tc.granularity)
+		}
+	}
+}
+
+func TestCompoundInterestsMonthlyHistory(t *testing.T) {
+	// act
+	output := run(compoundInterestsParams{
+		principal:            decimal.NewFromInt(1000),
+		periodsPerYear:       12,
+		years:                decimal.New(25, -2),
+		contribution:         decimal.NewFromInt(50),
+		contributionsPerYear: 12,
+		rate:                 decimal.New(4, -2),
+	}, 12)
+
+	// assert
+	first, _ := json.Marshal(output.History[0])
+	assert.JSONEq(t, `{
+		"Period": "1",
+		"OpeningBalance": 1000,
+		"Contributions": 50,
+		"Interests": 3.33,
+		"Fees": 0,
+		"Taxes": 0,
+		"ClosingBalance": 1053.33,
+		"Totals": {
+			"FinalAmount": 1053.33,
+			"TotalContributions": 1050,
+			"Interests": 3.33,
+			"NetGain": 3.33,
+			"FeesPaid": 0,
+			"TaxPaid": 0,
+			"NetAmount": 1053.33,
+			"RealAmount": 1053.33
+		}
+	}`, string(first))
+	assert.Equal(t, "1160.53", output.Total.FinalAmount.String())
+}
+
+func TestCompoundInterestsPartialPeriod(t *testing.T) {
+	// act: the last half year earns simple interests
+	total := calculateValues(compoundInterestsParams{
+		principal:      decimal.NewFromInt(1000),
+		periodsPerYear: 1,
+		years:          decimal.New(25, -1),
+		rate:           decimal.New(5, -2),
+	})
+
+	// assert
+	assert.Equal(t, "1130.06", total.FinalAmount.String())
+	assert.Equal(t, "130.06", total.Interests.String())
+	assert.Equal(t, "130.06", total.NetGain.String())
+}
+
+func TestCompoundInterestsContributionsCmd(t *testing.T) {
+	testCases := []struct {
+		args      []string
+		final     string
+		contribs  string
+		interests string
+	}{
+		// ordinary annuity and annuity-due of 200 a month at 6%
+		{[]string{"-p=0", "-r=6", "-n=12", "-m=200"}, "2467.11", "2400", "67.11"},
+		{[]string{"-p=0", "-r=6", "-n=12", "-m=200", "--contribution-timing=begin"}, "2479.45", "2400", "79.45"},
+		// 1200, 1320 and 1452 a year
+		{[]string{"-t=3", "-p=0", "-r=0", "-n=1", "-m=100", "--contribution-growth=10"}, "3972", "3972", "0"},
+		// 1100, 1210 + 500 and 1881 - 200
+		{[]string{"-t=3", "-p=1000", "-r=10", "-n=1", "--lump-sum=2:500", "--lump-sum=3:-200"}, "1681", "1300", "381"},
+		{[]string{"-t=3", "-p=1000", "-r=10", "-n=1", "--lump-sum=2:500", "--contribution-timing=begin"}, "1936", "1500", "436"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewCompoundInterestsCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(append([]string{"-t=1"}, tc.args...))
+		_, err := cmd.ExecuteC()
+
+		// assert
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := compoundInterestsOutput{}
+		assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+		assert.Equal(t, tc.final, output.Total.FinalAmount.String(), tc.args)
+		assert.Equal(t, tc.contribs, output.Total.TotalContributions.String(), tc.args)
+		assert.Equal(t, tc.interests, output.Total.Interests.String(), tc.args)
+	}
+}
+
+func TestCompoundInterestsFeesTaxesAndInflationCmd(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected compoundInterestsDetailOutput
+	}{
+		// 990 invested after the fee
+		{
+			[]string{"--entry-fee=1"},
+			compoundInterestsDetailOutput{FinalAmount: decimal.New(11979, -1), TotalContributions: decimal.NewFromInt(1000), Interests: decimal.New(2079, -1), NetGain: decimal.New(1979, -1), FeesPaid: decimal.NewFromInt(10), NetAmount: decimal.New(11979, -1), RealAmount: decimal.New(11979, -1)},
+		},
+		// 1000 + 100 - 10 and 1090 + 109 - 10.9
+		{
+			[]string{"--annual-fee=1"},
+			compoundInterestsDetailOutput{FinalAmount: decimal.New(11881, -1), TotalContributions: decimal.NewFromInt(1000), Interests: decimal.NewFromInt(209), NetGain: decimal.New(1881, -1), FeesPaid: decimal.New(209, -1), NetAmount: decimal.New(11881, -1), RealAmount: decimal.New(11881, -1)},
+		},
+		// 28% of the 210 gained
+		{
+			[]string{"--gains-tax=28"},
+			compoundInterestsDetailOutput{FinalAmount: decimal.NewFromInt(1210), TotalContributions: decimal.NewFromInt(1000), Interests: decimal.NewFromInt(210), NetGain: decimal.NewFromInt(210), TaxPaid: decimal.New(588, -1), NetAmount: decimal.New(11512, -1), RealAmount: decimal.New(11512, -1)},
+		},
+		// 28% of 100, then of 107.2
+		{
+			[]string{"--gains-tax=28", "--gains-tax-timing=yearly"},
+			compoundInterestsDetailOutput{FinalAmount: decimal.New(114918, -2), TotalContributions: decimal.NewFromInt(1000), Interests: decimal.New(2072, -1), NetGain: decimal.New(14918, -2), TaxPaid: decimal.New(5802, -2), NetAmount: decimal.New(114918, -2), RealAmount: decimal.New(114918, -2)},
+		},
+		{
+			[]string{"--inflation=10"},
+			compoundInterestsDetailOutput{FinalAmount: decimal.NewFromInt(1210), TotalContributions: decimal.NewFromInt(1000), Interests: decimal.NewFromInt(210), NetGain: decimal.NewFromInt(210), NetAmount: decimal.NewFromInt(1210), RealAmount: decimal.NewFromInt(1000)},
+		},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewCompoundInterestsCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(append([]string{"-t=2", "-p=1000", "-r=10", "-n=1"}, tc.args...))
+		_, err := cmd.ExecuteC()
+
+		// assert
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := compoundInterestsOutput{}
+		assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+		expected, _ := json.Marshal(tc.expected)
+		actual, _ := json.Marshal(output.Total)
+		assert.JSONEq(t, string(expected), string(actual), tc.args)
+	}
+}
+
+func TestCompoundInterestsYearlyTaxHistory(t *testing.T) {
+	// act
+	output := run(compoundInterestsParams{
+		principal:      decimal.NewFromInt(1000),
+		periodsPerYear: 2,
+		years:          decimal.NewFromInt(2),
+		rate:           decimal.New(10, -2),
+		gainsTax:       decimal.New(25, -2),
+		gainsTaxYearly: true,
+	}, 1)
+
+	// assert: 1000 * 1.05^2 - 25% of 102.5, taxed at the end of the year
+	assert.Len(t, output.History, 2)
+	assert.Equal(t, "25.62", output.History[0].Taxes.String())
+	assert.Equal(t, "1076.88", output.History[0].ClosingBalance.String())
+	assert.Equal(t, "1076.88", output.History[1].OpeningBalance.String())
+}
+
+func TestCompoundInterestsHistoryInterestsAreGross(t *testing.T) {
+	// act
+	output := run(compoundInterestsParams{
+		principal:      decimal.NewFromInt(1000),
+		periodsPerYear: 12,
+		years:          decimal.NewFromInt(2),
+		rate:           decimal.New(5, -2),
+		entryFee:       decimal.New(1, -2),
+	}, 12)
+
+	// assert: the entry fee lowers the net gain, not the interests, and the
+	// rows only differ from the total by their roundings to cents
+	interests := decimal.Zero
+	for _, row := range output.History {
+		interests = interests.Add(row.Interests)
+	}
+	assert.InDelta(t, output.Total.Interests.Float64(), interests.Float64(), 0.05)
+	assert.Equal(t, output.Total.NetGain.String(), output.Total.FinalAmount.Sub(output.Total.TotalContributions).String())
+	assert.Equal(t, -1, output.Total.NetGain.Cmp(output.Total.Interests))
+}
+
+func TestDeflate(t *testing.T) {
+	// act
+	whole := deflate(decimal.NewFromInt(1210), decimal.New(1, -1), decimal.NewFromInt(2))
+	half := deflate(decimal.NewFromInt(1100), decimal.New(21, -2), decimal.New(5, -1))
+
+	// assert
+	assert.Equal(t, "1000", whole.String())
+	assert.Equal(t, "1000.00", half.Round(2, decimal.HalfEven).StringFixed(2))
+}
+
+func TestCompoundInterestsRoundingCmd(t *testing.T) {
+	testCases := []struct {
+		rounding string
+		expected string
+	}{
+		{"half-even", `"FinalAmount": 1157.62`},
+		{"half-up", `"FinalAmount": 1157.63`},
+		{"truncate", `"FinalAmount": 1157.62`},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewCompoundInterestsCmd(*iostreams)
+
+		// act
+		cmd.SetArgs([]string{"-t=3", "-p=1000", "-r=5", "-n=1", "--rounding=" + tc.rounding})
+		_, err := cmd.ExecuteC()
+
+		// assert
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, out.String(), tc.expected, tc.rounding)
+	}
+}
+
+func TestCompoundInterestsDecimalAmountsCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewCompoundInterestsCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"-t=2", "-p=1500.50", "-r=0", "-n=12", "-m=99.99", "-y=12"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := compoundInterestsOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	assert.Equal(t, "3900.26", output.Total.FinalAmount.String())
+	assert.Equal(t, "3900.26", output.Total.TotalContributions.String())
+	assert.Equal(t, "0", output.Total.Interests.String())
+}
+
+func TestGetFlagDecimal(t *testing.T) {
+	// arrange
+	cmd := NewCompoundInterestsCmd(iostreams.IOStreams{})
+	cmd.ParseFlags([]string{"-p=1234567890123.456789", "-r=0.1"})
+
+	// act
+	principal, principalErr := getFlagDecimal(cmd, flagInvestAmount)
+	rate, rateErr := getFlagDecimal(cmd, flagAnnualInterestRate)
+	contribution, contributionErr := getFlagDecimal(cmd, flagRegularContributions)
+
+	// assert: the values are exact, unlike floats
+	assert.Nil(t, principalErr)
+	assert.Nil(t, rateErr)
+	assert.Nil(t, contributionErr)
+	assert.Equal(t, "1234567890123.456789", principal.String())
+	assert.Equal(t, 0, rate.Cmp(decimal.New(1, -1)))
+	assert.True(t, contribution.IsZero())
+}
+
+func TestCompoundInterestsInvalidValuesCmd(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--rounding=ceil"}, `invalid rounding mode "ceil", must be half-even, half-up, truncate`},
+		{[]string{"--granularity=week"}, `invalid granularity "week", must be period, month, quarter or year`},
+		{[]string{"-t=-1"}, "the time cannot be negative"},
+		{[]string{"-n=0"}, "the compound-periods must be greater than zero"},
+		{[]string{"--contribution-timing=middle"}, `invalid contribution timing "middle", must be begin or end`},
+		{[]string{"--lump-sum=5000"}, `invalid lump sum "5000", must be period:amount (e.g. 12:5000)`},
+		{[]string{"--lump-sum=0:5000"}, `invalid lump sum period "0", must be a positive integer`},
+		{[]string{"--lump-sum=1:5k"}, `invalid lump sum amount "5k"`},
+		{[]string{"--lump-sum=4:5000"}, "the lump sum period 4 is after the last period (3)"},
+		{[]string{"--gains-tax-timing=monthly"}, `invalid gains tax timing "monthly", must be withdrawal or yearly`},
+		{[]string{"--inflation=-100"}, "the inflation must be greater than -100"},
+		{[]string{"-p=1,000"}, `invalid invest-amount "1,000", must be a number`},
+		{[]string{"-r=5%"}, `invalid annual-interest-rate "5%", must be a number`},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewCompoundInterestsCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(append([]string{"-t=3", "-p=1000", "-r=5", "-n=1"}, tc.args...))
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.EqualError(t, err, tc.expected, tc.args)
+	}
 }
 
 func TestCompoundInterestsWithRegularContributionsCmd(t *testing.T) {
@@ -33,7 +33,12 @@ func NewFinanceCmd(iostreams iostreams.IOStreams) *cobra.Command {
 		},
 	}
 
+	financeCmd.AddCommand(NewCashflowCmd(iostreams))
 	financeCmd.AddCommand(NewCompoundInterestsCmd(iostreams))
+	financeCmd.AddCommand(NewGoalCmd(iostreams))
+	financeCmd.AddCommand(NewLoanCmd(iostreams))
+	financeCmd.AddCommand(NewMontecarloCmd(iostreams))
+	financeCmd.AddCommand(NewRetireCmd(iostreams))
 
 	return financeCmd
 }
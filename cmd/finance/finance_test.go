@@ -34,5 +34,5 @@ func TestNewFinanceCmd(t *testing.T) {
 	// assert
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "must specify a subcommand")
-	assert.Len(t, cmd.Commands(), 3)
+	assert.Len(t, cmd.Commands(), 8)
 }
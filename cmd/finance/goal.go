@@ -0,0 +1,398 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/decimal"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type goalOutput struct {
+	SolveFor string
+	// Value is the contribution or the principal, the annual interest rate
+	// as a percentage, or the time in years
+	Value decimal.Decimal
+	// Method is closed-form or numeric
+	Method string
+	Total  compoundInterestsDetailOutput
+}
+
+const flagTarget = "target"
+const flagSolveFor = "solve-for"
+
+const unknownContribution = "contribution"
+const unknownRate = "rate"
+const unknownTime = "time"
+const unknownPrincipal = "principal"
+
+const methodClosedForm = "closed-form"
+const methodNumeric = "numeric"
+
+// goalUnknowns are the flags of the values that can be solved for.
+var goalUnknowns = map[string]string{
+	unknownContribution: flagRegularContributions,
+	unknownRate:         flagAnnualInterestRate,
+	unknownTime:         flagTime,
+	unknownPrincipal:    flagInvestAmount,
+}
+
+// maxGoalYears is the longest time searched for when solving for the time.
+const maxGoalYears = 100
+
+// goalMaxAdjustments is how many times a rounded value can be increased by
+// a step when it falls short of the target with the exact schedule.
+const goalMaxAdjustments = 10
+
+func NewGoalCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	var goalCmd = &cobra.Command{
+		Use:   "goal",
+		Short: "Solves for the contribution, rate, time or principal to reach a target",
+		Long: heredoc.Doc(`
+			Solves for the value of an investment needed to reach a target
+			final amount, given the others: the regular contribution, the
+			annual interest rate, the time or the principal.
+
+			The investment has the parameters of compoundinterests, without
+			the flag of the unknown value. When it has no fees, yearly gains
+			tax, contribution growth or lump sums, the value is given by
+			the inverse of the compound interests formula, if there's one.
+			Otherwise it's found numerically, with the Newton-Raphson method
+			falling back to bisection, over the balance calculated period by
+			period with floats. The rounded value is then confirmed with the
+			exact calculation.
+
+			The contribution and the principal are rounded up to cents, the
+			rate up to 4 decimal places and the time up to whole compounding
+			periods, so that the target is reached. The totals are those of
+			the investment with the value.
+		`),
+		Example: heredoc.Doc(`
+			canivete finance goal --target 100000 --solve-for contribution -p 10000 -r 5 -n 12 -t 15
+			canivete finance goal --target 20000 --solve-for rate -p 10000 -n 1 -t 10
+			canivete finance goal --target 50000 --solve-for time -p 5000 -r 6 -n 12 -m 300 -y 12
+			canivete finance goal --target 100000 --solve-for principal -r 4 -n 4 -t 20 --annual-fee 0.5
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			unknown, _ := cmd.Flags().GetString(flagSolveFor)
+
+			target, err := getFlagDecimal(cmd, flagTarget)
+			if err != nil {
+				return err
+			}
+			if target.Sign() <= 0 {
+				return fmt.Errorf("the target must be greater than zero")
+			}
+			unknownFlag, ok := goalUnknowns[unknown]
+			if !ok {
+				return fmt.Errorf("invalid solve-for %q, must be contribution, rate, time or principal", unknown)
+			}
+			if cmd.Flags().Changed(unknownFlag) {
+				return fmt.Errorf("the %s can't be given when solving for it", unknownFlag)
+			}
+			for _, required := range []string{flagAnnualInterestRate, flagTime} {
+				if required != unknownFlag && !cmd.Flags().Changed(required) {
+					return fmt.Errorf("the %s is required", required)
+				}
+			}
+
+			params, err := getCompoundInterestsParams(cmd)
+			if err != nil {
+				return err
+			}
+			if unknown == unknownContribution && params.contributionsPerYear <= 0 {
+				return fmt.Errorf("the regular-contributions-period must be greater than zero")
+			}
+			if unknown != unknownTime {
+				if err := checkLumpSums(params); err != nil {
+					return err
+				}
+			}
+
+			output, err := solveGoal(params, unknown, target)
+			if err != nil {
+				return err
+			}
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	addCompoundInterestsFlags(goalCmd)
+	goalCmd.MarkFlagRequired(flagCompoundPeriods)
+
+	goalCmd.Flags().String(flagTarget, "", "the target final amount")
+	goalCmd.MarkFlagRequired(flagTarget)
+
+	goalCmd.Flags().StringP(flagSolveFor, "s", "", "the unknown value: contribution, rate, time or principal")
+	goalCmd.MarkFlagRequired(flagSolveFor)
+
+	return goalCmd
+}
+
+// solveGoal returns the value of the unknown of an investment that reaches
+// the target final amount.
+func solveGoal(params compoundInterestsParams, unknown string, target decimal.Decimal) (goalOutput, error) {
+	output := goalOutput{SolveFor: unknown, Method: methodClosedForm}
+
+	value, ok := solveGoalClosedForm(params, unknown, target)
+	if !ok {
+		output.Method = methodNumeric
+
+		var err error
+		value, err = solveGoalNumerically(params, unknown, target)
+		if err != nil {
+			return goalOutput{}, err
+		}
+	}
+
+	// the value is rounded up, so that the target is reached, and the step
+	// is the smallest increase of the rounded value
+	var step decimal.Decimal
+	switch unknown {
+	case unknownContribution, unknownPrincipal:
+		value = roundUp(value, 2)
+		if value.Sign() < 0 {
+			return goalOutput{}, fmt.Errorf("the target is reached without any %s", unknown)
+		}
+		step = decimal.New(1, -2)
+	case unknownRate:
+		value = roundUp(value.Mul(decimal.Hundred), 4).Div(decimal.Hundred)
+		step = decimal.New(1, -6)
+	case unknownTime:
+		n := decimal.NewFromInt(int64(params.periodsPerYear))
+		value = roundUp(value.Mul(n), 0).Div(n)
+		step = decimal.One.Div(n)
+	}
+
+	// the search uses floats, so the value is confirmed with the exact
+	// schedule, and increased when it falls short of the target
+	for i := 0; ; i++ {
+		goalParams := withGoalValue(params, unknown, value)
+		if unknown == unknownTime {
+			if err := checkLumpSums(goalParams); err != nil {
+				return goalOutput{}, err
+			}
+		}
+		output.Total = calculateValues(goalParams)
+		if output.Total.FinalAmount.Cmp(target) >= 0 || i == goalMaxAdjustments {
+			break
+		}
+		value = value.Add(step)
+	}
+
+	switch unknown {
+	case unknownContribution, unknownPrincipal:
+		output.Value = value
+	case unknownRate:
+		output.Value = value.Mul(decimal.Hundred)
+	case unknownTime:
+		output.Value = value.Round(4, decimal.HalfEven)
+	}
+
+	return output, nil
+}
+
+// solveGoalClosedForm returns the value of the unknown of an investment
+// with the inverse of the compound interests formula
+//
+//	a = p(1+i)^N + c[(1+i)^N - 1]/i
+//
+// where i = r/n is the rate, N = n*t the number of periods and c = m*y/n the
+// contribution of a period, multiplied by 1+i when it's at the beginning.
+// It fails when the investment isn't described by the formula, or the
+// formula has no inverse for the unknown.
+func solveGoalClosedForm(params compoundInterestsParams, unknown string, target decimal.Decimal) (decimal.Decimal, bool) {
+	if !params.entryFee.IsZero() || !params.annualFee.IsZero() || !params.contributionGrowth.IsZero() ||
+		len(params.lumpSums) > 0 || (params.gainsTaxYearly && !params.gainsTax.IsZero()) {
+		return decimal.Zero, false
+	}
+
+	n := decimal.NewFromInt(int64(params.periodsPerYear))
+	periods := params.years.Mul(n)
+	i := params.rate.Div(n)
+	perContribution := decimal.NewFromInt(int64(params.contributionsPerYear)).Div(n)
+	if params.contributeAtBegin {
+		perContribution = perContribution.Mul(decimal.One.Add(i))
+	}
+	c := params.contribution.Mul(perContribution)
+
+	switch unknown {
+	case unknownContribution, unknownPrincipal:
+		if periods.Floor().Cmp(periods) != 0 {
+			return decimal.Zero, false
+		}
+		growth := decimal.One.Add(i).Pow(int(periods.Int64()))
+		annuity := periods
+		if !i.IsZero() {
+			annuity = growth.Sub(decimal.One).Div(i)
+		}
+		if unknown == unknownPrincipal {
+			return target.Sub(c.Mul(annuity)).Div(growth), true
+		}
+		if annuity.IsZero() || perContribution.IsZero() {
+			return decimal.Zero, false
+		}
+		return target.Sub(params.principal.Mul(growth)).Div(annuity).Div(perContribution), true
+
+	case unknownRate:
+		// without contributions, (1+i)^N = a/p
+		if !params.contribution.IsZero() || params.principal.Sign() <= 0 || periods.IsZero() {
+			return decimal.Zero, false
+		}
+		growth := target.Div(params.principal).Float64()
+		rate := (math.Pow(growth, 1/periods.Float64()) - 1) * float64(params.periodsPerYear)
+		return decimal.NewFromFloat(rate), true
+
+	case unknownTime:
+		// (1+i)^N = (a*i + c) / (p*i + c), or N = (a - p) / c without a rate
+		var count float64
+		if i.IsZero() {
+			if c.Sign() <= 0 {
+				return decimal.Zero, false
+			}
+			count = target.Sub(params.principal).Div(c).Float64()
+		} else {
+			numerator := target.Mul(i).Add(c)
+			denominator := params.principal.Mul(i).Add(c)
+			if numerator.Sign() <= 0 || denominator.Sign() <= 0 || i.Cmp(decimal.One.Neg()) <= 0 {
+				return decimal.Zero, false
+			}
+			count = math.Log(numerator.Div(denominator).Float64()) / math.Log(1+i.Float64())
+		}
+		if count < 0 || math.IsNaN(count) || math.IsInf(count, 0) {
+			return decimal.Zero, false
+		}
+		return decimal.NewFromFloat(count).Div(n), true
+	}
+
+	return decimal.Zero, false
+}
+
+// solveGoalNumerically returns the value of the unknown of an investment
+// that makes its final amount the target, searched in a range of the
+// values.
+func solveGoalNumerically(params compoundInterestsParams, unknown string, target decimal.Decimal) (decimal.Decimal, error) {
+	goal := target.Float64()
+	f := func(x float64) float64 {
+		p := withGoalValue(params, unknown, decimal.NewFromFloat(x))
+		return finalBalance(p) - goal
+	}
+
+	lo, hi := 0.0, goal*100
+	switch unknown {
+	case unknownContribution:
+		hi = goal
+	case unknownRate:
+		lo, hi = -0.99, 10
+	case unknownTime:
+		hi = maxGoalYears
+	}
+
+	if unknown != unknownRate && f(lo) >= 0 {
+		if unknown == unknownTime {
+			return decimal.Zero, nil
+		}
+		return decimal.Zero, fmt.Errorf("the target is reached without any %s", unknown)
+	}
+
+	x, err := findRoot(f, nil, lo+(hi-lo)/100, lo, hi)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("the target can't be reached by changing the %s", unknown)
+	}
+	return decimal.NewFromFloat(x), nil
+}
+
+// finalBalance returns the final balance of an investment calculated as
+// schedule does, but with floats, for the many evaluations of the numeric
+// search.
+func finalBalance(params compoundInterestsParams) float64 {
+	n := float64(params.periodsPerYear)
+	total := params.years.Float64() * n
+	periodRate := params.rate.Float64() / n
+	periodFee := params.annualFee.Float64() / n
+	periodContribution := params.contribution.Float64() * float64(params.contributionsPerYear) / n
+	growth := 1 + params.contributionGrowth.Float64()
+	entryFee := params.entryFee.Float64()
+
+	balance := params.principal.Float64()
+	if balance > 0 {
+		balance -= balance * entryFee
+	}
+	gains := 0.0
+	for number := 1; total > float64(number-1); number++ {
+		end := float64(number)
+		fraction := 1.0
+		if end > total {
+			end = total
+			fraction = total - float64(number-1)
+		}
+
+		year := (number - 1) / params.periodsPerYear
+		deposit := periodContribution*math.Pow(growth, float64(year))*fraction + params.lumpSums[number].Float64()
+		if deposit > 0 {
+			deposit -= deposit * entryFee
+		}
+
+		if params.contributeAtBegin {
+			balance += deposit
+		}
+		interest := balance * periodRate * fraction
+		annualFee := balance * periodFee * fraction
+		balance += interest - annualFee
+		if !params.contributeAtBegin {
+			balance += deposit
+		}
+
+		gains += interest - annualFee
+		if params.gainsTaxYearly && (number%params.periodsPerYear == 0 || end == total) {
+			if gains > 0 {
+				balance -= gains * params.gainsTax.Float64()
+			}
+			gains = 0
+		}
+	}
+
+	return balance
+}
+
+// withGoalValue returns the parameters of an investment with a value of the
+// unknown, the rate as a fraction and the time in years.
+func withGoalValue(params compoundInterestsParams, unknown string, value decimal.Decimal) compoundInterestsParams {
+	switch unknown {
+	case unknownContribution:
+		params.contribution = value
+	case unknownRate:
+		params.rate = value
+	case unknownTime:
+		params.years = value
+	case unknownPrincipal:
+		params.principal = value
+	}
+	return params
+}
+
+// roundUp rounds a value up to a number of decimal places, ignoring the
+// float noise of the numeric solutions.
+func roundUp(value decimal.Decimal, places int) decimal.Decimal {
+	scale := decimal.New(1, places)
+	return value.Round(places+6, decimal.HalfEven).Mul(scale).Ceil().Div(scale)
+}
@@ -0,0 +1,175 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/renato0307/canivete/pkg/decimal"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoalCmd(t *testing.T) {
+	testCases := []struct {
+		args        []string
+		value       string
+		method      string
+		finalAmount string
+	}{
+		{[]string{"-s=contribution", "-p=10000", "-r=5", "-n=12", "-t=15"}, "295.05", "closed-form", "100000.64"},
+		{[]string{"-s=contribution", "-p=10000", "-r=5", "-n=12", "-t=15", "--annual-fee=0.3"}, "306.07", "numeric", "100002.03"},
+		{[]string{"-s=rate", "-p=50000", "-n=1", "-t=10"}, "7.1774", "closed-form", "100000.5"},
+		{[]string{"-s=rate", "-p=10000", "-n=12", "-t=10", "-m=100"}, "18.8665", "numeric", "100000.77"},
+		{[]string{"-s=time", "-p=5000", "-r=6", "-n=12", "-m=300"}, "15.0833", "closed-form", "100313.66"},
+		{[]string{"-s=time", "-p=5000", "-r=6", "-n=12", "-m=300", "--entry-fee=1"}, "15.1667", "numeric", "100104.08"},
+		{[]string{"-s=principal", "-r=4", "-n=4", "-t=20"}, "45111.8", "closed-form", "100000.01"},
+		{[]string{"-s=principal", "-r=4", "-n=4", "-t=20", "--annual-fee=0.5"}, "49809.96", "numeric", "100000"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewGoalCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(append([]string{"--target=100000"}, tc.args...))
+		_, err := cmd.ExecuteC()
+
+		// assert
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := goalOutput{}
+		assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+		assert.Equal(t, tc.value, output.Value.String(), tc.args)
+		assert.Equal(t, tc.method, output.Method, tc.args)
+		assert.Equal(t, tc.finalAmount, output.Total.FinalAmount.String(), tc.args)
+	}
+}
+
+func TestSolveGoalClosedFormMatchesNumeric(t *testing.T) {
+	// arrange
+	params := compoundInterestsParams{
+		principal:            decimal.NewFromInt(2000),
+		periodsPerYear:       12,
+		years:                decimal.NewFromInt(10),
+		contribution:         decimal.NewFromInt(150),
+		contributionsPerYear: 12,
+		contributeAtBegin:    true,
+		rate:                 decimal.New(45, -3),
+	}
+	target := decimal.NewFromInt(40000)
+
+	for _, unknown := range []string{unknownContribution, unknownTime, unknownPrincipal} {
+		// act
+		closedForm, ok := solveGoalClosedForm(params, unknown, target)
+		numeric, err := solveGoalNumerically(params, unknown, target)
+
+		// assert
+		assert.True(t, ok, unknown)
+		assert.Nil(t, err, unknown)
+		assert.InDelta(t, closedForm.Float64(), numeric.Float64(), 1e-6, unknown)
+	}
+}
+
+func TestFinalBalanceMatchesSchedule(t *testing.T) {
+	// arrange
+	params := compoundInterestsParams{
+		principal:            decimal.NewFromInt(5000),
+		periodsPerYear:       12,
+		years:                decimal.New(105, -1),
+		contribution:         decimal.NewFromInt(300),
+		contributionsPerYear: 12,
+		contributeAtBegin:    true,
+		contributionGrowth:   decimal.New(3, -2),
+		lumpSums:             map[int]decimal.Decimal{24: decimal.NewFromInt(2000), 60: decimal.NewFromInt(-1000)},
+		rate:                 decimal.New(6, -2),
+		annualFee:            decimal.New(5, -3),
+		entryFee:             decimal.New(1, -2),
+		gainsTax:             decimal.New(28, -2),
+		gainsTaxYearly:       true,
+	}
+
+	// act
+	actual := finalBalance(params)
+
+	// assert
+	assert.InDelta(t, finalPeriod(params).closing.Float64(), actual, 1e-6)
+}
+
+func TestGoalCmdDailyCompoundingIsFast(t *testing.T) {
+	testCases := [][]string{
+		{"-s=time", "-p=5000", "-r=6", "-m=300", "--annual-fee=0.5"},
+		{"-s=rate", "-p=5000", "-t=40", "-m=300", "--annual-fee=0.5"},
+	}
+
+	for _, args := range testCases {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewGoalCmd(*iostreams)
+		start := time.Now()
+
+		// act
+		cmd.SetArgs(append([]string{"--target=1000000", "-n=365"}, args...))
+		_, err := cmd.ExecuteC()
+
+		// assert
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Less(t, time.Since(start).Seconds(), 5.0, args)
+		output := goalOutput{}
+		assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+		assert.Equal(t, "numeric", output.Method, args)
+		assert.True(t, output.Total.FinalAmount.Cmp(decimal.NewFromInt(1000000)) >= 0, args)
+	}
+}
+
+func TestGoalCmdErrors(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--target=0", "-s=rate", "-t=10"}, "the target must be greater than zero"},
+		{[]string{"--target=100k", "-s=rate", "-t=10"}, `invalid target "100k", must be a number`},
+		{[]string{"--target=1000", "-s=inflation", "-r=5", "-t=10"}, `invalid solve-for "inflation", must be contribution, rate, time or principal`},
+		{[]string{"--target=1000", "-s=rate", "-r=5", "-t=10"}, "the annual-interest-rate can't be given when solving for it"},
+		{[]string{"--target=1000", "-s=principal", "-r=5"}, "the time is required"},
+		{[]string{"--target=1000", "-s=contribution", "-p=100", "-r=5", "-t=10", "-y=0"}, "the regular-contributions-period must be greater than zero"},
+		{[]string{"--target=1000", "-s=contribution", "-p=1000", "-r=5", "-t=10"}, "the target is reached without any contribution"},
+		{[]string{"--target=1000", "-s=principal", "-r=5", "-t=10", "-m=100"}, "the target is reached without any principal"},
+		{[]string{"--target=1000", "-s=rate", "-p=0", "-t=10"}, "the target can't be reached by changing the rate"},
+		{[]string{"--target=1000", "-s=time", "-p=10", "-r=-5"}, "the target can't be reached by changing the time"},
+		{[]string{"--target=1000", "-s=principal", "-r=5", "-t=1", "--lump-sum=20:100"}, "the lump sum period 20 is after the last period (12)"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewGoalCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(append([]string{"-n=12"}, tc.args...))
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.EqualError(t, err, tc.expected, tc.args)
+	}
+}
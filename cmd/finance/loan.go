@@ -0,0 +1,535 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/decimal"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type loanPaymentOutput struct {
+	Period int
+	// Rate is the annual interest rate, as a percentage
+	Rate         decimal.Decimal
+	Payment      decimal.Decimal
+	Interest     decimal.Decimal
+	Principal    decimal.Decimal
+	ExtraPayment decimal.Decimal
+	Balance      decimal.Decimal
+}
+
+type loanOutput struct {
+	Method string
+	// Payment is the first regular payment
+	Payment       decimal.Decimal
+	Periods       int
+	TotalInterest decimal.Decimal
+	TotalPaid     decimal.Decimal
+	Scenarios     []loanScenarioOutput `json:",omitempty"`
+	Schedule      []loanPaymentOutput
+}
+
+type loanScenarioOutput struct {
+	// Shock is added to the index, in percentage points
+	Shock         decimal.Decimal
+	MaxPayment    decimal.Decimal
+	TotalInterest decimal.Decimal
+	TotalPaid     decimal.Decimal
+}
+
+const flagAmount = "amount"
+const flagPaymentsPerYear = "payments-per-year"
+const flagMethod = "method"
+const flagExtraPayment = "extra-payment"
+const flagExtraMode = "extra-mode"
+const flagTable = "table"
+const flagIndex = "index"
+const flagIndexFile = "index-file"
+const flagSpread = "spread"
+const flagResetMonths = "reset-months"
+const flagScenario = "scenario"
+
+const (
+	methodFrench = "french"
+	methodGerman = "german"
+	methodBullet = "bullet"
+)
+
+// extraPayment is an extra payment in a range of periods, which is open
+// ended when to is 0.
+type extraPayment struct {
+	from   int
+	to     int
+	amount decimal.Decimal
+}
+
+// loanParams are the parameters of a loan.
+type loanParams struct {
+	amount         decimal.Decimal
+	periodsPerYear int
+	periods        int
+	// rates are the annual interest rates of each period, as fractions
+	rates         []decimal.Decimal
+	method        string
+	extraPayments []extraPayment
+	// reducePayment recalculates the payments after an extra payment,
+	// instead of keeping them and reducing the term
+	reducePayment bool
+	rounding      decimal.RoundingMode
+	// scenarios are the rates of each period of stress scenarios
+	scenarios []loanScenario
+}
+
+// loanScenario is a stress scenario of a variable rate.
+type loanScenario struct {
+	shock decimal.Decimal
+	rates []decimal.Decimal
+}
+
+func NewLoanCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	var loanCmd = &cobra.Command{
+		Use:   "loan",
+		Short: "Calculates the amortization schedule of a loan",
+		Long: heredoc.Doc(`
+			Calculates the amortization schedule of a loan or mortgage, with
+			the payment, interest, principal and remaining balance of each
+			period.
+
+			The amortization methods are:
+				french = constant payments (an annuity)
+				german = constant principal, with decreasing payments
+				bullet = interest only, with the principal paid at the end
+
+			The french payment is a = p * i / (1 - (1 + i)^-n), where i is
+			the interest rate of the period and n the number of periods.
+
+			Extra payments can be made once (e.g. 24:5000), in a range of
+			periods (e.g. 13-36:200) or in every period from one on (e.g.
+			1-:100). They either reduce the term, keeping the payments, or
+			reduce the payments, keeping the term.
+
+			The amounts are rounded to cents on every period, using the
+			rounding mode: half-even (banker's rounding), half-up or truncate.
+
+			With a spread the rate is variable, e.g. a Euribor index plus a
+			spread, reset every 3, 6 or 12 months. The index is constant or
+			read from a CSV file, with period,index lines, or from a YAML
+			file, with a list of period and index entries. Each index value
+			applies from its payment period on. The payment is recalculated
+			at every reset where the rate changes.
+
+			Stress scenarios add shocks to the index of every reset after the
+			first period, e.g. +1 or +2 percentage points, and are reported
+			with their worst-case payment, leaving out the final payment
+			that settles the rounding, and total interest.
+		`),
+		Example: heredoc.Doc(`
+			canivete finance loan -a 200000 -r 3.5 -t 30
+			canivete finance loan -a 200000 -r 3.5 -t 30 --method german --table
+			canivete finance loan -a 150000 -r 4 -t 25 --extra-payment 24:10000 --extra-mode reduce-payment
+			canivete finance loan -a 20000 -r 6 -t 5 -n 4 --method bullet
+			canivete finance loan -a 180000 -t 35 --index 2.1 --spread 1 --reset-months 6 --scenario 1 --scenario 2
+			canivete finance loan -a 180000 -t 35 --index-file euribor.csv --spread 0.85 --reset-months 12
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asTable, _ := cmd.Flags().GetBool(flagTable)
+
+			params, err := getLoanParams(cmd)
+			if err != nil {
+				return err
+			}
+
+			output := amortize(params)
+			if len(params.scenarios) > 0 {
+				output.Scenarios = runLoanScenarios(params)
+			}
+			if asTable {
+				return printLoanTable(iostreams, output)
+			}
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	loanCmd.Flags().StringP(flagAmount, "a", "", "the amount borrowed")
+	loanCmd.MarkFlagRequired(flagAmount)
+	loanCmd.Flags().StringP(flagAnnualInterestRate, "r", "", "the annual interest rate, for a fixed rate (decimal, percentage)")
+	loanCmd.Flags().StringP(flagTime, "t", "", "the term of the loan in years (e.g. 30 or 2.5)")
+	loanCmd.MarkFlagRequired(flagTime)
+	loanCmd.Flags().IntP(flagPaymentsPerYear, "n", 12, "the number of payments per year, i.e. 12 = monthly, 4 = quarterly")
+	loanCmd.Flags().StringP(flagMethod, "m", methodFrench, "the amortization method: french, german or bullet")
+	loanCmd.Flags().StringArray(flagExtraPayment, []string{}, "an extra payment in a period, a range of periods or every period from one on (e.g. 24:5000, 13-36:200 or 1-:100)")
+	loanCmd.Flags().String(flagExtraMode, "reduce-term", "what the extra payments reduce: reduce-term or reduce-payment")
+	loanCmd.Flags().String(flagRounding, decimal.HalfEven.String(), "how the amounts are rounded to cents: half-even, half-up or truncate")
+	loanCmd.Flags().Bool(flagTable, false, "output the schedule as a table instead of JSON")
+	loanCmd.Flags().String(flagSpread, "", "the spread over the index, for a variable rate (decimal, percentage)")
+	loanCmd.Flags().String(flagIndex, "", "the index of a variable rate, e.g. Euribor (decimal, percentage)")
+	loanCmd.Flags().String(flagIndexFile, "", "a CSV or YAML file with the index values by period")
+	loanCmd.Flags().Int(flagResetMonths, 12, "the months between resets of a variable rate: 3, 6 or 12")
+	loanCmd.Flags().StringSlice(flagScenario, []string{}, "a shock to the index of a variable rate, in percentage points (e.g. 1 or 2)")
+
+	return loanCmd
+}
+
+// getLoanParams returns the parameters of a loan given by the flags.
+func getLoanParams(cmd *cobra.Command) (loanParams, error) {
+	n, _ := cmd.Flags().GetInt(flagPaymentsPerYear)
+	method, _ := cmd.Flags().GetString(flagMethod)
+	extraPaymentValues, _ := cmd.Flags().GetStringArray(flagExtraPayment)
+	extraMode, _ := cmd.Flags().GetString(flagExtraMode)
+	roundingName, _ := cmd.Flags().GetString(flagRounding)
+	variable := cmd.Flags().Changed(flagSpread) || cmd.Flags().Changed(flagIndexFile)
+
+	values, err := getFlagDecimals(cmd, flagAmount, flagAnnualInterestRate, flagTime)
+	if err != nil {
+		return loanParams{}, err
+	}
+	amount, rate, t := values[0], values[1], values[2]
+
+	if variable && cmd.Flags().Changed(flagAnnualInterestRate) {
+		return loanParams{}, fmt.Errorf("the annual-interest-rate can't be used with a variable rate")
+	}
+	if !variable && !cmd.Flags().Changed(flagAnnualInterestRate) {
+		return loanParams{}, fmt.Errorf("the annual-interest-rate, or the spread of a variable rate, is required")
+	}
+	if amount.Sign() <= 0 {
+		return loanParams{}, fmt.Errorf("the amount must be greater than zero")
+	}
+	if n <= 0 {
+		return loanParams{}, fmt.Errorf("the payments-per-year must be greater than zero")
+	}
+	// the rate of a period can't lose more than everything
+	minRate := decimal.NewFromInt(int64(-100 * n))
+	if !variable && rate.Cmp(minRate) <= 0 {
+		return loanParams{}, fmt.Errorf("the annual-interest-rate must be greater than %s, -100 per payment period", minRate)
+	}
+	periods := t.Mul(decimal.NewFromInt(int64(n)))
+	if periods.Sign() <= 0 || periods.Cmp(periods.Floor()) != 0 {
+		return loanParams{}, fmt.Errorf("the time must be a positive whole number of payment periods")
+	}
+	if method != methodFrench && method != methodGerman && method != methodBullet {
+		return loanParams{}, fmt.Errorf("invalid method %q, must be french, german or bullet", method)
+	}
+	if extraMode != "reduce-term" && extraMode != "reduce-payment" {
+		return loanParams{}, fmt.Errorf("invalid extra mode %q, must be reduce-term or reduce-payment", extraMode)
+	}
+
+	rounding, err := decimal.ParseRoundingMode(roundingName)
+	if err != nil {
+		return loanParams{}, err
+	}
+
+	extraPayments, err := parseExtraPayments(extraPaymentValues)
+	if err != nil {
+		return loanParams{}, err
+	}
+
+	params := loanParams{
+		amount:         amount,
+		periodsPerYear: n,
+		periods:        int(periods.Int64()),
+		method:         method,
+		extraPayments:  extraPayments,
+		reducePayment:  extraMode == "reduce-payment",
+		rounding:       rounding,
+	}
+
+	if variable {
+		return addVariableRates(cmd, params)
+	}
+	for i := 0; i < params.periods; i++ {
+		params.rates = append(params.rates, rate.Div(decimal.Hundred))
+	}
+
+	return params, nil
+}
+
+// addVariableRates adds the rates of a variable rate given by the flags, and
+// of its stress scenarios, to the parameters of a loan.
+func addVariableRates(cmd *cobra.Command, params loanParams) (loanParams, error) {
+	indexFile, _ := cmd.Flags().GetString(flagIndexFile)
+	resetMonths, _ := cmd.Flags().GetInt(flagResetMonths)
+	shockValues, _ := cmd.Flags().GetStringSlice(flagScenario)
+
+	values, err := getFlagDecimals(cmd, flagSpread, flagIndex)
+	if err != nil {
+		return loanParams{}, err
+	}
+	spread, index := values[0], values[1]
+
+	if resetMonths != 3 && resetMonths != 6 && resetMonths != 12 {
+		return loanParams{}, fmt.Errorf("invalid reset-months %d, must be 3, 6 or 12", resetMonths)
+	}
+	if resetMonths*params.periodsPerYear%12 != 0 {
+		return loanParams{}, fmt.Errorf("the reset-months must be a whole number of payment periods")
+	}
+	resetPeriods := resetMonths * params.periodsPerYear / 12
+
+	indexValues := []indexValue{}
+	if indexFile != "" {
+		indexValues, err = readIndexValues(indexFile)
+		if err != nil {
+			return loanParams{}, err
+		}
+	}
+
+	params.rates = variableRates(params.periods, resetPeriods, index, indexValues, spread, decimal.Zero)
+	for _, shockValue := range shockValues {
+		shock, err := decimal.Parse(shockValue)
+		if err != nil {
+			return loanParams{}, fmt.Errorf("invalid scenario %q, must be a number", shockValue)
+		}
+		params.scenarios = append(params.scenarios, loanScenario{
+			shock: shock,
+			rates: variableRates(params.periods, resetPeriods, index, indexValues, spread, shock),
+		})
+	}
+
+	return params, nil
+}
+
+// runLoanScenarios returns the worst-case payment and the totals of a loan,
+// without and with the shocks of its stress scenarios. The final payment
+// settles the rounding of the others, so it isn't a worst case.
+func runLoanScenarios(params loanParams) []loanScenarioOutput {
+	scenarios := append([]loanScenario{{rates: params.rates}}, params.scenarios...)
+
+	outputs := []loanScenarioOutput{}
+	for _, scenario := range scenarios {
+		scenarioParams := params
+		scenarioParams.rates = scenario.rates
+		output := amortize(scenarioParams)
+
+		scenarioOutput := loanScenarioOutput{
+			Shock:         scenario.shock,
+			TotalInterest: output.TotalInterest,
+			TotalPaid:     output.TotalPaid,
+		}
+		payments := output.Schedule
+		if len(payments) > 1 {
+			payments = payments[:len(payments)-1]
+		}
+		for _, payment := range payments {
+			if payment.Payment.Cmp(scenarioOutput.MaxPayment) > 0 {
+				scenarioOutput.MaxPayment = payment.Payment
+			}
+		}
+		outputs = append(outputs, scenarioOutput)
+	}
+
+	return outputs
+}
+
+// parseExtraPayments returns the extra payments of values like 24:5000,
+// 13-36:200 or 1-:100.
+func parseExtraPayments(values []string) ([]extraPayment, error) {
+	extraPayments := []extraPayment{}
+	for _, value := range values {
+		invalidErr := fmt.Errorf("invalid extra payment %q, must be period:amount, from-to:amount or from-:amount (e.g. 24:5000)", value)
+
+		parts := strings.Split(value, ":")
+		if len(parts) != 2 {
+			return nil, invalidErr
+		}
+
+		periods := strings.Split(parts[0], "-")
+		if len(periods) > 2 {
+			return nil, invalidErr
+		}
+		from, err := strconv.Atoi(periods[0])
+		if err != nil || from < 1 {
+			return nil, invalidErr
+		}
+		to := from
+		if len(periods) == 2 {
+			to = 0
+			if periods[1] != "" {
+				to, err = strconv.Atoi(periods[1])
+				if err != nil || to < from {
+					return nil, invalidErr
+				}
+			}
+		}
+
+		amount, err := decimal.Parse(parts[1])
+		if err != nil || amount.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid extra payment amount %q, must be greater than zero", parts[1])
+		}
+
+		extraPayments = append(extraPayments, extraPayment{from: from, to: to, amount: amount})
+	}
+
+	return extraPayments, nil
+}
+
+// amortize calculates the schedule of a loan. The french payment and the
+// german principal are recalculated for the remaining periods on the first
+// period, when the rate changes and, when reducing the payments, after an
+// extra payment. When reducing the term, an extra payment brings forward the
+// last period.
+func amortize(params loanParams) loanOutput {
+	output := loanOutput{
+		Method:   params.method,
+		Schedule: []loanPaymentOutput{},
+	}
+
+	n := decimal.NewFromInt(int64(params.periodsPerYear))
+	balance := params.amount
+	recalculate := true
+	lastPeriod := params.periods
+	var payment, principal decimal.Decimal
+	for period := 1; period <= lastPeriod && balance.Sign() > 0; period++ {
+		rate := params.rates[period-1].Div(n)
+		if period > 1 && params.rates[period-1].Cmp(params.rates[period-2]) != 0 {
+			recalculate = true
+		}
+		if recalculate {
+			remaining := lastPeriod - period + 1
+			payment = annuityPayment(balance, rate, remaining).Round(2, params.rounding)
+			principal = balance.Div(decimal.NewFromInt(int64(remaining))).Round(2, params.rounding)
+			recalculate = false
+		}
+
+		row := loanPaymentOutput{Period: period, Rate: params.rates[period-1].Mul(decimal.Hundred)}
+		row.Interest = balance.Mul(rate).Round(2, params.rounding)
+		switch params.method {
+		case methodFrench:
+			row.Principal = payment.Sub(row.Interest)
+		case methodGerman:
+			row.Principal = principal
+		case methodBullet:
+			row.Principal = decimal.Zero
+		}
+		// the last payment settles the balance
+		if row.Principal.Cmp(balance) > 0 || period == lastPeriod {
+			row.Principal = balance
+		}
+		row.Payment = row.Interest.Add(row.Principal)
+		balance = balance.Sub(row.Principal)
+
+		row.ExtraPayment = extraPaymentOf(params.extraPayments, period)
+		if row.ExtraPayment.Cmp(balance) > 0 {
+			row.ExtraPayment = balance
+		}
+		balance = balance.Sub(row.ExtraPayment)
+		row.Balance = balance
+
+		if !row.ExtraPayment.IsZero() && balance.Sign() > 0 {
+			if params.reducePayment {
+				recalculate = true
+			} else if params.method != methodBullet {
+				lastPeriod = period + periodsToRepay(balance, rate, payment, principal, params.method, lastPeriod-period)
+				// the rounding of the payment can add a period, but an
+				// extra payment never extends the term
+				if lastPeriod > params.periods {
+					lastPeriod = params.periods
+				}
+			}
+		}
+
+		if period == 1 {
+			output.Payment = row.Payment
+		}
+		output.TotalInterest = output.TotalInterest.Add(row.Interest)
+		output.TotalPaid = output.TotalPaid.Add(row.Payment).Add(row.ExtraPayment)
+		output.Schedule = append(output.Schedule, row)
+	}
+	output.Periods = len(output.Schedule)
+
+	return output
+}
+
+// annuityPayment returns the constant payment of an amount in a number of
+// periods, with the interest rate of a period.
+func annuityPayment(amount, rate decimal.Decimal, periods int) decimal.Decimal {
+	if rate.IsZero() {
+		return amount.Div(decimal.NewFromInt(int64(periods)))
+	}
+	discount := decimal.One.Sub(decimal.One.Add(rate).Pow(-periods))
+	return amount.Mul(rate).Div(discount)
+}
+
+// periodsToRepay returns the number of periods needed to repay a balance with
+// the current french payment or german principal, or the current number when
+// the payment doesn't cover the interest.
+func periodsToRepay(balance, rate, payment, principal decimal.Decimal, method string, current int) int {
+	if method == methodGerman {
+		return int(balance.Div(principal).Ceil().Int64())
+	}
+
+	b, i, p := balance.Float64(), rate.Float64(), payment.Float64()
+	if i == 0 {
+		return int(math.Ceil(b / p))
+	}
+	if b*i >= p {
+		return current
+	}
+	// n such that the annuity of the balance in n periods is the payment,
+	// with a tolerance for the rounding of the payment
+	n := -math.Log(1-b*i/p) / math.Log(1+i)
+	return int(math.Ceil(n - 1e-9))
+}
+
+// extraPaymentOf returns the sum of the extra payments of a period.
+func extraPaymentOf(extraPayments []extraPayment, period int) decimal.Decimal {
+	total := decimal.Zero
+	for _, extra := range extraPayments {
+		if period >= extra.from && (extra.to == 0 || period <= extra.to) {
+			total = total.Add(extra.amount)
+		}
+	}
+	return total
+}
+
+// printLoanTable prints the schedule of a loan as a table, with the totals in
+// the last row.
+func printLoanTable(iostreams iostreams.IOStreams, output loanOutput) error {
+	headers := []string{"PERIOD", "RATE", "PAYMENT", "INTEREST", "PRINCIPAL", "EXTRA", "BALANCE"}
+	rows := [][]string{}
+	principal := decimal.Zero
+	extra := decimal.Zero
+	for _, payment := range output.Schedule {
+		rows = append(rows, []string{
+			fmt.Sprint(payment.Period),
+			payment.Rate.String(),
+			payment.Payment.StringFixed(2),
+			payment.Interest.StringFixed(2),
+			payment.Principal.StringFixed(2),
+			payment.ExtraPayment.StringFixed(2),
+			payment.Balance.StringFixed(2),
+		})
+		principal = principal.Add(payment.Principal)
+		extra = extra.Add(payment.ExtraPayment)
+	}
+	rows = append(rows, []string{
+		"TOTAL",
+		"",
+		output.TotalPaid.Sub(extra).StringFixed(2),
+		output.TotalInterest.StringFixed(2),
+		principal.StringFixed(2),
+		extra.StringFixed(2),
+	})
+
+	return iostreams.PrintTable(headers, rows)
+}
@@ -0,0 +1,320 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/renato0307/canivete/pkg/decimal"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoanCmd(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewLoanCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"--amount=200000", "--annual-interest-rate=3.5", "--time=30"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := loanOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	assert.Equal(t, "french", output.Method)
+	assert.Equal(t, "898.09", output.Payment.String())
+	assert.Equal(t, 360, output.Periods)
+	assert.Equal(t, "123311.97", output.TotalInterest.String())
+	assert.Equal(t, "323311.97", output.TotalPaid.String())
+	assert.Equal(t, "583.33", output.Schedule[0].Interest.String())
+	assert.Equal(t, "199685.24", output.Schedule[0].Balance.String())
+	assert.Equal(t, "0", output.Schedule[359].Balance.String())
+}
+
+func TestLoanCmdTable(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected []string
+	}{
+		{
+			[]string{},
+			[]string{
+				"PERIOD  RATE  PAYMENT  INTEREST  PRINCIPAL  EXTRA  BALANCE",
+				"1       12    172.55   10.00     162.55     0.00   837.45",
+				"2       12    172.55   8.37      164.18     0.00   673.27",
+				"3       12    172.55   6.73      165.82     0.00   507.45",
+				"4       12    172.55   5.07      167.48     0.00   339.97",
+				"5       12    172.55   3.40      169.15     0.00   170.82",
+				"6       12    172.53   1.71      170.82     0.00   0.00",
+				"TOTAL         1035.28  35.28     1000.00    0.00",
+			},
+		},
+		{
+			[]string{"--method=german"},
+			[]string{
+				"PERIOD  RATE  PAYMENT  INTEREST  PRINCIPAL  EXTRA  BALANCE",
+				"1       12    176.67   10.00     166.67     0.00   833.33",
+				"2       12    175.00   8.33      166.67     0.00   666.66",
+				"3       12    173.34   6.67      166.67     0.00   499.99",
+				"4       12    171.67   5.00      166.67     0.00   333.32",
+				"5       12    170.00   3.33      166.67     0.00   166.65",
+				"6       12    168.32   1.67      166.65     0.00   0.00",
+				"TOTAL         1035.00  35.00     1000.00    0.00",
+			},
+		},
+		{
+			[]string{"--method=bullet"},
+			[]string{
+				"PERIOD  RATE  PAYMENT  INTEREST  PRINCIPAL  EXTRA  BALANCE",
+				"1       12    10.00    10.00     0.00       0.00   1000.00",
+				"2       12    10.00    10.00     0.00       0.00   1000.00",
+				"3       12    10.00    10.00     0.00       0.00   1000.00",
+				"4       12    10.00    10.00     0.00       0.00   1000.00",
+				"5       12    10.00    10.00     0.00       0.00   1000.00",
+				"6       12    1010.00  10.00     1000.00    0.00   0.00",
+				"TOTAL         1060.00  60.00     1000.00    0.00",
+			},
+		},
+		{
+			[]string{"--extra-payment=2:300"},
+			[]string{
+				"PERIOD  RATE  PAYMENT  INTEREST  PRINCIPAL  EXTRA   BALANCE",
+				"1       12    172.55   10.00     162.55     0.00    837.45",
+				"2       12    172.55   8.37      164.18     300.00  373.27",
+				"3       12    172.55   3.73      168.82     0.00    204.45",
+				"4       12    172.55   2.04      170.51     0.00    33.94",
+				"5       12    34.28    0.34      33.94      0.00    0.00",
+				"TOTAL         724.48   24.48     700.00     300.00",
+			},
+		},
+		{
+			[]string{"--extra-payment=2:300", "--extra-mode=reduce-payment"},
+			[]string{
+				"PERIOD  RATE  PAYMENT  INTEREST  PRINCIPAL  EXTRA   BALANCE",
+				"1       12    172.55   10.00     162.55     0.00    837.45",
+				"2       12    172.55   8.37      164.18     300.00  373.27",
+				"3       12    95.66    3.73      91.93      0.00    281.34",
+				"4       12    95.66    2.81      92.85      0.00    188.49",
+				"5       12    95.66    1.88      93.78      0.00    94.71",
+				"6       12    95.66    0.95      94.71      0.00    0.00",
+				"TOTAL         727.74   27.74     700.00     300.00",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewLoanCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(append([]string{"-a=1000", "-r=12", "-t=0.5", "--table"}, tc.args...))
+		_, err := cmd.ExecuteC()
+
+		// assert
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, strings.Join(tc.expected, "\n")+"\n", out.String(), tc.args)
+	}
+}
+
+func TestLoanCmdVariableRate(t *testing.T) {
+	// arrange
+	path := writeIndexFile(t, "euribor.csv", "period,index\n1,3\n13,2.5\n25,4\n")
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewLoanCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"-a=100000", "-t=3", "--spread=1", "--index-file=" + path, "--scenario=1,2"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := loanOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	assert.Equal(t, "2952.4", output.Payment.String())
+	assert.Equal(t, "3.5", output.Schedule[12].Rate.String())
+	assert.Equal(t, "2937.29", output.Schedule[12].Payment.String())
+	assert.Equal(t, "5", output.Schedule[24].Rate.String())
+	data, _ := json.Marshal(output.Scenarios)
+	assert.JSONEq(t, `[
+		{"Shock": 0, "MaxPayment": 2961.01, "TotalInterest": 6208.44, "TotalPaid": 106208.44},
+		{"Shock": 1, "MaxPayment": 2991.46, "TotalInterest": 6936.95, "TotalPaid": 106936.95},
+		{"Shock": 2, "MaxPayment": 3022.1, "TotalInterest": 7669.97, "TotalPaid": 107669.97}
+	]`, string(data))
+}
+
+func TestLoanCmdScenarioMaxPaymentLeavesOutFinalPayment(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewLoanCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"-a=180000", "-t=35", "--index=2.1", "--spread=1", "--reset-months=6", "--scenario=1"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := loanOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	assert.Equal(t, "706.37", output.Schedule[len(output.Schedule)-1].Payment.String())
+	assert.Equal(t, "702.81", output.Scenarios[0].MaxPayment.String())
+	assert.Equal(t, "806.61", output.Scenarios[1].MaxPayment.String())
+}
+
+func TestAmortizeRateChangeAfterReducingTerm(t *testing.T) {
+	// arrange: the rate doubles after an extra payment that shortens the loan
+	rates := []decimal.Decimal{}
+	for i := 0; i < 12; i++ {
+		rate := decimal.New(6, -2)
+		if i >= 6 {
+			rate = decimal.New(12, -2)
+		}
+		rates = append(rates, rate)
+	}
+	params := loanParams{
+		amount:         decimal.NewFromInt(12000),
+		periodsPerYear: 12,
+		periods:        12,
+		rates:          rates,
+		method:         methodFrench,
+		extraPayments:  []extraPayment{{from: 3, to: 3, amount: decimal.NewFromInt(3000)}},
+	}
+
+	// act
+	output := amortize(params)
+
+	// assert: the payment is recalculated for the shortened term
+	assert.Equal(t, 9, output.Periods)
+	assert.Equal(t, "0", output.Schedule[8].Balance.String())
+	assert.Equal(t, 1, output.Schedule[6].Payment.Cmp(output.Schedule[5].Payment))
+}
+
+func TestAmortizeRecurringExtraPayments(t *testing.T) {
+	// arrange: 100 more from the 3rd period until the 4th
+	params := loanParams{
+		amount:         decimal.NewFromInt(1200),
+		periodsPerYear: 12,
+		periods:        12,
+		rates:          make([]decimal.Decimal, 12),
+		method:         methodGerman,
+		extraPayments:  []extraPayment{{from: 3, to: 4, amount: decimal.NewFromInt(100)}},
+	}
+
+	// act
+	output := amortize(params)
+
+	// assert: without interest, 100 a month plus 200 extra
+	assert.Equal(t, 10, output.Periods)
+	assert.Equal(t, "0", output.TotalInterest.String())
+	assert.Equal(t, "1200", output.TotalPaid.String())
+	assert.Equal(t, "100", output.Schedule[2].ExtraPayment.String())
+	assert.Equal(t, "0", output.Schedule[4].ExtraPayment.String())
+}
+
+func TestLoanCmdSmallExtraPaymentKeepsTerm(t *testing.T) {
+	testCases := []struct {
+		args    []string
+		periods int
+	}{
+		{[]string{"-a=1000", "-r=0", "-t=1", "--extra-payment=1:0.01"}, 12},
+		{[]string{"-a=100000", "-r=7.3", "-t=30", "--rounding=half-up", "--extra-payment=1:0.01"}, 360},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewLoanCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(tc.args)
+		_, err := cmd.ExecuteC()
+
+		// assert
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := loanOutput{}
+		assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+		assert.Equal(t, tc.periods, output.Periods, tc.args)
+		assert.Equal(t, "0", output.Schedule[tc.periods-1].Balance.String(), tc.args)
+	}
+}
+
+func TestParseExtraPayments(t *testing.T) {
+	// act
+	extraPayments, err := parseExtraPayments([]string{"24:5000", "13-36:200", "1-:100.50"})
+
+	// assert
+	assert.Nil(t, err)
+	assert.Len(t, extraPayments, 3)
+	assert.Equal(t, []int{24, 24}, []int{extraPayments[0].from, extraPayments[0].to})
+	assert.Equal(t, []int{13, 36}, []int{extraPayments[1].from, extraPayments[1].to})
+	assert.Equal(t, []int{1, 0}, []int{extraPayments[2].from, extraPayments[2].to})
+	assert.Equal(t, "100.5", extraPayments[2].amount.String())
+	assert.Equal(t, "300.5", extraPaymentOf(extraPayments, 13).String())
+}
+
+func TestLoanCmdErrors(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-r=5", "-a=0"}, "the amount must be greater than zero"},
+		{[]string{"-r=5", "-n=0"}, "the payments-per-year must be greater than zero"},
+		{[]string{"-r=-1200", "-n=12"}, "the annual-interest-rate must be greater than -1200, -100 per payment period"},
+		{[]string{"-r=-500", "-n=4"}, "the annual-interest-rate must be greater than -400, -100 per payment period"},
+		{[]string{"-r=5", "-t=1.01"}, "the time must be a positive whole number of payment periods"},
+		{[]string{"-r=5", "--method=italian"}, `invalid method "italian", must be french, german or bullet`},
+		{[]string{"-r=5", "--extra-mode=reduce-rate"}, `invalid extra mode "reduce-rate", must be reduce-term or reduce-payment`},
+		{[]string{"-r=5", "--rounding=ceil"}, `invalid rounding mode "ceil", must be half-even, half-up, truncate`},
+		{[]string{"-r=5", "--extra-payment=5000"}, `invalid extra payment "5000", must be period:amount, from-to:amount or from-:amount (e.g. 24:5000)`},
+		{[]string{"-r=5", "--extra-payment=36-13:200"}, `invalid extra payment "36-13:200", must be period:amount, from-to:amount or from-:amount (e.g. 24:5000)`},
+		{[]string{"-r=5", "--extra-payment=1:-5"}, `invalid extra payment amount "-5", must be greater than zero`},
+		{[]string{}, "the annual-interest-rate, or the spread of a variable rate, is required"},
+		{[]string{"-r=5", "--spread=1"}, "the annual-interest-rate can't be used with a variable rate"},
+		{[]string{"--spread=1", "--reset-months=1"}, "invalid reset-months 1, must be 3, 6 or 12"},
+		{[]string{"--spread=1", "--reset-months=3", "-n=1"}, "the reset-months must be a whole number of payment periods"},
+		{[]string{"--index-file=missing.csv"}, "open missing.csv: no such file or directory"},
+		{[]string{"-r=five"}, `invalid annual-interest-rate "five", must be a number`},
+		{[]string{"--spread=1", "--scenario=high"}, `invalid scenario "high", must be a number`},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewLoanCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(append([]string{"-a=1000", "-t=1"}, tc.args...))
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.EqualError(t, err, tc.expected, tc.args)
+	}
+}
@@ -0,0 +1,428 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/decimal"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type montecarloYearOutput struct {
+	Year int
+	P5   decimal.Decimal
+	P50  decimal.Decimal
+	P95  decimal.Decimal
+}
+
+type montecarloOutput struct {
+	Paths int
+	Seed  int64
+	// TargetProbability is the percentage of the paths reaching the target
+	TargetProbability *decimal.Decimal `json:",omitempty"`
+	Years             []montecarloYearOutput
+}
+
+const flagExpectedReturn = "expected-return"
+const flagVolatility = "volatility"
+const flagDistribution = "distribution"
+const flagReturnsFile = "returns-file"
+const flagPaths = "paths"
+const flagSeed = "seed"
+const flagWorkers = "workers"
+
+const distributionNormal = "normal"
+const distributionLognormal = "lognormal"
+const distributionBootstrap = "bootstrap"
+
+// montecarloParams are the parameters of a simulation, with the returns as
+// fractions.
+type montecarloParams struct {
+	principal            float64
+	contribution         float64
+	contributionsPerYear int
+	years                int
+	expectedReturn       float64
+	volatility           float64
+	distribution         string
+	// returns are the historical annual returns of the bootstrap
+	returns []float64
+	paths   int
+	seed    int64
+	workers int
+}
+
+func NewMontecarloCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	var montecarloCmd = &cobra.Command{
+		Use:   "montecarlo",
+		Short: "Simulates the risk of an investment with the Monte Carlo method",
+		Long: heredoc.Doc(`
+			Simulates the paths of an investment with regular contributions,
+			whose annual returns are random, with the Monte Carlo method. It
+			reports the 5th, 50th (median) and 95th percentiles of the
+			balances at the end of each year and, given a target, the
+			probability of reaching it.
+
+			The annual returns have a normal or lognormal distribution with
+			the expected return and volatility (standard deviation), or are
+			drawn from historical returns (a bootstrap), read from a CSV file
+			with a return, or a year and a return, per line, as percentages,
+			and an optional header. A return of a year compounds over its
+			contributions, added at the end of each contribution period.
+
+			The simulation is reproducible: each path has a random generator
+			seeded from the seed, so the results don't depend on the number
+			of workers running the paths.
+		`),
+		Example: heredoc.Doc(`
+			canivete finance montecarlo -p 10000 -m 300 -t 30 --expected-return 7 --volatility 15 --target 400000
+			canivete finance montecarlo -p 10000 -t 20 --expected-return 5 --volatility 10 --distribution normal --table
+			canivete finance montecarlo -p 10000 -m 500 -t 25 --distribution bootstrap --returns-file sp500.csv --paths 50000 --seed 42
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			table, _ := cmd.Flags().GetBool(flagTable)
+
+			params, err := getMontecarloParams(cmd)
+			if err != nil {
+				return err
+			}
+
+			balances := simulate(params)
+			output, err := newMontecarloOutput(params, balances)
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed(flagTarget) {
+				target, err := getFlagDecimal(cmd, flagTarget)
+				if err != nil {
+					return err
+				}
+				probability := targetProbability(balances, target.Float64())
+				output.TargetProbability = &probability
+			}
+
+			if table {
+				return printMontecarloTable(iostreams, output)
+			}
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	montecarloCmd.Flags().StringP(flagInvestAmount, "p", "", "the principal investment amount")
+	montecarloCmd.Flags().StringP(flagRegularContributions, "m", "", "regular contributions (additional money added to investment)")
+	montecarloCmd.Flags().IntP(flagRegularContributionsPeriod, "y", 12, "regular contributions in a year (e.g. 12 if every month)")
+	montecarloCmd.Flags().StringP(flagTime, "t", "", "the time the money is invested for, in whole years")
+	montecarloCmd.MarkFlagRequired(flagTime)
+	montecarloCmd.Flags().String(flagExpectedReturn, "", "the expected annual return (decimal, percentage)")
+	montecarloCmd.Flags().String(flagVolatility, "", "the standard deviation of the annual returns (decimal, percentage)")
+	montecarloCmd.Flags().String(flagDistribution, distributionLognormal, "the distribution of the returns: normal, lognormal or bootstrap")
+	montecarloCmd.Flags().String(flagReturnsFile, "", "a CSV file with the historical annual returns, for the bootstrap")
+	montecarloCmd.Flags().Int(flagPaths, 10000, "the number of paths simulated")
+	montecarloCmd.Flags().Int64(flagSeed, 1, "the seed of the random generators")
+	montecarloCmd.Flags().Int(flagWorkers, runtime.NumCPU(), "the number of paths simulated in parallel")
+	montecarloCmd.Flags().String(flagTarget, "", "the target final amount")
+	montecarloCmd.Flags().Bool(flagTable, false, "output the percentiles as a table instead of JSON")
+
+	return montecarloCmd
+}
+
+// getMontecarloParams returns the parameters of a simulation given by the
+// flags.
+func getMontecarloParams(cmd *cobra.Command) (montecarloParams, error) {
+	y, _ := cmd.Flags().GetInt(flagRegularContributionsPeriod)
+	distribution, _ := cmd.Flags().GetString(flagDistribution)
+	returnsFile, _ := cmd.Flags().GetString(flagReturnsFile)
+	paths, _ := cmd.Flags().GetInt(flagPaths)
+	seed, _ := cmd.Flags().GetInt64(flagSeed)
+	workers, _ := cmd.Flags().GetInt(flagWorkers)
+
+	values, err := getFlagDecimals(cmd, flagInvestAmount, flagRegularContributions, flagTime,
+		flagExpectedReturn, flagVolatility)
+	if err != nil {
+		return montecarloParams{}, err
+	}
+	p, m, t, expectedReturn, volatility := values[0], values[1], values[2], values[3], values[4]
+
+	if !m.IsZero() && y <= 0 {
+		return montecarloParams{}, fmt.Errorf("the regular-contributions-period must be greater than zero")
+	}
+	if t.Sign() <= 0 {
+		return montecarloParams{}, fmt.Errorf("the time must be greater than zero")
+	}
+	if t.Cmp(t.Floor()) != 0 {
+		return montecarloParams{}, fmt.Errorf("the time must be a whole number of years")
+	}
+	if paths <= 0 {
+		return montecarloParams{}, fmt.Errorf("the paths must be greater than zero")
+	}
+	if workers <= 0 {
+		return montecarloParams{}, fmt.Errorf("the workers must be greater than zero")
+	}
+	if volatility.Sign() < 0 {
+		return montecarloParams{}, fmt.Errorf("the volatility cannot be negative")
+	}
+
+	params := montecarloParams{
+		principal:            p.Float64(),
+		contribution:         m.Float64(),
+		contributionsPerYear: y,
+		years:                int(t.Int64()),
+		expectedReturn:       expectedReturn.Div(decimal.Hundred).Float64(),
+		volatility:           volatility.Div(decimal.Hundred).Float64(),
+		distribution:         distribution,
+		paths:                paths,
+		seed:                 seed,
+		workers:              workers,
+	}
+
+	switch distribution {
+	case distributionNormal:
+	case distributionLognormal:
+		if expectedReturn.Cmp(decimal.Hundred.Neg()) <= 0 {
+			return montecarloParams{}, fmt.Errorf("the expected-return must be greater than -100")
+		}
+	case distributionBootstrap:
+		if returnsFile == "" {
+			return montecarloParams{}, fmt.Errorf("the returns-file is required by the bootstrap distribution")
+		}
+		returns, err := readReturns(returnsFile)
+		if err != nil {
+			return montecarloParams{}, err
+		}
+		params.returns = returns
+	default:
+		return montecarloParams{}, fmt.Errorf("invalid distribution %q, must be normal, lognormal or bootstrap", distribution)
+	}
+	if distribution != distributionBootstrap && returnsFile != "" {
+		return montecarloParams{}, fmt.Errorf("the returns-file can only be used with the bootstrap distribution")
+	}
+
+	return params, nil
+}
+
+// readReturns reads annual returns, as fractions, from a CSV file with a
+// return, or a year and a return, per line, as percentages, and an optional
+// header.
+func readReturns(path string) ([]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	returns := []float64{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid returns file %q: %w", path, err)
+		}
+
+		value, err := decimal.Parse(record[len(record)-1])
+		if err != nil && line == 1 {
+			// the header
+			continue
+		}
+		if err != nil || len(record) > 2 {
+			return nil, fmt.Errorf("invalid returns file %q: line %d: invalid return %q", path, line, record[len(record)-1])
+		}
+		returns = append(returns, value.Float64()/100)
+	}
+	if len(returns) == 0 {
+		return nil, fmt.Errorf("invalid returns file %q: no returns", path)
+	}
+
+	return returns, nil
+}
+
+// simulate returns the balances at the end of each year of each path. The
+// paths are simulated by a pool of workers, each path with its own random
+// generator seeded by mixing the seed and the path number.
+func simulate(params montecarloParams) [][]float64 {
+	balances := make([][]float64, params.paths)
+
+	paths := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < params.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				random := rand.New(rand.NewSource(pathSeed(params.seed, path)))
+				balances[path] = simulatePath(params, random)
+			}
+		}()
+	}
+
+	for path := 0; path < params.paths; path++ {
+		paths <- path
+	}
+	close(paths)
+	wg.Wait()
+
+	return balances
+}
+
+// pathSeed returns the seed of the random generator of a path, mixing the
+// seed and the path number with splitmix64, so consecutive seeds don't share
+// paths.
+func pathSeed(seed int64, path int) int64 {
+	z := uint64(seed) + uint64(path+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+// simulatePath returns the balances at the end of each year of a path. Each
+// contribution earns the return of the rest of its year.
+func simulatePath(params montecarloParams, random *rand.Rand) []float64 {
+	balances := make([]float64, params.years)
+	balance := params.principal
+	for year := 0; year < params.years; year++ {
+		growth := 1 + annualReturn(params, random)
+		balance *= growth
+		for k := 1; k <= params.contributionsPerYear; k++ {
+			remaining := float64(params.contributionsPerYear-k) / float64(params.contributionsPerYear)
+			balance += params.contribution * math.Pow(growth, remaining)
+		}
+		balances[year] = balance
+	}
+	return balances
+}
+
+// annualReturn returns a random annual return, which can't lose more than
+// everything.
+func annualReturn(params montecarloParams, random *rand.Rand) float64 {
+	var r float64
+	switch params.distribution {
+	case distributionNormal:
+		r = params.expectedReturn + params.volatility*random.NormFloat64()
+	case distributionLognormal:
+		// the log of 1+r is normal, with the mean and variance that give
+		// the expected return and volatility
+		variance := math.Log(1 + math.Pow(params.volatility/(1+params.expectedReturn), 2))
+		mean := math.Log(1+params.expectedReturn) - variance/2
+		r = math.Exp(mean+math.Sqrt(variance)*random.NormFloat64()) - 1
+	case distributionBootstrap:
+		r = params.returns[random.Intn(len(params.returns))]
+	}
+	return math.Max(r, -1)
+}
+
+// newMontecarloOutput returns the percentiles of the balances of each year.
+func newMontecarloOutput(params montecarloParams, balances [][]float64) (montecarloOutput, error) {
+	output := montecarloOutput{
+		Paths: params.paths,
+		Seed:  params.seed,
+		Years: []montecarloYearOutput{},
+	}
+
+	values := make([]float64, len(balances))
+	for year := 0; year < params.years; year++ {
+		for path := range balances {
+			values[path] = balances[path][year]
+		}
+		sort.Float64s(values)
+
+		amounts := []decimal.Decimal{}
+		for _, p := range []float64{5, 50, 95} {
+			amount, err := toAmount(percentile(values, p))
+			if err != nil {
+				return montecarloOutput{}, err
+			}
+			amounts = append(amounts, amount)
+		}
+		output.Years = append(output.Years, montecarloYearOutput{
+			Year: year + 1,
+			P5:   amounts[0],
+			P50:  amounts[1],
+			P95:  amounts[2],
+		})
+	}
+
+	return output, nil
+}
+
+// percentile returns a percentile of sorted values, interpolated linearly
+// between the closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	if lower+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
+
+// targetProbability returns the percentage of the paths whose final balance
+// reaches the target.
+func targetProbability(balances [][]float64, target float64) decimal.Decimal {
+	reached := 0
+	for _, path := range balances {
+		if path[len(path)-1] >= target {
+			reached++
+		}
+	}
+	probability := decimal.NewFromInt(int64(reached)).Mul(decimal.Hundred).Div(decimal.NewFromInt(int64(len(balances))))
+	return probability.Round(2, decimal.HalfEven)
+}
+
+// toAmount returns a float amount rounded to cents, or an error if the
+// amount overflowed.
+func toAmount(value float64) (decimal.Decimal, error) {
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return decimal.Zero, fmt.Errorf("the balances are too large, try a shorter time or smaller returns")
+	}
+	return decimal.NewFromFloat(value).Round(2, decimal.HalfEven), nil
+}
+
+func printMontecarloTable(iostreams iostreams.IOStreams, output montecarloOutput) error {
+	headers := []string{"YEAR", "P5", "P50", "P95"}
+	rows := [][]string{}
+	for _, year := range output.Years {
+		rows = append(rows, []string{
+			fmt.Sprint(year.Year),
+			year.P5.StringFixed(2),
+			year.P50.StringFixed(2),
+			year.P95.StringFixed(2),
+		})
+	}
+
+	err := iostreams.PrintTable(headers, rows)
+	if err == nil && output.TargetProbability != nil {
+		fmt.Fprintf(iostreams.Out, "\nTarget probability: %s%%\n", output.TargetProbability.StringFixed(2))
+	}
+	return err
+}
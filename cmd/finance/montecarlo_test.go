@@ -0,0 +1,206 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/renato0307/canivete/pkg/decimal"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMontecarloCmdWithoutVolatility(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewMontecarloCmd(*iostreams)
+
+	// act
+	cmd.SetArgs([]string{"-p=10000", "-t=3", "--expected-return=5", "--paths=10", "--target=11576.25"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+		"Paths": 10,
+		"Seed": 1,
+		"TargetProbability": 100,
+		"Years": [
+			{"Year": 1, "P5": 10500, "P50": 10500, "P95": 10500},
+			{"Year": 2, "P5": 11025, "P50": 11025, "P95": 11025},
+			{"Year": 3, "P5": 11576.25, "P50": 11576.25, "P95": 11576.25}
+		]
+	}`
+	assert.JSONEq(t, expected, out.String())
+}
+
+func TestMontecarloCmdIsReproducible(t *testing.T) {
+	outputs := []string{}
+	for _, workers := range []string{"1", "4"} {
+		// arrange
+		iostreams, _, out, _ := iostreams.Test()
+		cmd := NewMontecarloCmd(*iostreams)
+
+		// act
+		cmd.SetArgs([]string{"-p=10000", "-m=100", "-t=10", "--expected-return=7", "--volatility=15",
+			"--paths=500", "--seed=42", "--target=30000", "--workers=" + workers})
+		_, err := cmd.ExecuteC()
+
+		// assert
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, out.String())
+	}
+	assert.Equal(t, outputs[0], outputs[1])
+
+	output := montecarloOutput{}
+	assert.Nil(t, json.Unmarshal([]byte(outputs[0]), &output))
+	assert.Len(t, output.Years, 10)
+	for _, year := range output.Years {
+		assert.True(t, year.P5.Cmp(year.P50) < 0, year)
+		assert.True(t, year.P50.Cmp(year.P95) < 0, year)
+	}
+	assert.True(t, output.TargetProbability.Sign() > 0)
+	assert.True(t, output.TargetProbability.Cmp(decimal.Hundred) < 0)
+}
+
+func TestSimulateSeedsHaveIndependentPaths(t *testing.T) {
+	// arrange
+	params := montecarloParams{
+		principal:            10000,
+		contributionsPerYear: 12,
+		years:                5,
+		expectedReturn:       0.07,
+		volatility:           0.15,
+		distribution:         distributionLognormal,
+		paths:                3,
+		workers:              1,
+	}
+	params1, params2 := params, params
+	params1.seed, params2.seed = 1, 2
+
+	// act
+	balances1 := simulate(params1)
+	balances2 := simulate(params2)
+
+	// assert
+	for _, path1 := range balances1 {
+		for _, path2 := range balances2 {
+			assert.NotEqual(t, path1, path2)
+		}
+	}
+}
+
+func TestMontecarloCmdBootstrapTable(t *testing.T) {
+	// arrange
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewMontecarloCmd(*iostreams)
+	path := writeIndexFile(t, "returns.csv", "year,return\n2020,10\n2021,10\n")
+
+	// act
+	cmd.SetArgs([]string{"-p=1000", "-m=100", "-y=2", "-t=2", "--distribution=bootstrap",
+		"--returns-file=" + path, "--paths=5", "--target=1500", "--table"})
+	_, err := cmd.ExecuteC()
+
+	// assert
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"YEAR  P5       P50      P95",
+		"1     1304.88  1304.88  1304.88",
+		"2     1640.25  1640.25  1640.25",
+		"",
+		"Target probability: 100.00%",
+		"",
+	}
+	assert.Equal(t, strings.Join(expected, "\n"), out.String())
+}
+
+func TestPercentile(t *testing.T) {
+	// arrange
+	values := []float64{1, 2, 3, 4, 5}
+
+	// act & assert
+	assert.Equal(t, 1.0, percentile(values, 0))
+	assert.Equal(t, 1.2, percentile(values, 5))
+	assert.Equal(t, 3.0, percentile(values, 50))
+	assert.Equal(t, 5.0, percentile(values, 100))
+	assert.Equal(t, 7.0, percentile([]float64{7}, 95))
+}
+
+func TestReadReturnsErrors(t *testing.T) {
+	testCases := []struct {
+		content  string
+		expected string
+	}{
+		{"return\n", "no returns"},
+		{"return\n5\nabc\n", `line 3: invalid return "abc"`},
+		{"1,2,3\n", `line 1: invalid return "3"`},
+		{"5\n1,2,3\n", `line 2: invalid return "3"`},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		path := writeIndexFile(t, "returns.csv", tc.content)
+
+		// act
+		_, err := readReturns(path)
+
+		// assert
+		assert.EqualError(t, err, `invalid returns file "`+path+`": `+tc.expected, tc.content)
+	}
+}
+
+func TestMontecarloCmdErrors(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-t=0"}, "the time must be greater than zero"},
+		{[]string{"-t=2.5"}, "the time must be a whole number of years"},
+		{[]string{"-t=10", "-p=ten"}, `invalid invest-amount "ten", must be a number`},
+		{[]string{"-t=10", "--target=a lot"}, `invalid target "a lot", must be a number`},
+		{[]string{"-t=10", "-m=100", "-y=0"}, "the regular-contributions-period must be greater than zero"},
+		{[]string{"-t=10", "--paths=0"}, "the paths must be greater than zero"},
+		{[]string{"-t=10", "--workers=0"}, "the workers must be greater than zero"},
+		{[]string{"-t=10", "--volatility=-1"}, "the volatility cannot be negative"},
+		{[]string{"-t=10", "--expected-return=-100"}, "the expected-return must be greater than -100"},
+		{[]string{"-t=10", "--distribution=uniform"}, `invalid distribution "uniform", must be normal, lognormal or bootstrap`},
+		{[]string{"-t=10", "--distribution=bootstrap"}, "the returns-file is required by the bootstrap distribution"},
+		{[]string{"-t=10", "--returns-file=returns.csv"}, "the returns-file can only be used with the bootstrap distribution"},
+		{[]string{"-p=10000", "-t=2000", "--expected-return=100", "--volatility=1", "--paths=10"}, "the balances are too large, try a shorter time or smaller returns"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewMontecarloCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(tc.args)
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.EqualError(t, err, tc.expected, tc.args)
+	}
+}
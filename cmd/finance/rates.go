@@ -0,0 +1,156 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/renato0307/canivete/pkg/decimal"
+	"gopkg.in/yaml.v2"
+)
+
+// indexValue is the value of a rate index (e.g. Euribor), as a percentage,
+// from a payment period on.
+type indexValue struct {
+	period int
+	value  decimal.Decimal
+}
+
+// indexFileEntry is an entry of a YAML index file.
+type indexFileEntry struct {
+	Period int     `yaml:"period"`
+	Index  float64 `yaml:"index"`
+}
+
+// readIndexValues reads the index values of a YAML file, with a list of
+// period and index entries, or of a CSV file, with period,index lines and
+// an optional header. The values are sorted by period.
+func readIndexValues(path string) ([]indexValue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []indexValue
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".yaml" || ext == ".yml" {
+		values, err = parseIndexYaml(data)
+	} else {
+		values, err = parseIndexCsv(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid index file %q: %w", path, err)
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].period < values[j].period
+	})
+
+	return values, nil
+}
+
+func parseIndexYaml(data []byte) ([]indexValue, error) {
+	entries := []indexFileEntry{}
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+
+	values := []indexValue{}
+	for _, entry := range entries {
+		if entry.Period < 1 {
+			return nil, fmt.Errorf("invalid period %d, must be a positive integer", entry.Period)
+		}
+		values = append(values, indexValue{period: entry.Period, value: decimal.NewFromFloat(entry.Index)})
+	}
+
+	return values, nil
+}
+
+func parseIndexCsv(data []byte) ([]indexValue, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	values := []indexValue{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		period, err := strconv.Atoi(record[0])
+		if err != nil && line == 1 {
+			// the header
+			continue
+		}
+		if err != nil || period < 1 {
+			return nil, fmt.Errorf("line %d: invalid period %q, must be a positive integer", line, record[0])
+		}
+		value, err := decimal.Parse(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		values = append(values, indexValue{period: period, value: value})
+	}
+
+	return values, nil
+}
+
+// variableRates returns the annual rates of each period, as fractions, of a
+// loan whose rate is an index plus a spread, reset every number of periods.
+// The index of a reset is the value of the latest period up to it, the
+// reset period included, or the initial index. The shock is added to the
+// index of every reset but the one of the first period, whose rate is known,
+// and the rates can't be negative.
+func variableRates(periods, resetPeriods int, index decimal.Decimal, values []indexValue, spread, shock decimal.Decimal) []decimal.Decimal {
+	rates := []decimal.Decimal{}
+	var rate decimal.Decimal
+	for period := 1; period <= periods; period++ {
+		if (period-1)%resetPeriods == 0 {
+			value := index
+			for _, v := range values {
+				if v.period <= period {
+					value = v.value
+				}
+			}
+			if period > 1 {
+				value = value.Add(shock)
+			}
+
+			rate = value.Add(spread)
+			if rate.Sign() < 0 {
+				rate = decimal.Zero
+			}
+			rate = rate.Div(decimal.Hundred)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates
+}
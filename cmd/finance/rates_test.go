@@ -0,0 +1,118 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/renato0307/canivete/pkg/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeIndexFile writes an index file in a temporary directory.
+func writeIndexFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadIndexValues(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{"euribor.csv", "period,index\n13, 2.5\n1,3.125\n"},
+		{"euribor.txt", "1,3.125\n13,2.5\n"},
+		{"euribor.yaml", "- period: 13\n  index: 2.5\n- period: 1\n  index: 3.125\n"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		path := writeIndexFile(t, tc.name, tc.content)
+
+		// act
+		values, err := readIndexValues(path)
+
+		// assert
+		assert.Nil(t, err, tc.name)
+		assert.Len(t, values, 2, tc.name)
+		assert.Equal(t, 1, values[0].period, tc.name)
+		assert.Equal(t, "3.125", values[0].value.String(), tc.name)
+		assert.Equal(t, 13, values[1].period, tc.name)
+		assert.Equal(t, "2.5", values[1].value.String(), tc.name)
+	}
+}
+
+func TestReadIndexValuesErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"a.csv", "1,3\n0,2\n", `: line 2: invalid period "0", must be a positive integer`},
+		{"b.csv", "1,abc\n", `: line 1: invalid decimal "abc"`},
+		{"c.csv", "1,2,3\n", ": record on line 1: wrong number of fields"},
+		{"d.yml", "- period: -1\n  index: 2\n", ": invalid period -1, must be a positive integer"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		path := writeIndexFile(t, tc.name, tc.content)
+
+		// act
+		_, err := readIndexValues(path)
+
+		// assert
+		assert.EqualError(t, err, `invalid index file "`+path+`"`+tc.expected, tc.name)
+	}
+}
+
+func TestVariableRatesValueAtReset(t *testing.T) {
+	// arrange: reset every 4 periods, in periods 1, 5 and 9
+	values := []indexValue{{period: 5, value: decimal.NewFromInt(3)}, {period: 6, value: decimal.NewFromInt(4)}}
+
+	// act
+	rates := variableRates(9, 4, decimal.NewFromInt(2), values, decimal.Zero, decimal.Zero)
+
+	// assert: the value of period 5 is used by its reset, the one of
+	// period 6 only by the next
+	expected := []string{"0.02", "0.02", "0.02", "0.02", "0.03", "0.03", "0.03", "0.03", "0.04"}
+	for i := range rates {
+		assert.Equal(t, expected[i], rates[i].String(), i+1)
+	}
+}
+
+func TestVariableRates(t *testing.T) {
+	// arrange: reset every 2 periods
+	values := []indexValue{{period: 3, value: decimal.NewFromInt(-2)}, {period: 6, value: decimal.NewFromInt(4)}}
+
+	// act
+	rates := variableRates(8, 2, decimal.NewFromInt(2), values, decimal.NewFromInt(1), decimal.Zero)
+	shocked := variableRates(8, 2, decimal.NewFromInt(2), values, decimal.NewFromInt(1), decimal.NewFromInt(2))
+
+	// assert
+	expected := []string{"0.03", "0.03", "0", "0", "0", "0", "0.05", "0.05"}
+	expectedShocked := []string{"0.03", "0.03", "0.01", "0.01", "0.01", "0.01", "0.07", "0.07"}
+	for i := range rates {
+		assert.Equal(t, expected[i], rates[i].String(), i+1)
+		assert.Equal(t, expectedShocked[i], shocked[i].String(), i+1)
+	}
+}
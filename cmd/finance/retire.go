@@ -0,0 +1,355 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/renato0307/canivete/pkg/decimal"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/spf13/cobra"
+)
+
+type retireYearOutput struct {
+	// Age is the age at the beginning of the year
+	Age            int
+	Phase          string
+	OpeningBalance decimal.Decimal
+	Contributions  decimal.Decimal
+	Withdrawal     decimal.Decimal
+	// Returns are the interests, net of fees and taxes
+	Returns        decimal.Decimal
+	ClosingBalance decimal.Decimal
+	// RealClosingBalance is the closing balance in today's money
+	RealClosingBalance decimal.Decimal
+}
+
+type retireOutput struct {
+	Strategy string
+	// RetirementBalance is the balance at retirement, after the gains tax
+	// due on withdrawal
+	RetirementBalance     decimal.Decimal
+	RealRetirementBalance decimal.Decimal
+	// SurvivalYears are the retirement years with the full withdrawal
+	SurvivalYears int
+	Survives      bool
+	DepletionAge  *int `json:",omitempty"`
+	// SafeWithdrawalRate is the highest percentage of the retirement
+	// balance that can be withdrawn in the first year, and then adjusted
+	// to inflation, until the life expectancy
+	SafeWithdrawalRate decimal.Decimal
+	// SafeWithdrawal is the annual withdrawal of the safe withdrawal rate,
+	// in today's money
+	SafeWithdrawal decimal.Decimal
+	Years          []retireYearOutput
+}
+
+const flagAge = "age"
+const flagRetirementAge = "retirement-age"
+const flagLifeExpectancy = "life-expectancy"
+const flagStrategy = "strategy"
+const flagWithdrawal = "withdrawal"
+const flagWithdrawalRate = "withdrawal-rate"
+const flagRetirementReturn = "retirement-return"
+const flagGuardrail = "guardrail"
+const flagGuardrailAdjustment = "guardrail-adjustment"
+
+const strategyFixed = "fixed"
+const strategyPercent = "percent"
+const strategyGuardrails = "guardrails"
+
+const phaseAccumulation = "accumulation"
+const phaseDecumulation = "decumulation"
+
+// retireParams are the parameters of a retirement plan, with the rates as
+// fractions.
+type retireParams struct {
+	accumulation     compoundInterestsParams
+	age              int
+	retirementAge    int
+	lifeExpectancy   int
+	strategy         string
+	withdrawal       decimal.Decimal
+	withdrawalRate   decimal.Decimal
+	retirementReturn decimal.Decimal
+	// guardrail is how far the withdrawal rate can move from the initial
+	// one before the withdrawal is adjusted
+	guardrail           decimal.Decimal
+	guardrailAdjustment decimal.Decimal
+}
+
+func NewRetireCmd(iostreams iostreams.IOStreams) *cobra.Command {
+	var retireCmd = &cobra.Command{
+		Use:   "retire",
+		Short: "Plans a retirement, with its accumulation and withdrawal phases",
+		Long: heredoc.Doc(`
+			Plans a retirement (e.g. FIRE), with an accumulation phase, from
+			the age until the retirement age, followed by a decumulation
+			phase, until the life expectancy.
+
+			The accumulation is calculated as compoundinterests does, with
+			its parameters, except the time. The decumulation starts with the
+			balance at retirement after the gains tax due on withdrawal, and
+			withdraws at the beginning of each year, with a strategy:
+
+				fixed       the withdrawal, in today's money, adjusted to inflation
+				percent     the withdrawal rate of the balance
+				guardrails  the withdrawal rate of the balance at retirement,
+				            adjusted to inflation, but cut by the guardrail
+				            adjustment when the rate goes above the guardrail
+				            around the initial rate, and raised by it when
+				            the rate goes below it (Guyton-Klinger)
+
+			The balance earns the retirement return, the interest rate by
+			default. The survival years are the retirement years with the
+			full withdrawal. The safe withdrawal rate is the highest initial
+			rate of the balance at retirement that, adjusted to inflation,
+			lasts until the life expectancy.
+		`),
+		Example: heredoc.Doc(`
+			canivete finance retire --age 30 --retirement-age 50 -p 20000 -m 1500 -r 6 -n 12 --inflation 2 --withdrawal 30000
+			canivete finance retire --age 40 --retirement-age 60 -p 100000 -m 1000 -r 5 -n 12 --strategy percent --withdrawal-rate 4
+			canivete finance retire --age 35 --retirement-age 55 -p 50000 -m 2000 -r 7 -n 12 --inflation 2.5 --strategy guardrails --withdrawal-rate 5 --retirement-return 4
+		`),
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params, err := getRetireParams(cmd)
+			if err != nil {
+				return err
+			}
+
+			output := planRetirement(params)
+			return iostreams.PrintOutput(output)
+		},
+	}
+
+	addCompoundInterestsFlags(retireCmd)
+	retireCmd.MarkFlagRequired(flagAnnualInterestRate)
+	retireCmd.MarkFlagRequired(flagCompoundPeriods)
+
+	retireCmd.Flags().Int(flagAge, 0, "the current age")
+	retireCmd.MarkFlagRequired(flagAge)
+	retireCmd.Flags().Int(flagRetirementAge, 0, "the age of the retirement")
+	retireCmd.MarkFlagRequired(flagRetirementAge)
+	retireCmd.Flags().Int(flagLifeExpectancy, 95, "the age until which the withdrawals are needed")
+	retireCmd.Flags().String(flagStrategy, strategyFixed, "the withdrawal strategy: fixed, percent or guardrails")
+	retireCmd.Flags().String(flagWithdrawal, "", "the annual withdrawal in today's money, for the fixed strategy")
+	retireCmd.Flags().String(flagWithdrawalRate, "", "the annual withdrawal rate, for the percent and guardrails strategies (decimal, percentage)")
+	retireCmd.Flags().String(flagRetirementReturn, "", "the annual return during the retirement, the annual-interest-rate by default (decimal, percentage)")
+	retireCmd.Flags().String(flagGuardrail, "20", "how far the withdrawal rate can move from the initial one, for the guardrails strategy (decimal, percentage)")
+	retireCmd.Flags().String(flagGuardrailAdjustment, "10", "how much the withdrawal is cut or raised at a guardrail (decimal, percentage)")
+
+	return retireCmd
+}
+
+// getRetireParams returns the parameters of a retirement plan given by the
+// flags.
+func getRetireParams(cmd *cobra.Command) (retireParams, error) {
+	age, _ := cmd.Flags().GetInt(flagAge)
+	retirementAge, _ := cmd.Flags().GetInt(flagRetirementAge)
+	lifeExpectancy, _ := cmd.Flags().GetInt(flagLifeExpectancy)
+	strategy, _ := cmd.Flags().GetString(flagStrategy)
+
+	values, err := getFlagDecimals(cmd, flagWithdrawal, flagWithdrawalRate, flagRetirementReturn, flagGuardrail, flagGuardrailAdjustment)
+	if err != nil {
+		return retireParams{}, err
+	}
+	withdrawal, withdrawalRate, retirementReturn := values[0], values[1], values[2]
+	guardrail, guardrailAdjustment := values[3], values[4]
+
+	if cmd.Flags().Changed(flagTime) {
+		return retireParams{}, fmt.Errorf("the time is given by the age and the retirement-age")
+	}
+	if age < 0 {
+		return retireParams{}, fmt.Errorf("the age cannot be negative")
+	}
+	if retirementAge < age {
+		return retireParams{}, fmt.Errorf("the retirement-age cannot be before the age")
+	}
+	if lifeExpectancy <= retirementAge {
+		return retireParams{}, fmt.Errorf("the life-expectancy must be after the retirement-age")
+	}
+	switch strategy {
+	case strategyFixed:
+		if withdrawal.Sign() <= 0 {
+			return retireParams{}, fmt.Errorf("the withdrawal is required by the fixed strategy")
+		}
+	case strategyPercent, strategyGuardrails:
+		if withdrawalRate.Sign() <= 0 {
+			return retireParams{}, fmt.Errorf("the withdrawal-rate is required by the %s strategy", strategy)
+		}
+	default:
+		return retireParams{}, fmt.Errorf("invalid strategy %q, must be fixed, percent or guardrails", strategy)
+	}
+	if guardrail.Sign() < 0 || guardrailAdjustment.Sign() < 0 || guardrailAdjustment.Cmp(decimal.Hundred) > 0 {
+		return retireParams{}, fmt.Errorf("the guardrail and guardrail-adjustment must be between 0 and 100")
+	}
+
+	accumulation, err := getCompoundInterestsParams(cmd)
+	if err != nil {
+		return retireParams{}, err
+	}
+	accumulation.years = decimal.NewFromInt(int64(retirementAge - age))
+	if err := checkLumpSums(accumulation); err != nil {
+		return retireParams{}, err
+	}
+
+	params := retireParams{
+		accumulation:        accumulation,
+		age:                 age,
+		retirementAge:       retirementAge,
+		lifeExpectancy:      lifeExpectancy,
+		strategy:            strategy,
+		withdrawal:          withdrawal,
+		withdrawalRate:      withdrawalRate.Div(decimal.Hundred),
+		retirementReturn:    accumulation.rate,
+		guardrail:           guardrail.Div(decimal.Hundred),
+		guardrailAdjustment: guardrailAdjustment.Div(decimal.Hundred),
+	}
+	if cmd.Flags().Changed(flagRetirementReturn) {
+		params.retirementReturn = retirementReturn.Div(decimal.Hundred)
+	}
+	if params.retirementReturn.Cmp(decimal.One.Neg()) <= 0 {
+		return retireParams{}, fmt.Errorf("the retirement-return, the annual-interest-rate by default, must be greater than -100")
+	}
+
+	return params, nil
+}
+
+// planRetirement returns the balances of each year of a retirement plan,
+// with the accumulation calculated by the compound interests schedule.
+func planRetirement(params retireParams) retireOutput {
+	accumulation := params.accumulation
+	rounding := accumulation.rounding
+	inflation := decimal.One.Add(accumulation.inflation)
+
+	output := retireOutput{
+		Strategy: params.strategy,
+		Years:    []retireYearOutput{},
+	}
+
+	history := run(accumulation, 1).History
+	for i, entry := range history {
+		output.Years = append(output.Years, retireYearOutput{
+			Age:                params.age + i,
+			Phase:              phaseAccumulation,
+			OpeningBalance:     entry.OpeningBalance,
+			Contributions:      entry.Contributions,
+			Withdrawal:         decimal.Zero,
+			Returns:            entry.Interests.Sub(entry.Fees).Sub(entry.Taxes),
+			ClosingBalance:     entry.ClosingBalance,
+			RealClosingBalance: roundTwoDecimalPlaces(deflate(entry.ClosingBalance, accumulation.inflation, decimal.NewFromInt(int64(i+1))), rounding),
+		})
+	}
+
+	retirement := calculateValues(accumulation)
+	output.RetirementBalance = retirement.NetAmount
+	output.RealRetirementBalance = retirement.RealAmount
+
+	years := params.lifeExpectancy - params.retirementAge
+	output.SafeWithdrawalRate = safeWithdrawalRate(accumulation.inflation, params.retirementReturn, years)
+	output.SafeWithdrawal = roundTwoDecimalPlaces(
+		output.RealRetirementBalance.Mul(output.SafeWithdrawalRate).Div(decimal.Hundred), rounding)
+
+	growth := decimal.One.Add(params.retirementReturn)
+	balance := retirement.NetAmount
+	withdrawal := decimal.Zero
+	output.Survives = true
+	for year := 0; year < years; year++ {
+		// the years since today, for the inflation
+		elapsed := params.retirementAge - params.age + year
+
+		switch params.strategy {
+		case strategyFixed:
+			withdrawal = params.withdrawal.Mul(inflation.Pow(elapsed))
+		case strategyPercent:
+			withdrawal = balance.Mul(params.withdrawalRate)
+		case strategyGuardrails:
+			withdrawal = guardrailsWithdrawal(params, balance, withdrawal, year == 0)
+		}
+
+		opening := balance
+		if withdrawal.Cmp(balance) > 0 {
+			withdrawal = balance
+			if output.Survives {
+				output.Survives = false
+				depletionAge := params.retirementAge + year
+				output.DepletionAge = &depletionAge
+			}
+		}
+		if output.Survives {
+			output.SurvivalYears++
+		}
+		balance = balance.Sub(withdrawal)
+		returns := balance.Mul(params.retirementReturn)
+		balance = balance.Mul(growth).Round(balancePlaces, decimal.HalfEven)
+
+		closing := roundTwoDecimalPlaces(balance, rounding)
+		output.Years = append(output.Years, retireYearOutput{
+			Age:                params.retirementAge + year,
+			Phase:              phaseDecumulation,
+			OpeningBalance:     roundTwoDecimalPlaces(opening, rounding),
+			Contributions:      decimal.Zero,
+			Withdrawal:         roundTwoDecimalPlaces(withdrawal, rounding),
+			Returns:            roundTwoDecimalPlaces(returns, rounding),
+			ClosingBalance:     closing,
+			RealClosingBalance: roundTwoDecimalPlaces(deflate(balance, accumulation.inflation, decimal.NewFromInt(int64(elapsed+1))), rounding),
+		})
+	}
+
+	return output
+}
+
+// guardrailsWithdrawal returns the withdrawal of a year of the guardrails
+// strategy, given the withdrawal of the previous year.
+func guardrailsWithdrawal(params retireParams, balance, previous decimal.Decimal, first bool) decimal.Decimal {
+	if first {
+		return balance.Mul(params.withdrawalRate)
+	}
+
+	withdrawal := previous.Mul(decimal.One.Add(params.accumulation.inflation))
+	if balance.Sign() <= 0 {
+		return withdrawal
+	}
+
+	rate := withdrawal.Div(balance)
+	upper := params.withdrawalRate.Mul(decimal.One.Add(params.guardrail))
+	lower := params.withdrawalRate.Mul(decimal.One.Sub(params.guardrail))
+	switch {
+	case rate.Cmp(upper) > 0:
+		withdrawal = withdrawal.Mul(decimal.One.Sub(params.guardrailAdjustment))
+	case rate.Cmp(lower) < 0:
+		withdrawal = withdrawal.Mul(decimal.One.Add(params.guardrailAdjustment))
+	}
+	return withdrawal
+}
+
+// safeWithdrawalRate returns, as a percentage, the highest rate of a
+// balance that can be withdrawn at the beginning of a number of years,
+// adjusted to inflation, while earning a return. It's the inverse of the
+// present value of the withdrawals, a growing annuity-due,
+//
+//	sum of ((1+inflation) / (1+return))^k for k in 0..years-1
+func safeWithdrawalRate(inflation, retirementReturn decimal.Decimal, years int) decimal.Decimal {
+	ratio := decimal.One.Add(inflation).Div(decimal.One.Add(retirementReturn))
+	presentValue := decimal.Zero
+	for k := 0; k < years; k++ {
+		presentValue = presentValue.Add(ratio.Pow(k))
+	}
+	return decimal.Hundred.Div(presentValue).Round(4, decimal.HalfEven)
+}
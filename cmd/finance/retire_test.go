@@ -0,0 +1,160 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/renato0307/canivete/pkg/decimal"
+	"github.com/renato0307/canivete/pkg/iostreams"
+	"github.com/stretchr/testify/assert"
+)
+
+var retireArgs = []string{"--age=60", "--retirement-age=62", "--life-expectancy=66", "-p=100000", "-r=5", "-n=1", "--inflation=2"}
+
+func executeRetireCmd(t *testing.T, args ...string) retireOutput {
+	iostreams, _, out, _ := iostreams.Test()
+	cmd := NewRetireCmd(*iostreams)
+	cmd.SetArgs(append(append([]string{}, retireArgs...), args...))
+	if _, err := cmd.ExecuteC(); err != nil {
+		t.Fatal(err)
+	}
+
+	output := retireOutput{}
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &output))
+	return output
+}
+
+func TestRetireCmd(t *testing.T) {
+	// act
+	output := executeRetireCmd(t, "--withdrawal=10000")
+
+	// assert
+	assert.Equal(t, "fixed", output.Strategy)
+	assert.Equal(t, "110250", output.RetirementBalance.String())
+	assert.Equal(t, "105968.86", output.RealRetirementBalance.String())
+	assert.Equal(t, 4, output.SurvivalYears)
+	assert.True(t, output.Survives)
+	assert.Nil(t, output.DepletionAge)
+	assert.Equal(t, "26.0973", output.SafeWithdrawalRate.String())
+	assert.Equal(t, "27655.01", output.SafeWithdrawal.String())
+
+	expected := `{
+		"Age": 62,
+		"Phase": "decumulation",
+		"OpeningBalance": 110250,
+		"Contributions": 0,
+		"Withdrawal": 10404,
+		"Returns": 4992.3,
+		"ClosingBalance": 104838.3,
+		"RealClosingBalance": 98791.47
+	}`
+	assert.Len(t, output.Years, 6)
+	assert.Equal(t, phaseAccumulation, output.Years[1].Phase)
+	assert.Equal(t, "110250", output.Years[1].ClosingBalance.String())
+	actual, _ := json.Marshal(output.Years[2])
+	assert.JSONEq(t, expected, string(actual))
+}
+
+func TestRetireCmdSafeWithdrawalLastsUntilLifeExpectancy(t *testing.T) {
+	// act
+	output := executeRetireCmd(t, "--withdrawal=27655.01")
+
+	// assert
+	assert.True(t, output.Survives)
+	assert.Equal(t, "0.03", output.Years[5].ClosingBalance.String())
+}
+
+func TestRetireCmdStrategies(t *testing.T) {
+	testCases := []struct {
+		args        []string
+		withdrawals []string
+	}{
+		{[]string{"--strategy=percent", "--withdrawal-rate=10"}, []string{"11025", "10418.62", "9845.6", "9304.09"}},
+		{[]string{"--strategy=guardrails", "--withdrawal-rate=20", "--retirement-return=0"}, []string{"22050", "20241.9", "18582.06", "17058.33"}},
+		{[]string{"--strategy=guardrails", "--withdrawal-rate=4", "--retirement-return=40"}, []string{"4410", "4948.02", "5551.68", "6228.98"}},
+	}
+
+	for _, tc := range testCases {
+		// act
+		output := executeRetireCmd(t, tc.args...)
+
+		// assert
+		withdrawals := []string{}
+		for _, year := range output.Years[2:] {
+			withdrawals = append(withdrawals, year.Withdrawal.String())
+		}
+		assert.Equal(t, tc.withdrawals, withdrawals, tc.args)
+	}
+}
+
+func TestRetireCmdDepletion(t *testing.T) {
+	// act
+	output := executeRetireCmd(t, "--withdrawal=40000")
+
+	// assert
+	assert.False(t, output.Survives)
+	assert.Equal(t, 2, output.SurvivalYears)
+	assert.Equal(t, 64, *output.DepletionAge)
+	assert.Equal(t, "31098.25", output.Years[4].Withdrawal.String())
+	assert.Equal(t, "0", output.Years[5].Withdrawal.String())
+	assert.Equal(t, "0", output.Years[5].ClosingBalance.String())
+}
+
+func TestSafeWithdrawalRate(t *testing.T) {
+	// act
+	withoutReturns := safeWithdrawalRate(decimal.Zero, decimal.Zero, 25)
+	sameAsInflation := safeWithdrawalRate(decimal.New(3, -2), decimal.New(3, -2), 40)
+
+	// assert
+	assert.Equal(t, "4", withoutReturns.String())
+	assert.Equal(t, "2.5", sameAsInflation.String())
+}
+
+func TestRetireCmdErrors(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--age=30", "--retirement-age=50", "--time=20", "--withdrawal=1"}, "the time is given by the age and the retirement-age"},
+		{[]string{"--age=-1", "--retirement-age=50", "--withdrawal=1"}, "the age cannot be negative"},
+		{[]string{"--age=30", "--retirement-age=20", "--withdrawal=1"}, "the retirement-age cannot be before the age"},
+		{[]string{"--age=30", "--retirement-age=50", "--life-expectancy=50", "--withdrawal=1"}, "the life-expectancy must be after the retirement-age"},
+		{[]string{"--age=30", "--retirement-age=50"}, "the withdrawal is required by the fixed strategy"},
+		{[]string{"--age=30", "--retirement-age=50", "--strategy=guardrails"}, "the withdrawal-rate is required by the guardrails strategy"},
+		{[]string{"--age=30", "--retirement-age=50", "--strategy=annuity"}, `invalid strategy "annuity", must be fixed, percent or guardrails`},
+		{[]string{"--age=30", "--retirement-age=50", "--withdrawal=1", "--retirement-return=-100"}, "the retirement-return, the annual-interest-rate by default, must be greater than -100"},
+		{[]string{"--age=30", "--retirement-age=31", "--life-expectancy=33", "-p=1000", "-r=-100", "--withdrawal=100"}, "the retirement-return, the annual-interest-rate by default, must be greater than -100"},
+		{[]string{"--age=30", "--retirement-age=50", "--withdrawal=1", "--guardrail-adjustment=150"}, "the guardrail and guardrail-adjustment must be between 0 and 100"},
+		{[]string{"--age=30", "--retirement-age=50", "--withdrawal=1k"}, `invalid withdrawal "1k", must be a number`},
+		{[]string{"--age=30", "--retirement-age=31", "--withdrawal=1", "--lump-sum=2:100"}, "the lump sum period 2 is after the last period (1)"},
+	}
+
+	for _, tc := range testCases {
+		// arrange
+		iostreams, _, _, _ := iostreams.Test()
+		cmd := NewRetireCmd(*iostreams)
+
+		// act
+		cmd.SetArgs(append([]string{"-r=5", "-n=1"}, tc.args...))
+		_, err := cmd.ExecuteC()
+
+		// assert
+		assert.EqualError(t, err, tc.expected, tc.args)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"errors"
+	"math"
+)
+
+// solverTolerance is the precision of the roots found.
+const solverTolerance = 1e-10
+
+const solverMaxIterations = 200
+
+var errNoRoot = errors.New("no root found")
+
+// findRoot finds a root of f in [lo, hi] with the Newton-Raphson method,
+// starting from a guess. When it doesn't converge in the interval, it falls
+// back to bisection, which needs f to have different signs in lo and hi.
+// Without a derivative df, it's approximated with central differences.
+func findRoot(f, df func(float64) float64, guess, lo, hi float64) (float64, error) {
+	if df == nil {
+		df = derivative(f)
+	}
+
+	if x, ok := newton(f, df, guess, lo, hi); ok {
+		return x, nil
+	}
+	return bisect(f, lo, hi)
+}
+
+// newton finds a root of f with the Newton-Raphson method, failing when it
+// leaves [lo, hi] or doesn't converge.
+func newton(f, df func(float64) float64, x, lo, hi float64) (float64, bool) {
+	for i := 0; i < solverMaxIterations; i++ {
+		y := f(x)
+		if math.Abs(y) < solverTolerance {
+			return x, true
+		}
+
+		slope := df(x)
+		if slope == 0 || math.IsNaN(slope) || math.IsInf(slope, 0) {
+			return 0, false
+		}
+		next := x - y/slope
+		if next < lo || next > hi || math.IsNaN(next) {
+			return 0, false
+		}
+		if math.Abs(next-x) < solverTolerance*math.Max(1, math.Abs(x)) {
+			return next, true
+		}
+		x = next
+	}
+	return 0, false
+}
+
+// bisect finds a root of f in [lo, hi], which must have different signs in
+// lo and hi.
+func bisect(f func(float64) float64, lo, hi float64) (float64, error) {
+	flo, fhi := f(lo), f(hi)
+	if flo == 0 {
+		return lo, nil
+	}
+	if fhi == 0 {
+		return hi, nil
+	}
+	if math.Signbit(flo) == math.Signbit(fhi) || math.IsNaN(flo) || math.IsNaN(fhi) {
+		return 0, errNoRoot
+	}
+
+	for i := 0; i < solverMaxIterations && hi-lo > solverTolerance*math.Max(1, math.Abs(lo)); i++ {
+		mid := lo + (hi-lo)/2
+		fmid := f(mid)
+		if fmid == 0 {
+			return mid, nil
+		}
+		if math.Signbit(fmid) == math.Signbit(flo) {
+			lo, flo = mid, fmid
+		} else {
+			hi = mid
+		}
+	}
+	return lo + (hi-lo)/2, nil
+}
+
+// derivative returns the central differences approximation of the
+// derivative of f.
+func derivative(f func(float64) float64) func(float64) float64 {
+	return func(x float64) float64 {
+		h := 1e-6 * math.Max(1, math.Abs(x))
+		return (f(x+h) - f(x-h)) / (2 * h)
+	}
+}
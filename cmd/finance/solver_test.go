@@ -0,0 +1,64 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package finance
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindRoot(t *testing.T) {
+	// arrange
+	f := func(x float64) float64 { return x*x - 2 }
+	df := func(x float64) float64 { return 2 * x }
+
+	// act
+	withDerivative, err := findRoot(f, df, 1, 0, 10)
+	withoutDerivative, errWithout := findRoot(f, nil, 1, 0, 10)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Nil(t, errWithout)
+	assert.InDelta(t, math.Sqrt2, withDerivative, 1e-9)
+	assert.InDelta(t, math.Sqrt2, withoutDerivative, 1e-9)
+}
+
+func TestFindRootFallsBackToBisection(t *testing.T) {
+	// arrange
+	// the derivative of a cube root sends Newton-Raphson away from the root
+	f := func(x float64) float64 { return math.Cbrt(x - 3) }
+
+	// act
+	root, err := findRoot(f, nil, 4, 0, 10)
+
+	// assert
+	assert.Nil(t, err)
+	assert.InDelta(t, 3, root, 1e-8)
+}
+
+func TestFindRootWithoutRoot(t *testing.T) {
+	// arrange
+	f := func(x float64) float64 { return x*x + 1 }
+
+	// act
+	_, err := findRoot(f, nil, 1, -10, 10)
+
+	// assert
+	assert.Equal(t, errNoRoot, err)
+}
@@ -61,9 +61,10 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.canivete.yaml)")
 
 	iostreams := iostreams.IOStreams{
-		ErrOut: os.Stderr,
-		In:     os.Stdin,
-		Out:    os.Stdout,
+		ErrOut:        os.Stderr,
+		In:            os.Stdin,
+		Out:           os.Stdout,
+		OutIsTerminal: iostreams.IsTerminal(os.Stdout),
 	}
 
 	rootCmd.AddCommand(datetime.NewDatetimeCmd(iostreams))
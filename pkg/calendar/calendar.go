@@ -0,0 +1,439 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package calendar
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Rule types
+const (
+	RuleFixed      = "fixed"
+	RuleEaster     = "easter"
+	RuleNthWeekday = "nth-weekday"
+)
+
+// Observed rules, used when a holiday falls on a weekend
+const (
+	ObservedNone       = ""
+	ObservedSubstitute = "substitute"
+	ObservedNearest    = "nearest"
+)
+
+//go:embed rules/*.yaml
+var embeddedRules embed.FS
+
+// Rule describes a holiday.
+//
+// Fixed holidays happen on the same month and day every year, easter
+// holidays are Offset days after Easter Sunday (e.g. -2 for Good Friday)
+// and nth-weekday holidays happen on the Nth weekday of a month (-1 for
+// the last one).
+//
+// When a holiday falls on a weekend the Observed rule can move it:
+// "substitute" moves it to the next free working day and "nearest" moves
+// Saturdays to Friday and Sundays to Monday.
+type Rule struct {
+	Name        string `yaml:"name"`
+	Type        string `yaml:"type"`
+	Month       int    `yaml:"month"`
+	Day         int    `yaml:"day"`
+	Offset      int    `yaml:"offset"`
+	Weekday     string `yaml:"weekday"`
+	Nth         int    `yaml:"nth"`
+	Observed    string `yaml:"observed"`
+	From        int    `yaml:"from"`
+	To          int    `yaml:"to"`
+	ExceptYears []int  `yaml:"exceptYears"`
+}
+
+// Calendar is a set of holiday rules and weekend days. A calendar can
+// extend another one, adding rules to the ones of the parent.
+type Calendar struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Extends     string   `yaml:"extends"`
+	Weekend     []string `yaml:"weekend"`
+	Rules       []Rule   `yaml:"rules"`
+
+	weekend  map[time.Weekday]bool
+	holidays map[int][]Holiday
+	// mu guards the holidays cache, a pointer so calendars can be copied
+	mu *sync.Mutex
+}
+
+// Holiday is a day off on a given date.
+type Holiday struct {
+	Date     time.Time
+	Name     string
+	Observed bool
+}
+
+// Names returns the names of the embedded calendars.
+func Names() []string {
+	entries, _ := embeddedRules.ReadDir("rules")
+
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), path.Ext(entry.Name())))
+	}
+
+	return names
+}
+
+// Get returns a calendar by name, looking first in the custom calendars
+// (e.g. the ones defined in the config file) and then in the embedded ones.
+func Get(name string, custom map[string]Calendar) (*Calendar, error) {
+	return get(strings.ToLower(name), custom, map[string]bool{})
+}
+
+func get(name string, custom map[string]Calendar, visited map[string]bool) (*Calendar, error) {
+	if visited[name] {
+		return nil, fmt.Errorf("calendar %q extends itself", name)
+	}
+	visited[name] = true
+
+	var cal Calendar
+	if customCal, ok := findCustom(name, custom); ok {
+		cal = customCal
+		cal.Name = name
+	} else {
+		data, err := embeddedRules.ReadFile(fmt.Sprintf("rules/%s.yaml", name))
+		if err != nil {
+			return nil, fmt.Errorf("unknown calendar %q, available: %s", name, strings.Join(Names(), ", "))
+		}
+		if err := yaml.Unmarshal(data, &cal); err != nil {
+			return nil, fmt.Errorf("invalid calendar %q: %w", name, err)
+		}
+	}
+
+	if cal.Extends != "" {
+		parent, err := get(strings.ToLower(cal.Extends), custom, visited)
+		if err != nil {
+			return nil, err
+		}
+		cal.Rules = append(append([]Rule{}, parent.Rules...), cal.Rules...)
+		if len(cal.Weekend) == 0 {
+			cal.Weekend = parent.Weekend
+		}
+	}
+
+	if err := cal.init(); err != nil {
+		return nil, err
+	}
+
+	return &cal, nil
+}
+
+func findCustom(name string, custom map[string]Calendar) (Calendar, bool) {
+	for customName, cal := range custom {
+		if strings.EqualFold(customName, name) {
+			return cal, true
+		}
+	}
+	return Calendar{}, false
+}
+
+func (c *Calendar) init() error {
+	if len(c.Weekend) == 0 {
+		c.Weekend = []string{"saturday", "sunday"}
+	}
+
+	c.weekend = map[time.Weekday]bool{}
+	for _, name := range c.Weekend {
+		weekday, err := ParseWeekday(name)
+		if err != nil {
+			return fmt.Errorf("invalid calendar %q: %w", c.Name, err)
+		}
+		c.weekend[weekday] = true
+	}
+	if len(c.weekend) == 7 {
+		return fmt.Errorf("invalid calendar %q: the weekend can't have every day of the week", c.Name)
+	}
+
+	for _, rule := range c.Rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("invalid calendar %q: %w", c.Name, err)
+		}
+	}
+
+	c.holidays = map[int][]Holiday{}
+	c.mu = &sync.Mutex{}
+
+	return nil
+}
+
+func (r Rule) validate() error {
+	switch r.Type {
+	case RuleFixed:
+		if r.Month < 1 || r.Month > 12 || r.Day < 1 || r.Day > 31 {
+			return fmt.Errorf("rule %q must have a valid month and day", r.Name)
+		}
+	case RuleEaster:
+	case RuleNthWeekday:
+		if r.Month < 1 || r.Month > 12 || r.Nth < -1 || r.Nth > 5 || r.Nth == 0 {
+			return fmt.Errorf("rule %q must have a valid month and nth (1 to 5 or -1)", r.Name)
+		}
+		if _, err := ParseWeekday(r.Weekday); err != nil {
+			return fmt.Errorf("rule %q: %w", r.Name, err)
+		}
+	default:
+		return fmt.Errorf("rule %q has an unknown type %q", r.Name, r.Type)
+	}
+
+	switch r.Observed {
+	case ObservedNone, ObservedSubstitute, ObservedNearest:
+	default:
+		return fmt.Errorf("rule %q has an unknown observed rule %q", r.Name, r.Observed)
+	}
+
+	return nil
+}
+
+// ParseWeekday converts an English weekday name (or its first three
+// letters) into a time.Weekday.
+func ParseWeekday(name string) (time.Weekday, error) {
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		full := strings.ToLower(weekday.String())
+		if strings.EqualFold(name, full) || strings.EqualFold(name, full[:3]) {
+			return weekday, nil
+		}
+	}
+
+	return time.Sunday, fmt.Errorf("invalid weekday %q", name)
+}
+
+// Easter returns Easter Sunday of a year in the Gregorian calendar, using
+// the anonymous Gregorian algorithm (Meeus/Jones/Butcher).
+func Easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// date returns the date of the rule in a year, if the rule applies.
+func (r Rule) date(year int) (time.Time, bool) {
+	if (r.From > 0 && year < r.From) || (r.To > 0 && year > r.To) {
+		return time.Time{}, false
+	}
+	for _, except := range r.ExceptYears {
+		if except == year {
+			return time.Time{}, false
+		}
+	}
+
+	switch r.Type {
+	case RuleFixed:
+		if r.Day > daysIn(year, time.Month(r.Month)) {
+			return time.Time{}, false
+		}
+		return time.Date(year, time.Month(r.Month), r.Day, 0, 0, 0, 0, time.UTC), true
+	case RuleEaster:
+		return Easter(year).AddDate(0, 0, r.Offset), true
+	case RuleNthWeekday:
+		weekday, _ := ParseWeekday(r.Weekday)
+		return NthWeekday(year, time.Month(r.Month), weekday, r.Nth).AddDate(0, 0, r.Offset), true
+	}
+
+	return time.Time{}, false
+}
+
+// NthWeekday returns the nth weekday of a month, or the last one when nth
+// is -1.
+func NthWeekday(year int, month time.Month, weekday time.Weekday, nth int) time.Time {
+	if nth == -1 {
+		last := time.Date(year, month, daysIn(year, month), 0, 0, 0, 0, time.UTC)
+		return last.AddDate(0, 0, -((int(last.Weekday()) - int(weekday) + 7) % 7))
+	}
+
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return first.AddDate(0, 0, (int(weekday)-int(first.Weekday())+7)%7+(nth-1)*7)
+}
+
+// Holidays returns the holidays of a year, sorted by date. It's safe for
+// concurrent use.
+func (c *Calendar) Holidays(year int) []Holiday {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if holidays, ok := c.holidays[year]; ok {
+		return holidays
+	}
+
+	// observed rules can move holidays across years (e.g. US New Year's
+	// Day on a Saturday is observed on December 31), so the neighbour
+	// years are also calculated
+	all := []Holiday{}
+	for y := year - 1; y <= year+1; y++ {
+		all = append(all, c.holidaysWithObserved(y)...)
+	}
+
+	holidays := []Holiday{}
+	for _, holiday := range all {
+		if holiday.Date.Year() == year {
+			holidays = append(holidays, holiday)
+		}
+	}
+
+	c.holidays[year] = holidays
+
+	return holidays
+}
+
+func (c *Calendar) holidaysWithObserved(year int) []Holiday {
+	holidays := []Holiday{}
+	taken := map[time.Time]bool{}
+	moved := []Rule{}
+
+	for _, rule := range c.Rules {
+		date, ok := rule.date(year)
+		if !ok {
+			continue
+		}
+		if rule.Observed != ObservedNone && c.weekend[date.Weekday()] {
+			moved = append(moved, rule)
+			continue
+		}
+		holidays = append(holidays, Holiday{Date: date, Name: rule.Name})
+		taken[date] = true
+	}
+
+	sort.SliceStable(moved, func(i, j int) bool {
+		di, _ := moved[i].date(year)
+		dj, _ := moved[j].date(year)
+		return di.Before(dj)
+	})
+
+	for _, rule := range moved {
+		date, _ := rule.date(year)
+		holidays = append(holidays, Holiday{Date: date, Name: rule.Name})
+
+		observed := date
+		if rule.Observed == ObservedNearest && date.Weekday() == time.Saturday {
+			observed = date.AddDate(0, 0, -1)
+		} else {
+			for c.weekend[observed.Weekday()] || taken[observed] {
+				observed = observed.AddDate(0, 0, 1)
+			}
+		}
+		holidays = append(holidays, Holiday{Date: observed, Name: rule.Name + " (observed)", Observed: true})
+		taken[observed] = true
+	}
+
+	sort.SliceStable(holidays, func(i, j int) bool { return holidays[i].Date.Before(holidays[j].Date) })
+
+	return holidays
+}
+
+// Holiday returns the holiday on a date, if any. Holidays falling on a
+// weekend and observed on another day are returned for both days, the
+// second one as Observed.
+func (c *Calendar) Holiday(date time.Time) (Holiday, bool) {
+	date = Date(date)
+	for _, holiday := range c.Holidays(date.Year()) {
+		if holiday.Date.Equal(date) {
+			return holiday, true
+		}
+	}
+
+	return Holiday{}, false
+}
+
+// IsWeekend tells if a date is a weekend day.
+func (c *Calendar) IsWeekend(date time.Time) bool {
+	return c.weekend[date.Weekday()]
+}
+
+// IsWorkday tells if a date is neither a weekend day nor a holiday.
+func (c *Calendar) IsWorkday(date time.Time) bool {
+	if c.IsWeekend(date) {
+		return false
+	}
+	_, isHoliday := c.Holiday(date)
+	return !isHoliday
+}
+
+// AddWorkdays adds n working days to a date, not counting the date
+// itself. A negative n goes back in time.
+func (c *Calendar) AddWorkdays(date time.Time, n int) time.Time {
+	date = Date(date)
+
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+
+	for n > 0 {
+		date = date.AddDate(0, 0, step)
+		if c.IsWorkday(date) {
+			n--
+		}
+	}
+
+	return date
+}
+
+// WorkdaysBetween counts the working days from a date (included) to
+// another (excluded). The result is negative when to is before from.
+func (c *Calendar) WorkdaysBetween(from, to time.Time) int {
+	from, to = Date(from), Date(to)
+
+	sign := 1
+	if to.Before(from) {
+		from, to, sign = to, from, -1
+	}
+
+	count := 0
+	for date := from; date.Before(to); date = date.AddDate(0, 0, 1) {
+		if c.IsWorkday(date) {
+			count++
+		}
+	}
+
+	return count * sign
+}
+
+// Date returns the date of t, in its location, as midnight UTC.
+func Date(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,207 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package calendar
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestEaster(t *testing.T) {
+	testCases := map[int]time.Time{
+		2019: date(2019, time.April, 21),
+		2022: date(2022, time.April, 17),
+		2024: date(2024, time.March, 31),
+		2038: date(2038, time.April, 25),
+	}
+
+	for year, expected := range testCases {
+		// act
+		easter := Easter(year)
+
+		// assert
+		assert.Equal(t, expected, easter, year)
+	}
+}
+
+func TestNthWeekday(t *testing.T) {
+	// act
+	thanksgiving := NthWeekday(2022, time.November, time.Thursday, 4)
+	memorialDay := NthWeekday(2022, time.May, time.Monday, -1)
+
+	// assert
+	assert.Equal(t, date(2022, time.November, 24), thanksgiving)
+	assert.Equal(t, date(2022, time.May, 30), memorialDay)
+}
+
+func TestHolidaysPortugal(t *testing.T) {
+	// arrange
+	cal, err := Get("pt", nil)
+	assert.Nil(t, err)
+
+	// act
+	holidays2022 := cal.Holidays(2022)
+	holidays2014 := cal.Holidays(2014)
+
+	// assert
+	assert.Len(t, holidays2022, 14)
+	assert.Equal(t, Holiday{Date: date(2022, time.March, 1), Name: "Carnival"}, holidays2022[1])
+	assert.Equal(t, Holiday{Date: date(2022, time.June, 16), Name: "Corpus Christi"}, holidays2022[7])
+	assert.Len(t, holidays2014, 10)
+}
+
+func TestHolidaysObservedSubstitute(t *testing.T) {
+	// arrange
+	cal, _ := Get("gb", nil)
+
+	// act
+	_, christmasObserved := cal.Holiday(date(2021, time.December, 27))
+	_, boxingDayObserved := cal.Holiday(date(2021, time.December, 28))
+
+	// assert
+	assert.True(t, christmasObserved)
+	assert.True(t, boxingDayObserved)
+	assert.False(t, cal.IsWorkday(date(2021, time.December, 28)))
+	assert.True(t, cal.IsWorkday(date(2021, time.December, 29)))
+}
+
+func TestHolidayOnWeekendAndObservedDay(t *testing.T) {
+	// arrange
+	cal, _ := Get("gb", nil)
+
+	// act: Christmas 2021 is on a Saturday
+	christmas, christmasOk := cal.Holiday(date(2021, time.December, 25))
+	observed, observedOk := cal.Holiday(date(2021, time.December, 27))
+
+	// assert
+	assert.True(t, christmasOk)
+	assert.True(t, observedOk)
+	assert.Equal(t, Holiday{Date: date(2021, time.December, 25), Name: "Christmas Day"}, christmas)
+	assert.Equal(t, Holiday{Date: date(2021, time.December, 27), Name: "Christmas Day (observed)", Observed: true}, observed)
+}
+
+func TestHolidaysConcurrentUse(t *testing.T) {
+	// arrange
+	cal, _ := Get("pt", nil)
+
+	// act
+	var wg sync.WaitGroup
+	for year := 2000; year < 2050; year++ {
+		wg.Add(1)
+		go func(year int) {
+			defer wg.Done()
+			cal.Holidays(year)
+			cal.IsWorkday(date(year, time.January, 1))
+		}(year)
+	}
+	wg.Wait()
+
+	// assert
+	assert.Len(t, cal.Holidays(2022), 14)
+}
+
+func TestHolidaysObservedNearestAcrossYears(t *testing.T) {
+	// arrange
+	cal, _ := Get("us", nil)
+
+	// act
+	holiday, ok := cal.Holiday(date(2021, time.December, 31))
+
+	// assert
+	assert.True(t, ok)
+	assert.Equal(t, "New Year's Day (observed)", holiday.Name)
+}
+
+func TestAddWorkdays(t *testing.T) {
+	// arrange
+	cal, _ := Get("pt", nil)
+
+	// act
+	forward := cal.AddWorkdays(date(2022, time.November, 30), 2)
+	backward := cal.AddWorkdays(date(2022, time.December, 9), -2)
+
+	// assert: December 1st and 8th are holidays
+	assert.Equal(t, date(2022, time.December, 5), forward)
+	assert.Equal(t, date(2022, time.December, 6), backward)
+}
+
+func TestWorkdaysBetween(t *testing.T) {
+	// arrange
+	cal, _ := Get("pt", nil)
+
+	// act
+	december := cal.WorkdaysBetween(date(2022, time.December, 1), date(2023, time.January, 1))
+	reversed := cal.WorkdaysBetween(date(2023, time.January, 1), date(2022, time.December, 1))
+
+	// assert: 22 weekdays minus December 1st and 8th (Christmas is on a Sunday)
+	assert.Equal(t, 20, december)
+	assert.Equal(t, -20, reversed)
+}
+
+func TestGetCustomCalendar(t *testing.T) {
+	// arrange
+	custom := map[string]Calendar{
+		"lisbon": {
+			Extends: "pt",
+			Rules:   []Rule{{Name: "St. Anthony's Day", Type: RuleFixed, Month: 6, Day: 13}},
+		},
+		"sixdays": {
+			Weekend: []string{"sun"},
+		},
+	}
+
+	// act
+	lisbon, lisbonErr := Get("Lisbon", custom)
+	sixDays, sixDaysErr := Get("sixdays", custom)
+
+	// assert
+	assert.Nil(t, lisbonErr)
+	assert.Nil(t, sixDaysErr)
+	assert.Len(t, lisbon.Holidays(2022), 15)
+	assert.False(t, lisbon.IsWorkday(date(2022, time.June, 13)))
+	assert.True(t, sixDays.IsWorkday(date(2022, time.January, 1)))
+}
+
+func TestGetInvalidCalendar(t *testing.T) {
+	testCases := []struct {
+		name     string
+		custom   map[string]Calendar
+		expected string
+	}{
+		{name: "xx", expected: "unknown calendar"},
+		{name: "loop", custom: map[string]Calendar{"loop": {Extends: "loop"}}, expected: "extends itself"},
+		{name: "bad", custom: map[string]Calendar{"bad": {Rules: []Rule{{Name: "x", Type: "lunar"}}}}, expected: "unknown type"},
+		{name: "bad", custom: map[string]Calendar{"bad": {Weekend: []string{"caturday"}}}, expected: "invalid weekday"},
+		{name: "bad", custom: map[string]Calendar{"bad": {Weekend: []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}}}, expected: "every day of the week"},
+	}
+
+	for _, tc := range testCases {
+		// act
+		_, err := Get(tc.name, tc.custom)
+
+		// assert
+		assert.NotNil(t, err, tc.name)
+		assert.Contains(t, err.Error(), tc.expected)
+	}
+}
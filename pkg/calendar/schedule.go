@@ -0,0 +1,203 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSchedule is the schedule used when none is given.
+const DefaultSchedule = "mon-fri 09:00-17:00"
+
+// Interval is a period of the day, as offsets from midnight.
+type Interval struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Schedule holds the working intervals of each day of the week.
+type Schedule struct {
+	days [7][]Interval
+}
+
+// ParseSchedule parses a weekly schedule with groups of days and their
+// working intervals separated by semicolons, e.g.
+//
+//	mon-thu 09:00-13:00,14:00-18:00; fri 09:00-15:00
+func ParseSchedule(spec string) (Schedule, error) {
+	schedule := Schedule{}
+
+	for _, group := range strings.Split(spec, ";") {
+		fields := strings.Fields(strings.Replace(group, ",", " ", -1))
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 1 {
+			return Schedule{}, fmt.Errorf("invalid schedule %q: %q has no working hours", spec, strings.TrimSpace(group))
+		}
+
+		weekdays, err := parseWeekdays(fields[0])
+		if err != nil {
+			return Schedule{}, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+
+		intervals := []Interval{}
+		for _, field := range fields[1:] {
+			interval, err := parseInterval(field)
+			if err != nil {
+				return Schedule{}, fmt.Errorf("invalid schedule %q: %w", spec, err)
+			}
+			intervals = append(intervals, interval)
+		}
+
+		for _, weekday := range weekdays {
+			schedule.days[weekday] = append(schedule.days[weekday], intervals...)
+		}
+	}
+
+	for weekday := range schedule.days {
+		intervals := schedule.days[weekday]
+		sort.Slice(intervals, func(i, j int) bool { return intervals[i].Start < intervals[j].Start })
+		for i := 1; i < len(intervals); i++ {
+			if intervals[i].Start < intervals[i-1].End {
+				return Schedule{}, fmt.Errorf("invalid schedule %q: overlapping intervals on %s", spec, time.Weekday(weekday))
+			}
+		}
+	}
+
+	return schedule, nil
+}
+
+// parseWeekdays parses "mon", "mon-fri" or "mon+wed" into weekdays.
+func parseWeekdays(text string) ([]time.Weekday, error) {
+	weekdays := []time.Weekday{}
+
+	for _, item := range strings.Split(text, "+") {
+		bounds := strings.SplitN(item, "-", 2)
+		from, err := ParseWeekday(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = ParseWeekday(bounds[1]); err != nil {
+				return nil, err
+			}
+		}
+
+		for weekday := from; ; weekday = (weekday + 1) % 7 {
+			weekdays = append(weekdays, weekday)
+			if weekday == to {
+				break
+			}
+		}
+	}
+
+	return weekdays, nil
+}
+
+// parseInterval parses "09:00-17:30"; 24:00 is accepted as the end of day.
+func parseInterval(text string) (Interval, error) {
+	bounds := strings.SplitN(text, "-", 2)
+	if len(bounds) != 2 {
+		return Interval{}, fmt.Errorf("invalid interval %q", text)
+	}
+
+	start, err := ParseClock(bounds[0])
+	if err != nil {
+		return Interval{}, err
+	}
+	end, err := ParseClock(bounds[1])
+	if err != nil {
+		return Interval{}, err
+	}
+	if end <= start {
+		return Interval{}, fmt.Errorf("invalid interval %q, the end must be after the start", text)
+	}
+
+	return Interval{Start: start, End: end}, nil
+}
+
+// ParseClock parses a time of the day such as 09:30 into the offset from
+// midnight.
+func ParseClock(text string) (time.Duration, error) {
+	parts := strings.SplitN(text, ":", 2)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time %q", text)
+	}
+
+	hour, hourErr := strconv.Atoi(parts[0])
+	minute, minuteErr := strconv.Atoi(parts[1])
+	if hourErr != nil || minuteErr != nil || hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return 0, fmt.Errorf("invalid time %q", text)
+	}
+
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
+
+// Intervals returns the working intervals of a weekday.
+func (s Schedule) Intervals(weekday time.Weekday) []Interval {
+	return s.days[weekday]
+}
+
+// IsEmpty tells if the schedule has no working intervals at all.
+func (s Schedule) IsEmpty() bool {
+	for _, intervals := range s.days {
+		if len(intervals) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WorkingPeriod is a working interval on a given day.
+type WorkingPeriod struct {
+	Start time.Time
+	End   time.Time
+}
+
+// WorkingPeriods returns the working periods of a local date in loc,
+// skipping holidays when a calendar is given. Intervals are converted
+// using the wall clock, so they are shorter or longer on daylight saving
+// changes.
+func (s Schedule) WorkingPeriods(date time.Time, cal *Calendar, loc *time.Location) []WorkingPeriod {
+	if cal != nil {
+		if _, isHoliday := cal.Holiday(date); isHoliday {
+			return nil
+		}
+	}
+
+	year, month, day := date.Date()
+	periods := []WorkingPeriod{}
+	for _, interval := range s.days[date.Weekday()] {
+		periods = append(periods, WorkingPeriod{
+			Start: wallClock(year, month, day, interval.Start, loc),
+			End:   wallClock(year, month, day, interval.End, loc),
+		})
+	}
+
+	return periods
+}
+
+func wallClock(year int, month time.Month, day int, offset time.Duration, loc *time.Location) time.Time {
+	minutes := int(offset / time.Minute)
+	return time.Date(year, month, day, minutes/60, minutes%60, 0, 0, loc)
+}
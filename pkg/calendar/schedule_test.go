@@ -0,0 +1,85 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	// act
+	schedule, err := ParseSchedule("mon-thu 09:00-13:00,14:00-18:00; fri+sat 09:00-12:00; sun 22:00-24:00")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, []Interval{
+		{Start: 9 * time.Hour, End: 13 * time.Hour},
+		{Start: 14 * time.Hour, End: 18 * time.Hour},
+	}, schedule.Intervals(time.Wednesday))
+	assert.Equal(t, []Interval{{Start: 9 * time.Hour, End: 12 * time.Hour}}, schedule.Intervals(time.Saturday))
+	assert.Equal(t, []Interval{{Start: 22 * time.Hour, End: 24 * time.Hour}}, schedule.Intervals(time.Sunday))
+}
+
+func TestParseScheduleWrappingWeekdays(t *testing.T) {
+	// act
+	schedule, err := ParseSchedule("sat-mon 10:00-11:00")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Len(t, schedule.Intervals(time.Sunday), 1)
+	assert.Len(t, schedule.Intervals(time.Monday), 1)
+	assert.Len(t, schedule.Intervals(time.Tuesday), 0)
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	testCases := map[string]string{
+		"mon-fri":                       "has no working hours",
+		"mon-xyz 09:00-10:00":           "invalid weekday",
+		"mon 09:00":                     "invalid interval",
+		"mon 25:00-26:00":               "invalid time",
+		"mon 09:00-12:00,11:00-13:00":   "overlapping intervals",
+		"mon 09:00-12:00; mon 11:00-13": "invalid time",
+	}
+
+	for spec, expected := range testCases {
+		// act
+		_, err := ParseSchedule(spec)
+
+		// assert
+		assert.NotNil(t, err, spec)
+		assert.Contains(t, err.Error(), expected, spec)
+	}
+}
+
+func TestWorkingPeriods(t *testing.T) {
+	// arrange
+	lisbon, _ := time.LoadLocation("Europe/Lisbon")
+	schedule, _ := ParseSchedule("mon-sun 00:00-03:00")
+	cal, _ := Get("pt", nil)
+
+	// act: daylight saving starts at 01:00 on March 27th 2022
+	periods := schedule.WorkingPeriods(time.Date(2022, time.March, 27, 0, 0, 0, 0, lisbon), nil, lisbon)
+	holiday := schedule.WorkingPeriods(time.Date(2022, time.December, 25, 0, 0, 0, 0, lisbon), cal, lisbon)
+
+	// assert
+	assert.Len(t, periods, 1)
+	assert.Equal(t, 2*time.Hour, periods[0].End.Sub(periods[0].Start))
+	assert.Len(t, holiday, 0)
+}
@@ -0,0 +1,279 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package decimal
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact rational number, for money arithmetic without the
+// representation errors of floats. Operations never round: rounding only
+// happens when asked for with Round.
+//
+// The zero value is 0. Decimals are immutable, so they can be copied.
+type Decimal struct {
+	r *big.Rat
+}
+
+// RoundingMode tells how to round a value halfway between two others.
+type RoundingMode int
+
+const (
+	// HalfEven rounds halves to the even neighbour (banker's rounding).
+	HalfEven RoundingMode = iota
+	// HalfUp rounds halves away from zero.
+	HalfUp
+	// Truncate discards the digits, rounding towards zero.
+	Truncate
+)
+
+var roundingModeNames = []string{"half-even", "half-up", "truncate"}
+
+// stringPlaces is the number of decimal places used to print values without
+// a finite decimal representation, like 1/3.
+const stringPlaces = 16
+
+var (
+	Zero    = New(0, 0)
+	One     = New(1, 0)
+	Hundred = New(100, 0)
+)
+
+// New returns value * 10^exp, e.g. New(150050, -2) is 1500.50.
+func New(value int64, exp int) Decimal {
+	r := new(big.Rat).SetInt64(value)
+	scale := new(big.Rat).SetInt(pow10(abs(exp)))
+	if exp < 0 {
+		r.Quo(r, scale)
+	} else {
+		r.Mul(r, scale)
+	}
+	return Decimal{r: r}
+}
+
+// NewFromInt returns an integer as a decimal.
+func NewFromInt(value int64) Decimal {
+	return New(value, 0)
+}
+
+// NewFromFloat returns the shortest decimal that converts back to the float,
+// so NewFromFloat(0.1) is exactly 0.1. It panics for NaN and infinities.
+func NewFromFloat(value float64) Decimal {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		panic(fmt.Sprintf("decimal: can't convert %v", value))
+	}
+	d, _ := Parse(strconv.FormatFloat(value, 'g', -1, 64))
+	return d
+}
+
+// Parse returns the decimal of a string like 1500.50, -3 or 1.5e3.
+func Parse(value string) (Decimal, error) {
+	r, ok := new(big.Rat).SetString(value)
+	if !ok || strings.Contains(value, "/") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", value)
+	}
+	return Decimal{r: r}, nil
+}
+
+// ParseRoundingMode returns the rounding mode with a name, which can be
+// half-even, half-up or truncate.
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	for i, n := range roundingModeNames {
+		if strings.EqualFold(name, n) {
+			return RoundingMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid rounding mode %q, must be %s", name, strings.Join(roundingModeNames, ", "))
+}
+
+func (m RoundingMode) String() string {
+	return roundingModeNames[m]
+}
+
+func (d Decimal) rat() *big.Rat {
+	if d.r == nil {
+		return new(big.Rat)
+	}
+	return d.r
+}
+
+func (d Decimal) Add(e Decimal) Decimal {
+	return Decimal{r: new(big.Rat).Add(d.rat(), e.rat())}
+}
+
+func (d Decimal) Sub(e Decimal) Decimal {
+	return Decimal{r: new(big.Rat).Sub(d.rat(), e.rat())}
+}
+
+func (d Decimal) Mul(e Decimal) Decimal {
+	return Decimal{r: new(big.Rat).Mul(d.rat(), e.rat())}
+}
+
+// Div returns d / e. It panics if e is zero.
+func (d Decimal) Div(e Decimal) Decimal {
+	return Decimal{r: new(big.Rat).Quo(d.rat(), e.rat())}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{r: new(big.Rat).Neg(d.rat())}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{r: new(big.Rat).Abs(d.rat())}
+}
+
+// Pow returns d^n. It panics if d is zero and n is negative.
+func (d Decimal) Pow(n int) Decimal {
+	r := d.rat()
+	num := new(big.Int).Exp(r.Num(), big.NewInt(int64(abs(n))), nil)
+	den := new(big.Int).Exp(r.Denom(), big.NewInt(int64(abs(n))), nil)
+	if n < 0 {
+		num, den = den, num
+	}
+	return Decimal{r: new(big.Rat).SetFrac(num, den)}
+}
+
+// Cmp returns -1, 0 or +1 when d is less than, equal to or greater than e.
+func (d Decimal) Cmp(e Decimal) int {
+	return d.rat().Cmp(e.rat())
+}
+
+// Sign returns -1, 0 or +1 when d is negative, zero or positive.
+func (d Decimal) Sign() int {
+	return d.rat().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Round returns d rounded to a number of decimal places.
+func (d Decimal) Round(places int, mode RoundingMode) Decimal {
+	r := d.rat()
+	scale := pow10(places)
+
+	// q and rem are the quotient and remainder of d * 10^places
+	num := new(big.Int).Mul(r.Num(), scale)
+	q, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+
+	if rem.Sign() != 0 && mode != Truncate {
+		// compares the remainder with half of the denominator
+		half := new(big.Int).Abs(rem)
+		half.Lsh(half, 1)
+		c := half.Cmp(r.Denom())
+		if c > 0 || c == 0 && (mode == HalfUp || q.Bit(0) == 1) {
+			q.Add(q, big.NewInt(int64(r.Sign())))
+		}
+	}
+
+	return Decimal{r: new(big.Rat).SetFrac(q, scale)}
+}
+
+// Floor returns the greatest integer less than or equal to d.
+func (d Decimal) Floor() Decimal {
+	r := d.rat()
+	// the Euclidean division rounds down when the denominator is positive
+	return Decimal{r: new(big.Rat).SetInt(new(big.Int).Div(r.Num(), r.Denom()))}
+}
+
+// Ceil returns the least integer greater than or equal to d.
+func (d Decimal) Ceil() Decimal {
+	return d.Neg().Floor().Neg()
+}
+
+// Int64 returns the integer part of d, truncated towards zero.
+func (d Decimal) Int64() int64 {
+	r := d.rat()
+	return new(big.Int).Quo(r.Num(), r.Denom()).Int64()
+}
+
+// Float64 returns the nearest float to d.
+func (d Decimal) Float64() float64 {
+	f, _ := d.rat().Float64()
+	return f
+}
+
+// String returns d with the decimal places needed to represent it exactly,
+// or rounded to 16 decimal places when it has no finite representation.
+func (d Decimal) String() string {
+	r := d.rat()
+	return r.FloatString(decimalPlaces(r.Denom()))
+}
+
+// StringFixed returns d with a number of decimal places, e.g. 1500.50. The
+// value should have been rounded to those places.
+func (d Decimal) StringFixed(places int) string {
+	return d.rat().FloatString(places)
+}
+
+// MarshalJSON encodes d as a JSON number.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodes d from a JSON number or string.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	value, err := Parse(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*d = value
+	return nil
+}
+
+// decimalPlaces returns the number of decimal places of a fraction with a
+// denominator, which is finite when the denominator is 2^a * 5^b.
+func decimalPlaces(den *big.Int) int {
+	rest := new(big.Int).Set(den)
+	twos := int(rest.TrailingZeroBits())
+	rest.Rsh(rest, uint(twos))
+
+	fives := 0
+	five := big.NewInt(5)
+	m := new(big.Int)
+	for {
+		q, r := new(big.Int).QuoRem(rest, five, m)
+		if r.Sign() != 0 {
+			break
+		}
+		rest = q
+		fives++
+	}
+
+	if rest.Cmp(big.NewInt(1)) != 0 {
+		return stringPlaces
+	}
+	if twos > fives {
+		return twos
+	}
+	return fives
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,156 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package decimal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParse(t *testing.T, value string) Decimal {
+	d, err := Parse(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestArithmeticIsExact(t *testing.T) {
+	// arrange
+	tenCents := NewFromFloat(0.1)
+
+	// act
+	sum := Zero
+	for i := 0; i < 10; i++ {
+		sum = sum.Add(tenCents)
+	}
+
+	// assert
+	assert.Equal(t, 0, sum.Cmp(One))
+	assert.Equal(t, "0.3", NewFromFloat(0.1).Add(NewFromFloat(0.2)).String())
+	assert.Equal(t, "1500.5", New(150050, -2).String())
+	assert.Equal(t, "1.157625", mustParse(t, "1.05").Pow(3).String())
+	assert.Equal(t, "0.0016", mustParse(t, "25").Pow(-2).String())
+	assert.Equal(t, "0.3333333333333333", One.Div(NewFromInt(3)).String())
+	assert.Equal(t, "-2500", mustParse(t, "-2.5e3").String())
+	assert.Equal(t, "0", Decimal{}.String())
+}
+
+func TestRound(t *testing.T) {
+	testCases := []struct {
+		value    string
+		mode     RoundingMode
+		expected string
+	}{
+		{"1157.625", HalfEven, "1157.62"},
+		{"1157.635", HalfEven, "1157.64"},
+		{"1157.625", HalfUp, "1157.63"},
+		{"1157.629", Truncate, "1157.62"},
+		{"-1157.625", HalfEven, "-1157.62"},
+		{"-1157.625", HalfUp, "-1157.63"},
+		{"-1157.629", Truncate, "-1157.62"},
+		{"1157.6251", HalfEven, "1157.63"},
+		{"0.005", HalfUp, "0.01"},
+		{"0.004", HalfUp, "0"},
+	}
+
+	for _, tc := range testCases {
+		// act
+		actual := mustParse(t, tc.value).Round(2, tc.mode)
+
+		// assert
+		assert.Equal(t, tc.expected, actual.String(), tc)
+	}
+}
+
+func TestRoundRepeatingFraction(t *testing.T) {
+	// arrange
+	twoThirds := NewFromInt(2).Div(NewFromInt(3))
+
+	// act
+	actual := twoThirds.Round(2, Truncate)
+
+	// assert
+	assert.Equal(t, "0.66", actual.String())
+	assert.Equal(t, "0.67", twoThirds.Round(2, HalfEven).String())
+	assert.Equal(t, "0.70", New(7, -1).StringFixed(2))
+}
+
+func TestIntegerParts(t *testing.T) {
+	testCases := []struct {
+		value string
+		floor string
+		ceil  string
+		int64 int64
+	}{
+		{"2.5", "2", "3", 2},
+		{"-2.5", "-3", "-2", -2},
+		{"4", "4", "4", 4},
+		{"-0.1", "-1", "0", 0},
+	}
+
+	for _, tc := range testCases {
+		// act
+		d := mustParse(t, tc.value)
+
+		// assert
+		assert.Equal(t, tc.floor, d.Floor().String(), tc.value)
+		assert.Equal(t, tc.ceil, d.Ceil().String(), tc.value)
+		assert.Equal(t, tc.int64, d.Int64(), tc.value)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, value := range []string{"", "abc", "1/3", "1,5"} {
+		// act
+		_, err := Parse(value)
+
+		// assert
+		assert.EqualError(t, err, `invalid decimal "`+value+`"`)
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	// act
+	mode, err := ParseRoundingMode("Half-Up")
+	_, invalidErr := ParseRoundingMode("ceil")
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, HalfUp, mode)
+	assert.Equal(t, "half-up", mode.String())
+	assert.EqualError(t, invalidErr, `invalid rounding mode "ceil", must be half-even, half-up, truncate`)
+}
+
+func TestJSON(t *testing.T) {
+	// arrange
+	value := struct {
+		Amount Decimal
+	}{}
+
+	// act
+	err := json.Unmarshal([]byte(`{"Amount": 1500.50}`), &value)
+	data, _ := json.Marshal(value)
+
+	// assert
+	assert.Nil(t, err)
+	assert.Equal(t, `{"Amount":1500.5}`, string(data))
+	assert.Nil(t, json.Unmarshal([]byte(`{"Amount": "0.1"}`), &value))
+	assert.Equal(t, 0.1, value.Amount.Float64())
+}
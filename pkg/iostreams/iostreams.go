@@ -22,12 +22,18 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
 )
 
 type IOStreams struct {
 	In     io.ReadCloser
 	Out    io.Writer
 	ErrOut io.Writer
+
+	// OutIsTerminal enables interactive output, like live updates.
+	OutIsTerminal bool
 }
 
 func Test() (*IOStreams, *bytes.Buffer, *bytes.Buffer, *bytes.Buffer) {
@@ -42,6 +48,15 @@ func Test() (*IOStreams, *bytes.Buffer, *bytes.Buffer, *bytes.Buffer) {
 	}, in, out, errOut
 }
 
+// IsTerminal tells if a file is a terminal (a character device).
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 func (iostreams *IOStreams) PrintOutput(v interface{}) error {
 	res, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
@@ -52,3 +67,15 @@ func (iostreams *IOStreams) PrintOutput(v interface{}) error {
 
 	return nil
 }
+
+// PrintTable prints the rows aligned in columns, below the headers.
+func (iostreams *IOStreams) PrintTable(headers []string, rows [][]string) error {
+	w := tabwriter.NewWriter(iostreams.Out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return w.Flush()
+}
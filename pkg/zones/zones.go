@@ -0,0 +1,180 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package zones
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	// embeds the IANA timezone database, so zones can be loaded on systems
+	// without it
+	_ "time/tzdata"
+)
+
+// The zone.tab and iso3166.tab tables of the IANA timezone database, which
+// are in the public domain.
+
+//go:embed data/zone.tab
+var zoneTab []byte
+
+//go:embed data/iso3166.tab
+var iso3166Tab []byte
+
+var coordinatesRegexp = regexp.MustCompile(`^([+-]\d{4}(?:\d{2})?)([+-]\d{5}(?:\d{2})?)$`)
+
+// Zone is a timezone of a country, with the coordinates of its principal
+// location.
+type Zone struct {
+	Name        string
+	Country     string
+	CountryName string
+	Latitude    float64
+	Longitude   float64
+	Comment     string
+}
+
+var zones []Zone
+var countries map[string]string
+
+func init() {
+	countries = map[string]string{}
+	for _, fields := range readTab(iso3166Tab) {
+		countries[fields[0]] = fields[1]
+	}
+
+	for _, fields := range readTab(zoneTab) {
+		zone := Zone{
+			Name:        fields[2],
+			Country:     fields[0],
+			CountryName: countries[fields[0]],
+		}
+		zone.Latitude, zone.Longitude = parseCoordinates(fields[1])
+		if len(fields) > 3 {
+			zone.Comment = fields[3]
+		}
+		zones = append(zones, zone)
+	}
+}
+
+// readTab returns the tab separated fields of the lines of a table,
+// skipping the comments.
+func readTab(data []byte) [][]string {
+	rows := [][]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rows = append(rows, strings.Split(line, "\t"))
+	}
+
+	return rows
+}
+
+// parseCoordinates parses ISO 6709 coordinates, ±DDMM±DDDMM or
+// ±DDMMSS±DDDMMSS, into decimal degrees.
+func parseCoordinates(value string) (float64, float64) {
+	matches := coordinatesRegexp.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, 0
+	}
+	return parseDegrees(matches[1], 2), parseDegrees(matches[2], 3)
+}
+
+func parseDegrees(value string, digits int) float64 {
+	degrees, _ := strconv.Atoi(value[1 : 1+digits])
+	minutes, _ := strconv.Atoi(value[1+digits : 3+digits])
+	seconds := 0
+	if len(value) > 3+digits {
+		seconds, _ = strconv.Atoi(value[3+digits:])
+	}
+
+	result := float64(degrees) + float64(minutes)/60 + float64(seconds)/3600
+	if value[0] == '-' {
+		return -result
+	}
+	return result
+}
+
+// All returns the zones of every country, ordered by country code.
+func All() []Zone {
+	return append([]Zone{}, zones...)
+}
+
+// ByCountry returns the zones of a country, given its ISO 3166 alpha-2
+// code, the principal one first.
+func ByCountry(code string) ([]Zone, error) {
+	code = strings.ToUpper(code)
+	if _, ok := countries[code]; !ok {
+		return nil, fmt.Errorf("unknown country %q", code)
+	}
+
+	result := []Zone{}
+	for _, zone := range zones {
+		if zone.Country == code {
+			result = append(result, zone)
+		}
+	}
+
+	return result, nil
+}
+
+// Find returns the zone with the given name, if it belongs to a country.
+func Find(name string) (Zone, bool) {
+	for _, zone := range zones {
+		if zone.Name == name {
+			return zone, true
+		}
+	}
+	return Zone{}, false
+}
+
+// Nearest returns the zone whose principal location is the closest to the
+// given coordinates, in decimal degrees. It is a good guess of the timezone
+// of a place, except near borders.
+func Nearest(latitude, longitude float64) Zone {
+	nearest := zones[0]
+	nearestDistance := math.Inf(1)
+
+	for _, zone := range zones {
+		distance := centralAngle(latitude, longitude, zone.Latitude, zone.Longitude)
+		if distance < nearestDistance {
+			nearest, nearestDistance = zone, distance
+		}
+	}
+
+	return nearest
+}
+
+// centralAngle returns the angle between two points of a sphere, using the
+// haversine formula.
+func centralAngle(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	phi1, phi2 := latitude1*math.Pi/180, latitude2*math.Pi/180
+	deltaPhi := phi2 - phi1
+	deltaLambda := (longitude2 - longitude1) * math.Pi / 180
+
+	a := math.Pow(math.Sin(deltaPhi/2), 2) + math.Cos(phi1)*math.Cos(phi2)*math.Pow(math.Sin(deltaLambda/2), 2)
+	return 2 * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2021 Renato Torres <renato.torres@pm.me>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Lesser General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Lesser General Public License for more details.
+
+You should have received a copy of the GNU Lesser General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package zones
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByCountry(t *testing.T) {
+	// act
+	portugal, err := ByCountry("pt")
+
+	// assert
+	assert.Nil(t, err)
+	names := []string{}
+	for _, zone := range portugal {
+		names = append(names, zone.Name)
+	}
+	assert.Equal(t, []string{"Europe/Lisbon", "Atlantic/Madeira", "Atlantic/Azores"}, names)
+	assert.Equal(t, "Portugal", portugal[0].CountryName)
+	assert.Equal(t, "Madeira Islands", portugal[1].Comment)
+}
+
+func TestByCountryUnknown(t *testing.T) {
+	// act
+	_, err := ByCountry("XX")
+
+	// assert
+	assert.EqualError(t, err, `unknown country "XX"`)
+}
+
+func TestFind(t *testing.T) {
+	// act
+	lisbon, ok := Find("Europe/Lisbon")
+	_, utcOk := Find("UTC")
+
+	// assert
+	assert.True(t, ok)
+	assert.InDelta(t, 38.7167, lisbon.Latitude, 0.0001)
+	assert.InDelta(t, -9.1333, lisbon.Longitude, 0.0001)
+	assert.False(t, utcOk)
+}
+
+func TestNearest(t *testing.T) {
+	// act
+	porto := Nearest(41.15, -8.61)
+	funchal := Nearest(32.65, -16.91)
+	fiji := Nearest(-17.7, 179.9)
+
+	// assert
+	assert.Equal(t, "Europe/Lisbon", porto.Name)
+	assert.Equal(t, "Atlantic/Madeira", funchal.Name)
+	assert.Equal(t, "Pacific/Fiji", fiji.Name)
+}
+
+func TestParseCoordinatesWithSeconds(t *testing.T) {
+	// act
+	latitude, longitude := parseCoordinates("-0754+11049")
+	latitudeSeconds, longitudeSeconds := parseCoordinates("+404251-0740023")
+
+	// assert
+	assert.InDelta(t, -7.9, latitude, 0.0001)
+	assert.InDelta(t, 110.8167, longitude, 0.0001)
+	assert.InDelta(t, 40.7142, latitudeSeconds, 0.0001)
+	assert.InDelta(t, -74.0064, longitudeSeconds, 0.0001)
+}
+
+func TestAllZonesLoad(t *testing.T) {
+	for _, zone := range All() {
+		// act
+		_, err := time.LoadLocation(zone.Name)
+
+		// assert
+		assert.Nil(t, err, zone.Name)
+	}
+}